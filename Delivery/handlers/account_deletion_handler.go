@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"g6_starter_project/Infrastructure/services"
+	usecases "g6_starter_project/Usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccountDeletionHandler struct {
+	accountDeletionUsecase *usecases.AccountDeletionUsecase
+	authCookies            *services.AuthCookies
+}
+
+func NewAccountDeletionHandler(accountDeletionUsecase *usecases.AccountDeletionUsecase, authCookies *services.AuthCookies) *AccountDeletionHandler {
+	return &AccountDeletionHandler{
+		accountDeletionUsecase: accountDeletionUsecase,
+		authCookies:            authCookies,
+	}
+}
+
+// RequestDeletion schedules the current user's account for deletion and signs them out everywhere
+func (h *AccountDeletionHandler) RequestDeletion(c *gin.Context) {
+	userID, exists := services.GinGetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deleteAfter, err := h.accountDeletionUsecase.RequestDeletion(userID, req.Password, req.Code, clientInfo(c, ""))
+	if err != nil {
+		switch {
+		case err.Error() == "password is incorrect", err.Error() == "invalid verification code":
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case err.Error() == "account deletion is already scheduled":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "too many"):
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if h.authCookies.Enabled() {
+		h.authCookies.Clear(c)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Your account will be deleted. Sign in before then to cancel.",
+		"delete_after": deleteAfter,
+	})
+}
+
+// CancelDeletion keeps the current user's account
+func (h *AccountDeletionHandler) CancelDeletion(c *gin.Context) {
+	userID, exists := services.GinGetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := h.accountDeletionUsecase.CancelDeletion(userID, clientInfo(c, "")); err != nil {
+		if err.Error() == "no account deletion is scheduled" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account deletion cancelled"})
+}
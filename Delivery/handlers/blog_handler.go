@@ -82,9 +82,11 @@ func (h *BlogHandler) UpdatePost(c *gin.Context) {
 	}
 
 	userIDHex, _ := c.Get("userID")
+	userRole, _ := c.Get("userRole")
 	requestingUserID, _ := primitive.ObjectIDFromHex(userIDHex.(string))
+	requestingUserRole := userRole.(string)
 
-	updatedPost, err := h.blogUsecase.UpdatePost(c.Request.Context(), postID, &updateData, requestingUserID)
+	updatedPost, err := h.blogUsecase.UpdatePost(c.Request.Context(), postID, &updateData, requestingUserID, requestingUserRole)
 	if err != nil {
 		if err.Error() == "post not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
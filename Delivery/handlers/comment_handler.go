@@ -3,6 +3,7 @@ package handlers
 import (
 	usecases "g6_starter_project/Usecases" // Make sure this import path is correct
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -43,3 +44,28 @@ func (h *CommentHandler) CreateComment(c *gin.Context) {
 
 	c.JSON(http.StatusCreated, comment)
 }
+
+// DeleteComment handles DELETE /blog/:id/comments/:commentId requests.
+func (h *CommentHandler) DeleteComment(c *gin.Context) {
+	userIDHex, exists := c.Get("userID") // From auth middleware
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	userRole, _ := c.Get("userRole")
+	userID, _ := primitive.ObjectIDFromHex(userIDHex.(string))
+
+	err := h.commentUsecase.DeleteComment(c.Request.Context(), c.Param("id"), c.Param("commentId"), userID, userRole.(string))
+	if err != nil {
+		if strings.Contains(err.Error(), "forbidden") {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		} else if strings.Contains(err.Error(), "invalid") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Comment deleted successfully"})
+}
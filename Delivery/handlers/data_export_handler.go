@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"g6_starter_project/Infrastructure/services"
+	usecases "g6_starter_project/Usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DataExportHandler struct {
+	dataExportUsecase *usecases.DataExportUsecase
+}
+
+func NewDataExportHandler(dataExportUsecase *usecases.DataExportUsecase) *DataExportHandler {
+	return &DataExportHandler{dataExportUsecase: dataExportUsecase}
+}
+
+// RequestExport starts building an export of the current user's data
+func (h *DataExportHandler) RequestExport(c *gin.Context) {
+	userID, exists := services.GinGetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	export, err := h.dataExportUsecase.RequestExport(userID, clientInfo(c, ""))
+	if err != nil {
+		switch {
+		case err.Error() == "an export is already being prepared":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "too many"):
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Your export is being prepared. We will email you a download link when it is ready.",
+		"export":  export,
+	})
+}
+
+// Download serves the archive an emailed download link points to
+func (h *DataExportHandler) Download(c *gin.Context) {
+	export, err := h.dataExportUsecase.Download(c.Query("token"))
+	if err != nil {
+		if err.Error() == "download token is required" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	filename := fmt.Sprintf("data-export-%s.zip", export.CreatedAt.UTC().Format("2006-01-02"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/zip", export.Archive)
+}
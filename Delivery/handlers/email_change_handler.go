@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"g6_starter_project/Infrastructure/services"
+	usecases "g6_starter_project/Usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EmailChangeHandler struct {
+	emailChangeUsecase *usecases.EmailChangeUsecase
+}
+
+func NewEmailChangeHandler(emailChangeUsecase *usecases.EmailChangeUsecase) *EmailChangeHandler {
+	return &EmailChangeHandler{
+		emailChangeUsecase: emailChangeUsecase,
+	}
+}
+
+// RequestChange asks for the current user's email to be changed
+func (h *EmailChangeHandler) RequestChange(c *gin.Context) {
+	userID, exists := services.GinGetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		NewEmail string `json:"new_email" binding:"required,email"`
+		Password string `json:"password" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pending, err := h.emailChangeUsecase.RequestChange(userID, req.Password, req.NewEmail)
+	if err != nil {
+		switch {
+		case err.Error() == "password is incorrect":
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case err.Error() == "email already exists":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "too many"):
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "failed to"):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":       "Check your new email address to confirm the change",
+		"pending_email": pending,
+	})
+}
+
+// CancelOwnChange drops the current user's pending email change
+func (h *EmailChangeHandler) CancelOwnChange(c *gin.Context) {
+	userID, exists := services.GinGetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := h.emailChangeUsecase.CancelOwnChange(userID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email change cancelled"})
+}
+
+// ConfirmChange applies the change when the link sent to the new address is followed
+func (h *EmailChangeHandler) ConfirmChange(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+
+	if err := h.emailChangeUsecase.ConfirmChange(token); err != nil {
+		if err.Error() == "email already exists" {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email changed successfully. Please log in again."})
+}
+
+// CancelChange drops a pending change when the link sent to the old address is followed
+func (h *EmailChangeHandler) CancelChange(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+
+	if err := h.emailChangeUsecase.CancelChange(token); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email change cancelled"})
+}
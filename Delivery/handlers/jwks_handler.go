@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"net/http"
+
+	"g6_starter_project/Infrastructure/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSHandler struct {
+	keyManager *services.KeyManager
+}
+
+func NewJWKSHandler(keyManager *services.KeyManager) *JWKSHandler {
+	return &JWKSHandler{
+		keyManager: keyManager,
+	}
+}
+
+// GetJWKS publishes the public keys other services use to verify our tokens
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	// Short cache so verifiers pick up a rotated key quickly
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keyManager.JWKS())
+}
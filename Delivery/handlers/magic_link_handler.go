@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"g6_starter_project/Infrastructure/services"
+	usecases "g6_starter_project/Usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+// magicLinkNonceCookie binds a sign-in link to the browser that requested it
+const magicLinkNonceCookie = "magic_link_nonce"
+
+type MagicLinkHandler struct {
+	magicLinkUsecase *usecases.MagicLinkUsecase
+	authCookies      *services.AuthCookies
+}
+
+func NewMagicLinkHandler(magicLinkUsecase *usecases.MagicLinkUsecase, authCookies *services.AuthCookies) *MagicLinkHandler {
+	return &MagicLinkHandler{
+		magicLinkUsecase: magicLinkUsecase,
+		authCookies:      authCookies,
+	}
+}
+
+// RequestLink emails a sign-in link and sets the nonce cookie on this browser
+func (h *MagicLinkHandler) RequestLink(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	nonce, err := h.magicLinkUsecase.RequestLink(req.Email, c.ClientIP())
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "too many") {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		} else if err.Error() == "invalid email format" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(magicLinkNonceCookie, nonce, int(usecases.MagicLinkTTL.Seconds()), "/auth/magic-link", "", c.Request.TLS != nil, true)
+	h.authCookies.RememberMode(c, "/auth/magic-link", int(usecases.MagicLinkTTL.Seconds()))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "If an account with that email exists, a sign-in link has been sent",
+	})
+}
+
+// Verify signs the user in when they follow the emailed link
+func (h *MagicLinkHandler) Verify(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+
+	nonce, _ := c.Cookie(magicLinkNonceCookie)
+
+	user, tokens, challenge, err := h.magicLinkUsecase.CompleteLogin(token, nonce, clientInfo(c, c.Query("device_label")))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The nonce has done its job
+	useCookies := h.authCookies.Requested(c)
+	c.SetCookie(magicLinkNonceCookie, "", -1, "/auth/magic-link", "", c.Request.TLS != nil, true)
+	h.authCookies.ForgetMode(c, "/auth/magic-link")
+
+	if challenge != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"mfa_required":        true,
+			"mfa_token":           challenge.Token,
+			"enrollment_required": challenge.EnrollmentRequired,
+		})
+		return
+	}
+
+	respondWithTokens(c, h.authCookies, useCookies, tokens, gin.H{"user": user})
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"g6_starter_project/Infrastructure/services"
+	usecases "g6_starter_project/Usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MFAHandler struct {
+	mfaUsecase  *usecases.MFAUsecase
+	authCookies *services.AuthCookies
+}
+
+func NewMFAHandler(mfaUsecase *usecases.MFAUsecase, authCookies *services.AuthCookies) *MFAHandler {
+	return &MFAHandler{
+		mfaUsecase:  mfaUsecase,
+		authCookies: authCookies,
+	}
+}
+
+// VerifyLogin completes a login with a TOTP or recovery code
+func (h *MFAHandler) VerifyLogin(c *gin.Context) {
+	var req struct {
+		MFAToken    string `json:"mfa_token" binding:"required"`
+		Code        string `json:"code" binding:"required"`
+		DeviceLabel string `json:"device_label"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, token, err := h.mfaUsecase.CompleteLogin(req.MFAToken, req.Code, clientInfo(c, req.DeviceLabel))
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "too many") {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	respondWithTokens(c, h.authCookies, h.authCookies.Requested(c), token, gin.H{"user": user})
+}
+
+// BeginLoginEnrollment starts the enrollment a role policy demands during login
+func (h *MFAHandler) BeginLoginEnrollment(c *gin.Context) {
+	var req struct {
+		MFAToken string `json:"mfa_token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	enrollment, err := h.mfaUsecase.BeginLoginEnrollment(req.MFAToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// ConfirmLoginEnrollment confirms the enrollment started during login and signs the user in
+func (h *MFAHandler) ConfirmLoginEnrollment(c *gin.Context) {
+	var req struct {
+		MFAToken    string `json:"mfa_token" binding:"required"`
+		Code        string `json:"code" binding:"required"`
+		DeviceLabel string `json:"device_label"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, token, recoveryCodes, err := h.mfaUsecase.ConfirmLoginEnrollment(req.MFAToken, req.Code, clientInfo(c, req.DeviceLabel))
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "too many") {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	respondWithTokens(c, h.authCookies, h.authCookies.Requested(c), token, gin.H{
+		"user":           user,
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+// BeginEnrollment returns a new TOTP secret and provisioning URI for the current user
+func (h *MFAHandler) BeginEnrollment(c *gin.Context) {
+	userID, exists := services.GinGetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	enrollment, err := h.mfaUsecase.BeginEnrollment(userID)
+	if err != nil {
+		if err.Error() == "two-factor authentication is already enabled" {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+// ConfirmEnrollment enables MFA for the current user and returns the recovery codes
+func (h *MFAHandler) ConfirmEnrollment(c *gin.Context) {
+	userID, exists := services.GinGetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	recoveryCodes, err := h.mfaUsecase.ConfirmEnrollment(userID, req.Code, clientInfo(c, ""))
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "too many") {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Two-factor authentication enabled",
+		"recovery_codes": recoveryCodes,
+	})
+}
+
+// Disable turns MFA off for the current user
+func (h *MFAHandler) Disable(c *gin.Context) {
+	userID, exists := services.GinGetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.mfaUsecase.Disable(userID, req.Code, clientInfo(c, "")); err != nil {
+		if err.Error() == "two-factor authentication is required for your role" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		} else if strings.HasPrefix(err.Error(), "too many") {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// GetPolicies lists the per-role MFA policies (admin only)
+func (h *MFAHandler) GetPolicies(c *gin.Context) {
+	policies, err := h.mfaUsecase.GetPolicies()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"policies": policies})
+}
+
+// SetPolicy requires or stops requiring MFA for a role (admin only)
+func (h *MFAHandler) SetPolicy(c *gin.Context) {
+	adminID, exists := services.GinGetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "admin authentication required"})
+		return
+	}
+
+	var req struct {
+		Required *bool `json:"required" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := h.mfaUsecase.SetPolicy(c.Param("role"), *req.Required, adminID)
+	if err != nil {
+		if err.Error() == "invalid role" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"policy": policy})
+}
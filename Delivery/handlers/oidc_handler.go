@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http"
+
+	"g6_starter_project/Infrastructure/services"
+	usecases "g6_starter_project/Usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+// oidcStateCookie ties a provider callback to the browser that began the sign-in
+const oidcStateCookie = "oidc_state"
+
+type OIDCHandler struct {
+	oidcUsecase *usecases.OIDCUsecase
+	authCookies *services.AuthCookies
+}
+
+func NewOIDCHandler(oidcUsecase *usecases.OIDCUsecase, authCookies *services.AuthCookies) *OIDCHandler {
+	return &OIDCHandler{
+		oidcUsecase: oidcUsecase,
+		authCookies: authCookies,
+	}
+}
+
+// ListProviders returns the identity providers users can sign in with
+func (h *OIDCHandler) ListProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": h.oidcUsecase.Providers()})
+}
+
+// Login redirects the user to the identity provider
+func (h *OIDCHandler) Login(c *gin.Context) {
+	authURL, state, err := h.oidcUsecase.BeginLogin(c.Param("provider"))
+	if err != nil {
+		if err.Error() == "unknown identity provider" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	// Lax, so the cookie comes back on the provider's top-level redirect
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state, int(usecases.OIDCStateTTL.Seconds()), "/auth/oidc", "", c.Request.TLS != nil, true)
+	h.authCookies.RememberMode(c, "/auth/oidc", int(usecases.OIDCStateTTL.Seconds()))
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback completes the sign-in when the identity provider redirects back
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	if providerError := c.Query("error"); providerError != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "identity provider returned an error: " + providerError})
+		return
+	}
+
+	state := c.Query("state")
+	code := c.Query("code")
+	if state == "" || code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "state and code are required"})
+		return
+	}
+
+	browserState, _ := c.Cookie(oidcStateCookie)
+
+	user, token, challenge, err := h.oidcUsecase.CompleteLogin(c.Param("provider"), state, browserState, code, clientInfo(c, c.Query("device_label")))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The state has done its job
+	useCookies := h.authCookies.Requested(c)
+	c.SetCookie(oidcStateCookie, "", -1, "/auth/oidc", "", c.Request.TLS != nil, true)
+	h.authCookies.ForgetMode(c, "/auth/oidc")
+
+	if challenge != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"mfa_required":        true,
+			"mfa_token":           challenge.Token,
+			"enrollment_required": challenge.EnrollmentRequired,
+		})
+		return
+	}
+
+	respondWithTokens(c, h.authCookies, useCookies, token, gin.H{"user": user})
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"g6_starter_project/Infrastructure/services"
+	usecases "g6_starter_project/Usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PersonalAccessTokenHandler struct {
+	tokenUsecase *usecases.PersonalAccessTokenUsecase
+}
+
+func NewPersonalAccessTokenHandler(tokenUsecase *usecases.PersonalAccessTokenUsecase) *PersonalAccessTokenHandler {
+	return &PersonalAccessTokenHandler{
+		tokenUsecase: tokenUsecase,
+	}
+}
+
+// CreateToken issues a personal access token for the current user
+func (h *PersonalAccessTokenHandler) CreateToken(c *gin.Context) {
+	userID, exists := services.GinGetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req struct {
+		Name          string   `json:"name" binding:"required"`
+		Scopes        []string `json:"scopes" binding:"required"`
+		ExpiresInDays int      `json:"expires_in_days"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, value, err := h.tokenUsecase.Create(userID, req.Name, req.Scopes, req.ExpiresInDays)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "failed to") {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"token":   value,
+		"details": token,
+	})
+}
+
+// ListTokens lists the current user's personal access tokens
+func (h *PersonalAccessTokenHandler) ListTokens(c *gin.Context) {
+	userID, exists := services.GinGetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	tokens, err := h.tokenUsecase.List(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tokens": tokens})
+}
+
+// RevokeToken deletes one of the current user's personal access tokens
+func (h *PersonalAccessTokenHandler) RevokeToken(c *gin.Context) {
+	userID, exists := services.GinGetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	err := h.tokenUsecase.Revoke(userID, c.Param("id"))
+	if err != nil {
+		if err.Error() == "token not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Token revoked successfully"})
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"g6_starter_project/Domain/entities"
+	"g6_starter_project/Infrastructure/services"
 	usecases "g6_starter_project/Usecases"
 
 	"github.com/gin-gonic/gin"
@@ -62,3 +63,38 @@ func (h *UserProfileHandler) UpdateMyProfile(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"user": updatedUser})
 }
+
+// ChangePassword changes the current user's password
+func (h *UserProfileHandler) ChangePassword(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	sessionID, _ := services.GinGetSessionID(c)
+
+	var req struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		NewPassword     string `json:"new_password" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.userProfileUsecase.ChangePassword(userID.(string), sessionID, req.CurrentPassword, req.NewPassword, clientInfo(c, ""))
+	if err != nil {
+		if respondPasswordPolicyError(c, err) {
+			return
+		}
+		if err.Error() == "current password is incorrect" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully. Other sessions have been signed out."})
+}
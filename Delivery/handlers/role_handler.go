@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"g6_starter_project/Domain/entities"
+	usecases "g6_starter_project/Usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RoleHandler struct {
+	roleUsecase *usecases.RoleUsecase
+}
+
+func NewRoleHandler(roleUsecase *usecases.RoleUsecase) *RoleHandler {
+	return &RoleHandler{
+		roleUsecase: roleUsecase,
+	}
+}
+
+// ListRoles returns every role and the permissions that can be granted
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	roles, err := h.roleUsecase.GetRoles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"roles":       roles,
+		"permissions": entities.AllPermissions,
+	})
+}
+
+// SaveRole creates a role or replaces its permissions
+func (h *RoleHandler) SaveRole(c *gin.Context) {
+	var req struct {
+		Description string   `json:"description"`
+		Permissions []string `json:"permissions"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := h.roleUsecase.SaveRole(c.Param("name"), req.Description, req.Permissions)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "failed to") {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"role": role})
+}
+
+// DeleteRole deletes a custom role
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	err := h.roleUsecase.DeleteRole(c.Param("name"))
+	if err != nil {
+		if err.Error() == "role not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role deleted successfully"})
+}
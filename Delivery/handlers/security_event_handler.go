@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+	"g6_starter_project/Infrastructure/services"
+	usecases "g6_starter_project/Usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SecurityEventHandler struct {
+	securityEventUsecase *usecases.SecurityEventUsecase
+}
+
+func NewSecurityEventHandler(securityEventUsecase *usecases.SecurityEventUsecase) *SecurityEventHandler {
+	return &SecurityEventHandler{
+		securityEventUsecase: securityEventUsecase,
+	}
+}
+
+// ListMyEvents returns the security history of the current user
+func (h *SecurityEventHandler) ListMyEvents(c *gin.Context) {
+	userID, exists := services.GinGetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	page, limit := pagination(c)
+	events, total, err := h.securityEventUsecase.ListForUser(userID, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events": events,
+		"total":  total,
+		"page":   page,
+		"limit":  limit,
+	})
+}
+
+// QueryEvents searches the audit log. Every filter is optional; from and to
+// are RFC 3339 times.
+func (h *SecurityEventHandler) QueryEvents(c *gin.Context) {
+	page, limit := pagination(c)
+	filter := entities.SecurityEventFilter{
+		ActorID:   c.Query("actor_id"),
+		TargetID:  c.Query("target_id"),
+		Type:      c.Query("type"),
+		Outcome:   c.Query("outcome"),
+		IPAddress: c.Query("ip"),
+		Page:      page,
+		Limit:     limit,
+	}
+
+	var ok bool
+	if filter.From, ok = timeQuery(c, "from"); !ok {
+		return
+	}
+	if filter.To, ok = timeQuery(c, "to"); !ok {
+		return
+	}
+
+	events, total, err := h.securityEventUsecase.Query(filter)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "failed to") {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events": events,
+		"total":  total,
+		"page":   filter.Page,
+		"limit":  filter.Limit,
+	})
+}
+
+// pagination reads the page and limit query parameters, defaulting to the first 20 results
+func pagination(c *gin.Context) (int64, int64) {
+	page, err := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "20"), 10, 64)
+	if err != nil || limit < 1 {
+		limit = 20
+	}
+	return page, limit
+}
+
+// timeQuery parses an optional RFC 3339 query parameter. It responds with an
+// error and returns false if the value is malformed.
+func timeQuery(c *gin.Context, param string) (*time.Time, bool) {
+	value := c.Query(param)
+	if value == "" {
+		return nil, true
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param + " time. Use RFC 3339, e.g. 2025-08-07T00:00:00Z"})
+		return nil, false
+	}
+	return &parsed, true
+}
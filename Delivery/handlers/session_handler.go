@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+
+	"g6_starter_project/Infrastructure/services"
+	usecases "g6_starter_project/Usecases"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionHandler struct {
+	tokenUsecase *usecases.TokenUsecase
+}
+
+func NewSessionHandler(tokenUsecase *usecases.TokenUsecase) *SessionHandler {
+	return &SessionHandler{
+		tokenUsecase: tokenUsecase,
+	}
+}
+
+// ListSessions returns the devices the current user is signed in on
+func (h *SessionHandler) ListSessions(c *gin.Context) {
+	userID, exists := services.GinGetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+	sessionID, _ := services.GinGetSessionID(c)
+
+	sessions, err := h.tokenUsecase.ListSessions(userID, sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// RevokeSession signs the current user out of one device
+func (h *SessionHandler) RevokeSession(c *gin.Context) {
+	userID, exists := services.GinGetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	err := h.tokenUsecase.RevokeSession(userID, c.Param("id"))
+	if err != nil {
+		if err.Error() == "session not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked successfully"})
+}
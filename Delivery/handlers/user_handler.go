@@ -1,8 +1,10 @@
 package handlers
 
 import (
-	"fmt"
+	"errors"
+	"io"
 	"net/http"
+	"strings"
 
 	"g6_starter_project/Domain/entities"
 	"g6_starter_project/Infrastructure/services"
@@ -14,21 +16,24 @@ import (
 type UserHandler struct {
 	userUsecase           *usecases.UserUsecase
 	passwordResetUsecase  *usecases.PasswordResetUsecase
+	authCookies           *services.AuthCookies
 }
 
 // NewUserHandler initializes a new UserHandler
-func NewUserHandler(userUC *usecases.UserUsecase, resetUC *usecases.PasswordResetUsecase) *UserHandler {
+func NewUserHandler(userUC *usecases.UserUsecase, resetUC *usecases.PasswordResetUsecase, authCookies *services.AuthCookies) *UserHandler {
 	return &UserHandler{
 		userUsecase:          userUC,
 		passwordResetUsecase: resetUC,
+		authCookies:          authCookies,
 	}
 }
 
 // Login handles user authentication and token generation
 func (h *UserHandler) Login(c *gin.Context) {
 	var loginRequest struct {
-		Email    string `json:"email" binding:"required,email"`
-		Password string `json:"password" binding:"required"`
+		Email       string `json:"email" binding:"required,email"`
+		Password    string `json:"password" binding:"required"`
+		DeviceLabel string `json:"device_label"`
 	}
 
 	if err := c.ShouldBindJSON(&loginRequest); err != nil {
@@ -41,17 +46,60 @@ func (h *UserHandler) Login(c *gin.Context) {
 		Password: loginRequest.Password,
 	}
 
-	authenticatedUser, token, err := h.userUsecase.Login(&user)
+	authenticatedUser, token, challenge, err := h.userUsecase.Login(&user, clientInfo(c, loginRequest.DeviceLabel))
+	if err != nil {
+		if err.Error() == "too many failed login attempts. please try again later" {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		} else if strings.HasPrefix(err.Error(), "account is suspended") {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if challenge != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"mfa_required":        true,
+			"mfa_token":           challenge.Token,
+			"enrollment_required": challenge.EnrollmentRequired,
+		})
+		return
+	}
+
+	respondWithTokens(c, h.authCookies, h.authCookies.Requested(c), token, gin.H{"user": authenticatedUser})
+}
+
+// RefreshToken exchanges a refresh token for a new access & refresh token pair.
+// Browser clients in cookie mode send no body and are refreshed from their cookie.
+func (h *UserHandler) RefreshToken(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// A browser refreshing from its cookie gets cookies back
+	useCookies := h.authCookies.Requested(c)
+	if req.RefreshToken == "" {
+		req.RefreshToken = h.authCookies.RefreshToken(c)
+		useCookies = useCookies || req.RefreshToken != ""
+	}
+	if req.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token is required"})
+		return
+	}
+
+	token, err := h.userUsecase.RefreshToken(req.RefreshToken)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"user":         authenticatedUser,
-		"accessToken":  token.AccessToken,
-		"refreshToken": token.RefreshToken,
-	})
+	respondWithTokens(c, h.authCookies, useCookies, token, gin.H{})
 }
 
 // ForgotPassword sends a reset link to the user's email
@@ -65,7 +113,7 @@ func (h *UserHandler) ForgotPassword(c *gin.Context) {
 		return
 	}
 
-	err := h.passwordResetUsecase.RequestPasswordReset(req.Email)
+	err := h.passwordResetUsecase.RequestPasswordReset(req.Email, clientInfo(c, ""))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -89,8 +137,11 @@ func (h *UserHandler) ResetPassword(c *gin.Context) {
 		return
 	}
 
-	err := h.passwordResetUsecase.ResetPassword(req.Token, req.NewPassword)
+	err := h.passwordResetUsecase.ResetPassword(req.Token, req.NewPassword, clientInfo(c, ""))
 	if err != nil {
+		if respondPasswordPolicyError(c, err) {
+			return
+		}
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -99,20 +150,68 @@ func (h *UserHandler) ResetPassword(c *gin.Context) {
 }
 
 
+// Logout signs out the session the access token belongs to
 func (h *UserHandler) Logout(c *gin.Context) {
-	fmt.Println("logout handler is processing ")
 	userID, exists := services.GinGetUserID(c)
-	fmt.Println("userId output: ", userID)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found in context"})
 		return
 	}
+	sessionID, _ := services.GinGetSessionID(c)
 
-	err := h.userUsecase.Logout(userID)
+	err := h.userUsecase.Logout(userID, sessionID, clientInfo(c, ""))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to logout"})
 		return
 	}
 
+	if h.authCookies.Enabled() {
+		h.authCookies.Clear(c)
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "logged out successfully"})
 }
+
+// clientInfo describes the device a login request came from
+func clientInfo(c *gin.Context, deviceLabel string) entities.ClientInfo {
+	return entities.ClientInfo{
+		DeviceLabel: deviceLabel,
+		UserAgent:   c.Request.UserAgent(),
+		IPAddress:   c.ClientIP(),
+	}
+}
+
+// respondWithTokens sends a new token pair along with body. Clients that asked
+// for cookie mode get the pair only as HttpOnly cookies, so scripts never see
+// it, and the response carries the CSRF token they must repeat in the
+// X-CSRF-Token header instead. Every other client gets the pair in the body.
+func respondWithTokens(c *gin.Context, authCookies *services.AuthCookies, useCookies bool, token *entities.Token, body gin.H) {
+	if useCookies {
+		csrfToken, err := authCookies.SetTokens(c, token)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set session cookies"})
+			return
+		}
+		body["csrfToken"] = csrfToken
+	} else {
+		body["accessToken"] = token.AccessToken
+		body["refreshToken"] = token.RefreshToken
+	}
+
+	c.JSON(http.StatusOK, body)
+}
+
+// respondPasswordPolicyError responds with every password rule err reports as
+// broken. It returns false, without responding, for any other error.
+func respondPasswordPolicyError(c *gin.Context, err error) bool {
+	var policyErr *services.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":      "password does not meet the requirements",
+		"violations": policyErr.Violations,
+	})
+	return true
+}
@@ -1,8 +1,14 @@
 package handlers
 
 import (
+	"encoding/csv"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"g6_starter_project/Domain/entities"
 	usecases "g6_starter_project/Usecases"
 	"g6_starter_project/Infrastructure/services"
 
@@ -35,16 +41,10 @@ func (h *UserManagementHandler) PromoteUser(c *gin.Context) {
 		return
 	}
 
-	// Prevent admin from promoting themselves
-	if adminID == userID {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot promote yourself"})
-		return
-	}
-
 	// Promote the user
-	updatedUser, err := h.userManagementUsecase.PromoteUser(userID)
+	updatedUser, err := h.userManagementUsecase.PromoteUser(adminID, userID, clientInfo(c, ""))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondRoleChangeError(c, err)
 		return
 	}
 
@@ -70,25 +70,278 @@ func (h *UserManagementHandler) DemoteUser(c *gin.Context) {
 		return
 	}
 
-	// Prevent admin from demoting themselves
-	if adminID == userID {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot demote yourself"})
+	// Demote the user
+	updatedUser, err := h.userManagementUsecase.DemoteUser(adminID, userID, clientInfo(c, ""))
+	if err != nil {
+		respondRoleChangeError(c, err)
 		return
 	}
 
-	// Demote the user
-	updatedUser, err := h.userManagementUsecase.DemoteUser(userID)
-	if err != nil {
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User demoted to regular user successfully",
+		"user":    updatedUser,
+	})
+}
+
+// AssignRole gives a user a role
+func (h *UserManagementHandler) AssignRole(c *gin.Context) {
+	// Get user ID from URL parameter
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user ID is required"})
+		return
+	}
+
+	// Get admin user ID from context (set by auth middleware)
+	adminID, exists := services.GinGetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "admin authentication required"})
+		return
+	}
+
+	var req struct {
+		Role string `json:"role" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	updatedUser, err := h.userManagementUsecase.AssignRole(adminID, userID, req.Role, clientInfo(c, ""))
+	if err != nil {
+		respondRoleChangeError(c, err)
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"message": "User demoted to regular user successfully",
+		"message": "Role assigned successfully",
 		"user":    updatedUser,
 	})
 }
 
+// ImpersonateUser issues a short-lived token to act as a user
+func (h *UserManagementHandler) ImpersonateUser(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user ID is required"})
+		return
+	}
+
+	adminID, exists := services.GinGetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "admin authentication required"})
+		return
+	}
+
+	impersonation, err := h.userManagementUsecase.Impersonate(adminID, userID, clientInfo(c, ""))
+	if err != nil {
+		switch err.Error() {
+		case "user not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "administrators cannot be impersonated":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, impersonation)
+}
+
+// SuspendUser keeps a user from signing in until a given time or until lifted
+func (h *UserManagementHandler) SuspendUser(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user ID is required"})
+		return
+	}
+
+	adminID, exists := services.GinGetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "admin authentication required"})
+		return
+	}
+
+	var req struct {
+		Reason    string     `json:"reason" binding:"required"`
+		Until     *time.Time `json:"until"`
+		HidePosts bool       `json:"hide_posts"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.userManagementUsecase.Suspend(adminID, userID, req.Reason, req.Until, req.HidePosts, clientInfo(c, ""))
+	if err != nil {
+		switch err.Error() {
+		case "user not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "administrators cannot be suspended":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User suspended successfully",
+		"user":    user,
+	})
+}
+
+// UnsuspendUser lifts a user's suspension
+func (h *UserManagementHandler) UnsuspendUser(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user ID is required"})
+		return
+	}
+
+	adminID, exists := services.GinGetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "admin authentication required"})
+		return
+	}
+
+	user, err := h.userManagementUsecase.Unsuspend(adminID, userID, clientInfo(c, ""))
+	if err != nil {
+		switch err.Error() {
+		case "user not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "user is not suspended":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Suspension lifted successfully",
+		"user":    user,
+	})
+}
+
+// ListUsers searches the user directory. Every filter is optional; created_from
+// and created_to are RFC 3339 times. With format=csv every matching user is
+// returned as a CSV file instead of a page of JSON.
+func (h *UserManagementHandler) ListUsers(c *gin.Context) {
+	page, limit := pagination(c)
+	filter := entities.UserFilter{
+		Search: strings.TrimSpace(c.Query("q")),
+		Role:   c.Query("role"),
+		SortBy: c.Query("sort_by"),
+		Order:  c.Query("order"),
+		Page:   page,
+		Limit:  limit,
+	}
+
+	var ok bool
+	if filter.Verified, ok = boolQuery(c, "verified"); !ok {
+		return
+	}
+	if filter.Suspended, ok = boolQuery(c, "suspended"); !ok {
+		return
+	}
+	if filter.CreatedFrom, ok = timeQuery(c, "created_from"); !ok {
+		return
+	}
+	if filter.CreatedTo, ok = timeQuery(c, "created_to"); !ok {
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		h.exportUsers(c, filter)
+		return
+	}
+
+	users, total, err := h.userManagementUsecase.GetAllUsers(filter)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "failed to") {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users": users,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// exportUsers responds with every user matching the filter as a CSV file
+func (h *UserManagementHandler) exportUsers(c *gin.Context, filter entities.UserFilter) {
+	users, err := h.userManagementUsecase.ExportUsers(filter)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "failed to") {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	now := time.Now()
+	filename := fmt.Sprintf("users-%s.csv", now.UTC().Format("2006-01-02"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Write([]string{"id", "full_name", "username", "email", "role", "is_verified", "suspended", "suspended_until", "created_at"})
+	for _, user := range users {
+		suspendedUntil := ""
+		if user.Suspension.Active(now) && user.Suspension.Until != nil {
+			suspendedUntil = user.Suspension.Until.UTC().Format(time.RFC3339)
+		}
+		writer.Write([]string{
+			user.ID.Hex(),
+			csvCell(user.FullName),
+			csvCell(user.Username),
+			csvCell(user.Email),
+			user.Role,
+			strconv.FormatBool(user.IsVerified),
+			strconv.FormatBool(user.Suspension.Active(now)),
+			suspendedUntil,
+			user.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+	writer.Flush()
+}
+
+// csvCell keeps user-provided text from being run as a formula by spreadsheet apps
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// boolQuery parses an optional true/false query parameter. It responds with an
+// error and returns false if the value is malformed.
+func boolQuery(c *gin.Context, param string) (*bool, bool) {
+	value := c.Query(param)
+	if value == "" {
+		return nil, true
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param + " value. Use true or false"})
+		return nil, false
+	}
+	return &parsed, true
+}
+
 // GetUserByID returns a specific user by ID
 func (h *UserManagementHandler) GetUserByID(c *gin.Context) {
 	// Get user ID from URL parameter
@@ -108,4 +361,14 @@ func (h *UserManagementHandler) GetUserByID(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"user": user,
 	})
-} 
\ No newline at end of file
+} 
+
+// respondRoleChangeError responds 403 to a role change the admin may not make
+// and 400 to any other failed one
+func respondRoleChangeError(c *gin.Context, err error) {
+	if strings.HasPrefix(err.Error(), "forbidden") {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
@@ -43,6 +43,9 @@ func (h *VerificationHandler) RegisterWithVerification(c *gin.Context) {
 
 	createdUser, err := h.verificationUsecase.RegisterWithVerification(userEntity)
 	if err != nil {
+		if respondPasswordPolicyError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -61,7 +64,7 @@ func (h *VerificationHandler) VerifyEmail(c *gin.Context) {
 		return
 	}
 
-	err := h.verificationUsecase.VerifyEmail(token)
+	err := h.verificationUsecase.VerifyEmail(token, clientInfo(c, ""))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
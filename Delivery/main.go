@@ -3,55 +3,89 @@ package main
 import (
 	"context"
 	"log"
-	"os"
+	"time"
 
 	"g6_starter_project/Delivery/handlers"
 	"g6_starter_project/Delivery/routers"
-	"g6_starter_project/Infrastructure/db"
+	"g6_starter_project/Infrastructure/config"
+	"g6_starter_project/Infrastructure/mongodb/migrations"
 	"g6_starter_project/Infrastructure/mongodb/repositories"
 	"g6_starter_project/Infrastructure/services"
 	usecases "g6_starter_project/Usecases"
-
-	"github.com/joho/godotenv"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func main() {
-	LoadEnvVariables()
+	config.LoadEnvVariables()
 
-	mongoURI, databaseName, serverPort := GetAppConfig()
+	mongoURI, databaseName, serverPort := config.GetAppConfig()
 
-	mongoClient := ConnectToMongoDB(mongoURI)
+	mongoClient := config.ConnectToMongoDB(mongoURI)
 	defer mongoClient.Disconnect(context.TODO())
 
 	database := mongoClient.Database(databaseName)
 
+	tokenHasher := services.NewTokenHasher(config.GetTokenHashKey())
+	if err := migrations.Migrate(context.TODO(), database, tokenHasher); err != nil {
+		log.Fatal("Failed to migrate database:", err)
+	}
+
 	// Repositories
 	userRepository := repositories.NewUserRepository(database.Collection("users"))
 	tokenRepository := repositories.NewTokenRepository(database.Collection("token"))
+	impersonationTokenRepository := repositories.NewImpersonationTokenRepository(database.Collection("impersonation_tokens"))
 	blogRepository := repositories.NewBlogRepository(database)
 	interactionRepository := repositories.NewBlogInteractionRepository(database)
 	commentRepository := repositories.NewCommentRepository(database)
 	chatRepository := repositories.NewChatRepository(database.Collection("chats"))
+	signingKeyRepository := repositories.NewSigningKeyRepository(database.Collection("signing_keys"))
+	mfaPolicyRepository := repositories.NewMFAPolicyRepository(database.Collection("mfa_policies"))
+	oidcStateRepository := repositories.NewOIDCStateRepository(database.Collection("oidc_states"))
+	personalAccessTokenRepository := repositories.NewPersonalAccessTokenRepository(database.Collection("personal_access_tokens"))
+	roleRepository := repositories.NewRoleRepository(database.Collection("roles"))
+	oneTimeTokenRepository := repositories.NewOneTimeTokenRepository(database.Collection("one_time_tokens"))
+	securityEventRepository := repositories.NewSecurityEventRepository(database.Collection("security_events"))
+	dataExportRepository := repositories.NewDataExportRepository(database.Collection("data_exports"))
 
 	// Services
-	jwtService := services.NewJWTService(os.Getenv("JWT_SECRET"))
+	keyManager := config.SetupKeyManager(signingKeyRepository)
+	jwtService := services.NewJWTService(keyManager)
 	emailService := services.NewEmailService()
 	rateLimiter := services.NewRateLimiter()
 	aiService := services.NewAIService()
 	rateLimiter.StartCleanup()
+	revocationStore := config.NewRevocationStore()
+	loginAttemptStore := config.NewLoginAttemptStore()
+	totpService := config.SetupTOTPService()
+	oidcService := config.SetupOIDCService()
+	passwordHasher := config.SetupPasswordHasher()
+	passwordPolicy := config.SetupPasswordPolicy()
+	authCookies := config.SetupAuthCookies()
 
 	// UseCases
-	tokenUseCase := usecases.NewTokenUsecase(tokenRepository, jwtService)
-	userUseCase := usecases.NewUserUsecase(userRepository, tokenUseCase)
-	passwordResetUseCase := usecases.NewPasswordResetUsecase(userRepository, jwtService, emailService, rateLimiter)
-	userManagementUseCase := usecases.NewUserManagementUsecase(userRepository)
-	userProfileUseCase := usecases.NewUserProfileUsecase(userRepository)
-	blogUseCase := usecases.NewBlogUsecase(blogRepository, interactionRepository, userRepository)
-	commentUseCase := usecases.NewCommentUsecase(commentRepository, blogRepository)
+	roleUseCase := usecases.NewRoleUsecase(roleRepository, userRepository)
+	if err := roleUseCase.EnsureDefaultRoles(context.TODO()); err != nil {
+		log.Fatal("Failed to create default roles:", err)
+	}
+	securityEventUseCase := usecases.NewSecurityEventUsecase(securityEventRepository)
+	oneTimeTokenUseCase := usecases.NewOneTimeTokenUsecase(oneTimeTokenRepository, tokenHasher)
+	tokenUseCase := usecases.NewTokenUsecase(tokenRepository, impersonationTokenRepository, userRepository, jwtService, revocationStore, tokenHasher)
+	mfaUseCase := usecases.NewMFAUsecase(userRepository, mfaPolicyRepository, roleUseCase, tokenUseCase, jwtService, totpService, tokenHasher, loginAttemptStore, securityEventUseCase)
+	userUseCase := usecases.NewUserUsecase(userRepository, tokenUseCase, mfaUseCase, loginAttemptStore, emailService, passwordHasher, securityEventUseCase)
+	personalAccessTokenUseCase := usecases.NewPersonalAccessTokenUsecase(personalAccessTokenRepository, userRepository, tokenHasher)
+	oidcUseCase := usecases.NewOIDCUsecase(userRepository, oidcStateRepository, oidcService, tokenUseCase, mfaUseCase, oneTimeTokenUseCase, passwordHasher, securityEventUseCase)
+	magicLinkUseCase := usecases.NewMagicLinkUsecase(userRepository, emailService, rateLimiter, tokenUseCase, mfaUseCase, oneTimeTokenUseCase, securityEventUseCase)
+	emailChangeUseCase := usecases.NewEmailChangeUsecase(userRepository, emailService, rateLimiter, tokenUseCase, oneTimeTokenUseCase, passwordHasher)
+	passwordResetUseCase := usecases.NewPasswordResetUsecase(userRepository, emailService, rateLimiter, tokenUseCase, oneTimeTokenUseCase, passwordHasher, passwordPolicy, securityEventUseCase)
+	userManagementUseCase := usecases.NewUserManagementUsecase(userRepository, tokenUseCase, roleUseCase, emailService, securityEventUseCase)
+	userProfileUseCase := usecases.NewUserProfileUsecase(userRepository, tokenUseCase, emailService, passwordHasher, passwordPolicy, securityEventUseCase)
+	blogUseCase := usecases.NewBlogUsecase(blogRepository, interactionRepository, userRepository, roleUseCase, userManagementUseCase)
+	commentUseCase := usecases.NewCommentUsecase(commentRepository, blogRepository, roleUseCase)
 	commentHandler := handlers.NewCommentHandler(commentUseCase)
 	aiUseCase := usecases.NewAIUsecase(aiService, chatRepository, userRepository)
-	verificationUseCase := usecases.NewVerificationUsecase(userRepository, emailService)
+	accountDeletionUseCase := usecases.NewAccountDeletionUsecase(userRepository, blogRepository, interactionRepository, commentRepository, chatRepository, dataExportRepository, tokenUseCase, personalAccessTokenUseCase, oneTimeTokenUseCase, mfaUseCase, passwordHasher, emailService, config.GetDurationEnv("ACCOUNT_DELETION_GRACE_PERIOD", 14*24*time.Hour), securityEventUseCase)
+	accountDeletionUseCase.StartPurge(config.GetDurationEnv("ACCOUNT_PURGE_INTERVAL", time.Hour))
+	dataExportUseCase := usecases.NewDataExportUsecase(dataExportRepository, userRepository, blogRepository, interactionRepository, commentRepository, chatRepository, emailService, rateLimiter, tokenHasher, securityEventUseCase)
+	verificationUseCase := usecases.NewVerificationUsecase(userRepository, emailService, oneTimeTokenUseCase, passwordHasher, passwordPolicy, securityEventUseCase)
 
 
 	// Handlers
@@ -59,6 +93,17 @@ func main() {
 	userProfileHandler := handlers.NewUserProfileHandler(userProfileUseCase)
 	aiHandler := handlers.NewAIHandler(aiUseCase)
 	verificationHandler := handlers.NewVerificationHandler(verificationUseCase)
+	sessionHandler := handlers.NewSessionHandler(tokenUseCase)
+	jwksHandler := handlers.NewJWKSHandler(keyManager)
+	mfaHandler := handlers.NewMFAHandler(mfaUseCase, authCookies)
+	oidcHandler := handlers.NewOIDCHandler(oidcUseCase, authCookies)
+	personalAccessTokenHandler := handlers.NewPersonalAccessTokenHandler(personalAccessTokenUseCase)
+	roleHandler := handlers.NewRoleHandler(roleUseCase)
+	magicLinkHandler := handlers.NewMagicLinkHandler(magicLinkUseCase, authCookies)
+	emailChangeHandler := handlers.NewEmailChangeHandler(emailChangeUseCase)
+	securityEventHandler := handlers.NewSecurityEventHandler(securityEventUseCase)
+	accountDeletionHandler := handlers.NewAccountDeletionHandler(accountDeletionUseCase, authCookies)
+	dataExportHandler := handlers.NewDataExportHandler(dataExportUseCase)
 
 	// Router
 	router := routers.SetupRouter(
@@ -71,7 +116,24 @@ func main() {
 		commentHandler,
 		aiHandler,
 		verificationHandler,
+		sessionHandler,
+		jwksHandler,
+		mfaHandler,
+		oidcHandler,
+		personalAccessTokenHandler,
+		roleHandler,
+		magicLinkHandler,
+		emailChangeHandler,
+		securityEventHandler,
+		accountDeletionHandler,
+		dataExportHandler,
 		jwtService,
+		revocationStore,
+		personalAccessTokenUseCase,
+		userManagementUseCase,
+		roleUseCase,
+		authCookies,
+		securityEventUseCase,
 	)
 
 	log.Printf("Server running on port %s", serverPort)
@@ -79,42 +141,3 @@ func main() {
 		log.Fatal("Failed to start server:", err)
 	}
 }
-
-func LoadEnvVariables() {
-	// Look for .env file in the current directory (project root)
-	if err := godotenv.Load(".env"); err != nil {
-		log.Println("Warning: .env file not found, using system environment variables")
-	}
-	}
-
-func GetAppConfig() (mongoURI string, dbName string, port string) {
-	mongoURI = os.Getenv("MONGODB_URI")
-	if mongoURI == "" {
-		log.Fatal("Environment variable MONGODB_URI is required")
-	}
-
-	dbName = os.Getenv("MONGODB_DATABASE")
-	if dbName == "" {
-		dbName = "blog_api"
-	}
-
-	port = os.Getenv("APP_PORT")
-	if port == "" {
-		port = "8080"
-	}
-
-	return
-	}
-
-func ConnectToMongoDB(uri string) *mongo.Client {
-	client, err := db.ConnectMongoDB(uri)
-	if err != nil {
-		log.Fatal("Failed to connect to MongoDB:", err)
-	}
-
-	if err := client.Ping(context.TODO(), nil); err != nil {
-		log.Fatal("MongoDB ping failed:", err)
-	}
-
-	return client
-}
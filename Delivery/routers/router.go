@@ -2,6 +2,7 @@ package routers
 
 import (
 	"g6_starter_project/Delivery/handlers"
+	"g6_starter_project/Domain/entities"
 	"g6_starter_project/Infrastructure/services"
 	usecases "g6_starter_project/Usecases"
 
@@ -18,43 +19,101 @@ func SetupRouter(
 	commentHandler *handlers.CommentHandler,
 	aiHandler *handlers.AIHandler,
 	verificationHandler *handlers.VerificationHandler,
+	sessionHandler *handlers.SessionHandler,
+	jwksHandler *handlers.JWKSHandler,
+	mfaHandler *handlers.MFAHandler,
+	oidcHandler *handlers.OIDCHandler,
+	personalAccessTokenHandler *handlers.PersonalAccessTokenHandler,
+	roleHandler *handlers.RoleHandler,
+	magicLinkHandler *handlers.MagicLinkHandler,
+	emailChangeHandler *handlers.EmailChangeHandler,
+	securityEventHandler *handlers.SecurityEventHandler,
+	accountDeletionHandler *handlers.AccountDeletionHandler,
+	dataExportHandler *handlers.DataExportHandler,
 	jwtService *services.JWTService,
+	revocationStore services.TokenRevocationStore,
+	patValidator services.PersonalAccessTokenValidator,
+	suspensions services.SuspensionChecker,
+	permissions entities.PermissionChecker,
+	authCookies *services.AuthCookies,
+	securityEvents services.SecurityEventRecorder,
 ) *gin.Engine {
 
 	router := gin.Default()
+	router.Use(services.GinCSRFMiddleware(authCookies))
+	router.Use(services.GinAuditImpersonation(securityEvents))
 	
 	// Initialize handlers
-	userHandler := handlers.NewUserHandler(userUsecase, passwordResetUsecase)
+	userHandler := handlers.NewUserHandler(userUsecase, passwordResetUsecase, authCookies)
 	userManagementHandler := handlers.NewUserManagementHandler(userManagementUsecase)
+	authMiddleware := services.GinAuthMiddleware(jwtService, revocationStore, patValidator, suspensions, authCookies)
+	optionalAuthMiddleware := services.GinOptionalAuthMiddleware(jwtService, revocationStore, patValidator, suspensions, authCookies)
+	sessionOnly := services.GinRequireSession()
+	// Impersonated sessions may look around but not change credentials
+	noImpersonation := services.GinForbidImpersonation()
 	
 	// Public routes
+	router.GET("/.well-known/jwks.json", jwksHandler.GetJWKS)
 	router.POST("/register", verificationHandler.RegisterWithVerification) // Registration with email verification
 	router.POST("/login", userHandler.Login)
+	router.POST("/login/mfa", mfaHandler.VerifyLogin)
+	router.POST("/login/mfa/setup", mfaHandler.BeginLoginEnrollment)
+	router.POST("/login/mfa/confirm", mfaHandler.ConfirmLoginEnrollment)
+	router.POST("/auth/refresh", userHandler.RefreshToken)
+	router.GET("/auth/oidc/providers", oidcHandler.ListProviders)
+	router.GET("/auth/oidc/:provider/login", oidcHandler.Login)
+	router.GET("/auth/oidc/:provider/callback", oidcHandler.Callback)
+	router.POST("/auth/magic-link", magicLinkHandler.RequestLink)
+	router.GET("/auth/magic-link/verify", magicLinkHandler.Verify)
 	router.POST("/forgot-password", userHandler.ForgotPassword)
 	router.POST("/reset-password", userHandler.ResetPassword)
 	
 	// Verification routes
 	router.GET("/auth/verify", verificationHandler.VerifyEmail)
 	router.POST("/auth/resend-verification", verificationHandler.ResendVerificationEmail)
+	router.GET("/auth/email-change/confirm", emailChangeHandler.ConfirmChange)
+	router.GET("/auth/email-change/cancel", emailChangeHandler.CancelChange)
+	router.GET("/profile/export/download", dataExportHandler.Download) // Download link from the export email
 
 	// Protected logout route
 	logoutRoutes := router.Group("")
-	logoutRoutes.Use(services.AuthMiddleware(jwtService))
+	logoutRoutes.Use(authMiddleware, sessionOnly, noImpersonation)
 	{
 		logoutRoutes.POST("/logout", userHandler.Logout)
 	}
 
+	// Session routes (authentication required)
+	sessionRoutes := router.Group("/auth/sessions")
+	sessionRoutes.Use(authMiddleware, sessionOnly)
+	{
+		sessionRoutes.GET("", sessionHandler.ListSessions)
+		sessionRoutes.DELETE("/:id", noImpersonation, sessionHandler.RevokeSession)
+	}
+
 	// Profile routes (authentication required)
 	profileRoutes := router.Group("/profile")
-	profileRoutes.Use(services.GinAuthMiddleware(jwtService))
+	profileRoutes.Use(authMiddleware, sessionOnly)
 	{
 		profileRoutes.GET("/me", userProfileHandler.GetMyProfile)
 		profileRoutes.PUT("/me", userProfileHandler.UpdateMyProfile)
+		profileRoutes.DELETE("/me", noImpersonation, accountDeletionHandler.RequestDeletion)
+		profileRoutes.POST("/me/cancel-deletion", noImpersonation, accountDeletionHandler.CancelDeletion)
+		profileRoutes.PUT("/password", noImpersonation, userProfileHandler.ChangePassword)
+		profileRoutes.POST("/email", noImpersonation, emailChangeHandler.RequestChange)
+		profileRoutes.DELETE("/email", noImpersonation, emailChangeHandler.CancelOwnChange)
+		profileRoutes.POST("/mfa/setup", noImpersonation, mfaHandler.BeginEnrollment)
+		profileRoutes.POST("/mfa/confirm", noImpersonation, mfaHandler.ConfirmEnrollment)
+		profileRoutes.POST("/mfa/disable", noImpersonation, mfaHandler.Disable)
+		profileRoutes.GET("/tokens", personalAccessTokenHandler.ListTokens)
+		profileRoutes.POST("/tokens", noImpersonation, personalAccessTokenHandler.CreateToken)
+		profileRoutes.DELETE("/tokens/:id", noImpersonation, personalAccessTokenHandler.RevokeToken)
+		profileRoutes.GET("/security-events", securityEventHandler.ListMyEvents)
+		profileRoutes.POST("/export", dataExportHandler.RequestExport)
 	}
 
 	// AI routes (authentication required)
 	aiRoutes := router.Group("/ai")
-	aiRoutes.Use(services.GinAuthMiddleware(jwtService))
+	aiRoutes.Use(authMiddleware, services.GinRequireScope(entities.ScopeAIUse))
 	{
 		aiRoutes.POST("/generate-content", aiHandler.GenerateBlogContent)
 		aiRoutes.POST("/suggest-topics", aiHandler.SuggestTopics)
@@ -66,13 +125,17 @@ func SetupRouter(
 	// Blog routes
 	postRoutes := router.Group("/blog")
 	{
-		// Public
-		postRoutes.GET("", blogHandler.ListPosts)
-		postRoutes.GET("/:id", blogHandler.GetPostByID)
+		// Public, but signed-in readers are identified so views are attributed to them
+		publicPostRoutes := postRoutes.Group("")
+		publicPostRoutes.Use(optionalAuthMiddleware)
+		{
+			publicPostRoutes.GET("", blogHandler.ListPosts)
+			publicPostRoutes.GET("/:id", blogHandler.GetPostByID)
+		}
 
 		// Protected routes
 		protectedPostRoutes := postRoutes.Group("")
-		protectedPostRoutes.Use(services.GinAuthMiddleware(jwtService))
+		protectedPostRoutes.Use(authMiddleware, services.GinRequireScope(entities.ScopeBlogWrite))
 		{
 			protectedPostRoutes.POST("", blogHandler.CreatePost)
 			protectedPostRoutes.PUT("/:id", blogHandler.UpdatePost)
@@ -80,18 +143,35 @@ func SetupRouter(
 
 			protectedPostRoutes.POST("/:id/like", blogHandler.LikePost)
 			protectedPostRoutes.POST("/:id/dislike", blogHandler.DislikePost)
-			protectedPostRoutes.POST("/:id/comments", commentHandler.CreateComment)
+		}
+
+		// Comment routes take their own scope
+		protectedCommentRoutes := postRoutes.Group("")
+		protectedCommentRoutes.Use(authMiddleware, services.GinRequireScope(entities.ScopeCommentsWrite))
+		{
+			protectedCommentRoutes.POST("/:id/comments", commentHandler.CreateComment)
+			protectedCommentRoutes.DELETE("/:id/comments/:commentId", commentHandler.DeleteComment)
 		}
 	}
 	
-	// Admin routes
+	// Admin routes (each route requires its own permission)
 	adminGroup := router.Group("/admin")
-	adminGroup.Use(services.GinAuthMiddleware(jwtService))
-	adminGroup.Use(services.GinRoleAuthorization("admin"))
+	adminGroup.Use(authMiddleware, sessionOnly, noImpersonation)
 	{
-		adminGroup.PUT("/users/:id/promote", userManagementHandler.PromoteUser)
-		adminGroup.PUT("/users/:id/demote", userManagementHandler.DemoteUser)
-		adminGroup.GET("/users/:id", userManagementHandler.GetUserByID)
+		adminGroup.PUT("/users/:id/promote", services.RequirePermission(permissions, entities.PermissionUsersManageRoles), userManagementHandler.PromoteUser)
+		adminGroup.PUT("/users/:id/demote", services.RequirePermission(permissions, entities.PermissionUsersManageRoles), userManagementHandler.DemoteUser)
+		adminGroup.PUT("/users/:id/role", services.RequirePermission(permissions, entities.PermissionUsersManageRoles), userManagementHandler.AssignRole)
+		adminGroup.GET("/users", services.RequirePermission(permissions, entities.PermissionUsersRead), userManagementHandler.ListUsers)
+		adminGroup.GET("/users/:id", services.RequirePermission(permissions, entities.PermissionUsersRead), userManagementHandler.GetUserByID)
+		adminGroup.POST("/users/:id/impersonate", services.RequirePermission(permissions, entities.PermissionUsersImpersonate), userManagementHandler.ImpersonateUser)
+		adminGroup.POST("/users/:id/suspend", services.RequirePermission(permissions, entities.PermissionUsersSuspend), userManagementHandler.SuspendUser)
+		adminGroup.POST("/users/:id/unsuspend", services.RequirePermission(permissions, entities.PermissionUsersSuspend), userManagementHandler.UnsuspendUser)
+		adminGroup.GET("/mfa-policies", services.RequirePermission(permissions, entities.PermissionMFAPolicyManage), mfaHandler.GetPolicies)
+		adminGroup.PUT("/mfa-policies/:role", services.RequirePermission(permissions, entities.PermissionMFAPolicyManage), mfaHandler.SetPolicy)
+		adminGroup.GET("/roles", services.RequirePermission(permissions, entities.PermissionRolesManage), roleHandler.ListRoles)
+		adminGroup.PUT("/roles/:name", services.RequirePermission(permissions, entities.PermissionRolesManage), roleHandler.SaveRole)
+		adminGroup.DELETE("/roles/:name", services.RequirePermission(permissions, entities.PermissionRolesManage), roleHandler.DeleteRole)
+		adminGroup.GET("/audit", services.RequirePermission(permissions, entities.PermissionAuditRead), securityEventHandler.QueryEvents)
 	}
 	
 	return router
@@ -21,4 +21,5 @@ type ChatRepository interface {
 	GetChatByID(id string) (*Chat, error)
 	GetChatsByUserID(userID string) ([]Chat, error)
 	DeleteChat(id string) error
+	DeleteChatsByUserID(userID string) error
 }
\ No newline at end of file
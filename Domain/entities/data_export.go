@@ -0,0 +1,39 @@
+package entities
+
+import (
+	"context"
+	"time"
+)
+
+// States of a personal data export
+const (
+	DataExportPending = "pending"
+	DataExportReady   = "ready"
+	DataExportFailed  = "failed"
+)
+
+// DataExport is an archive of everything stored about a user, built in the
+// background and downloaded through an emailed link. Only a keyed hash of the
+// download token is stored, and MongoDB deletes the export once it expires.
+type DataExport struct {
+	ID          string     `bson:"_id" json:"id"`
+	UserID      string     `bson:"user_id" json:"-"`
+	Status      string     `bson:"status" json:"status"`
+	TokenHash   string     `bson:"token_hash,omitempty" json:"-"`
+	Archive     []byte     `bson:"archive,omitempty" json:"-"` // zip archive, set once the export is ready
+	Size        int        `bson:"size" json:"size"`
+	Error       string     `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
+	CompletedAt *time.Time `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	ExpiresAt   time.Time  `bson:"expires_at" json:"expires_at"`
+}
+
+// interface for repository to use
+type DataExportRepository interface {
+	Create(ctx context.Context, export *DataExport) error
+	HasPending(ctx context.Context, userID string, now time.Time) (bool, error)
+	Complete(ctx context.Context, id, tokenHash string, archive []byte, completedAt, expiresAt time.Time) error
+	Fail(ctx context.Context, id, reason string, completedAt time.Time) error
+	FindByTokenHash(ctx context.Context, tokenHash string, now time.Time) (*DataExport, error)
+	DeleteByUserID(ctx context.Context, userID string) error
+}
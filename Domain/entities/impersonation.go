@@ -0,0 +1,24 @@
+package entities
+
+import (
+	"context"
+	"time"
+)
+
+// ImpersonationToken records an access token issued to an admin acting as a
+// user, so it can be revoked before it expires when either of them is signed
+// out. Only the token's ID ("jti") is stored.
+type ImpersonationToken struct {
+	JTI       string    `bson:"_id"`
+	ActorID   string    `bson:"actor_id"` // the admin the token was issued to
+	UserID    string    `bson:"user_id"`  // the user the admin acts as
+	ExpiresAt time.Time `bson:"expires_at"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+// interface for repository to use
+type ImpersonationTokenRepository interface {
+	Create(ctx context.Context, token *ImpersonationToken) error
+	FindByUser(ctx context.Context, userID string) ([]ImpersonationToken, error)
+	DeleteByUser(ctx context.Context, userID string) error
+}
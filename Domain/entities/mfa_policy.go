@@ -0,0 +1,21 @@
+package entities
+
+import (
+	"context"
+	"time"
+)
+
+// MFAPolicy says whether users with a role must use two-factor authentication
+type MFAPolicy struct {
+	Role      string    `bson:"_id" json:"role"`
+	Required  bool      `bson:"required" json:"required"`
+	UpdatedBy string    `bson:"updated_by" json:"updated_by"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// interface for repository to use
+type MFAPolicyRepository interface {
+	GetByRole(ctx context.Context, role string) (*MFAPolicy, error)
+	GetAll(ctx context.Context) ([]MFAPolicy, error)
+	Upsert(ctx context.Context, policy *MFAPolicy) error
+}
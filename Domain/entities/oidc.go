@@ -0,0 +1,31 @@
+package entities
+
+import (
+	"context"
+	"time"
+)
+
+// ExternalIdentity links a user to an account at an OpenID Connect provider
+type ExternalIdentity struct {
+	Provider string    `bson:"provider" json:"provider"`
+	Subject  string    `bson:"subject" json:"-"` // the provider's stable user ID ("sub")
+	Email    string    `bson:"email" json:"email"`
+	LinkedAt time.Time `bson:"linked_at" json:"linked_at"`
+}
+
+// OIDCState is a pending sign-in at an identity provider. It is consumed by the
+// callback, so each state value can complete at most one login.
+type OIDCState struct {
+	State        string    `bson:"_id"`
+	Provider     string    `bson:"provider"`
+	Nonce        string    `bson:"nonce"`
+	CodeVerifier string    `bson:"code_verifier"` // PKCE verifier, never sent to the browser
+	ExpiresAt    time.Time `bson:"expires_at"`
+	CreatedAt    time.Time `bson:"created_at"`
+}
+
+// interface for repository to use
+type OIDCStateRepository interface {
+	Create(ctx context.Context, state *OIDCState) error
+	Consume(ctx context.Context, state string, now time.Time) (*OIDCState, error)
+}
@@ -0,0 +1,35 @@
+package entities
+
+import (
+	"context"
+	"time"
+)
+
+// Purposes of one-time tokens. A token only works for the purpose it was issued for.
+const (
+	TokenPurposeVerifyEmail       = "verify_email"
+	TokenPurposeResetPassword     = "reset_password"
+	TokenPurposeChangeEmail       = "change_email"
+	TokenPurposeCancelEmailChange = "cancel_email_change"
+	TokenPurposeMagicLink         = "magic_link"
+)
+
+// OneTimeToken is a single-use token sent to a user, e.g. in an emailed link.
+// Only its keyed hash is stored, and it is deleted when used or when it expires.
+type OneTimeToken struct {
+	TokenHash   string    `bson:"_id"`
+	Purpose     string    `bson:"purpose"`
+	UserID      string    `bson:"user_id"`
+	Email       string    `bson:"email,omitempty"` // address the token is for, e.g. the new address of an email change
+	BindingHash string    `bson:"binding_hash"`    // hash of a second value the holder must present, e.g. a browser nonce; empty if unbound
+	ExpiresAt   time.Time `bson:"expires_at"`
+	CreatedAt   time.Time `bson:"created_at"`
+}
+
+// interface for repository to use
+type OneTimeTokenRepository interface {
+	Create(ctx context.Context, token *OneTimeToken) error
+	Find(ctx context.Context, purpose, tokenHash, bindingHash string, now time.Time) (*OneTimeToken, error)
+	Consume(ctx context.Context, purpose, tokenHash, bindingHash string, now time.Time) (*OneTimeToken, error)
+	DeleteByUser(ctx context.Context, userID string, purposes ...string) error
+}
@@ -0,0 +1,51 @@
+package entities
+
+import (
+	"context"
+	"time"
+)
+
+// Scopes a personal access token can be granted
+const (
+	ScopeBlogWrite     = "blog:write"
+	ScopeCommentsWrite = "comments:write"
+	ScopeAIUse         = "ai:use"
+)
+
+// PersonalAccessTokenScopes lists every valid scope
+var PersonalAccessTokenScopes = []string{ScopeBlogWrite, ScopeCommentsWrite, ScopeAIUse}
+
+// PersonalAccessToken is a long-lived token for scripts and CI. Only a keyed
+// hash of the token is stored; the token itself is shown once when created.
+type PersonalAccessToken struct {
+	ID         string     `bson:"_id" json:"id"`
+	UserID     string     `bson:"user_id" json:"-"`
+	Name       string     `bson:"name" json:"name"`
+	TokenHash  string     `bson:"token_hash" json:"-"`
+	Prefix     string     `bson:"prefix" json:"prefix"` // first characters of the token, to recognise it
+	Scopes     []string   `bson:"scopes" json:"scopes"`
+	ExpiresAt  time.Time  `bson:"expires_at" json:"expires_at"`
+	LastUsedAt *time.Time `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `bson:"created_at" json:"created_at"`
+}
+
+// HasScope reports whether the token was granted a scope
+func (t *PersonalAccessToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// interface for repository to use
+type PersonalAccessTokenRepository interface {
+	Create(ctx context.Context, token *PersonalAccessToken) error
+	FindByHash(ctx context.Context, tokenHash string) (*PersonalAccessToken, error)
+	FindByUserID(ctx context.Context, userID string) ([]PersonalAccessToken, error)
+	CountByUserID(ctx context.Context, userID string) (int64, error)
+	TouchLastUsed(ctx context.Context, id string, usedAt time.Time) error
+	DeleteByIDForUser(ctx context.Context, id, userID string) (bool, error)
+	DeleteByUserID(ctx context.Context, userID string) error
+}
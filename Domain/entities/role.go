@@ -0,0 +1,79 @@
+package entities
+
+import (
+	"context"
+	"time"
+)
+
+// Permissions that can be granted to a role
+const (
+	PermissionPostsUpdateAny    = "posts:update:any"
+	PermissionPostsDeleteAny    = "posts:delete:any"
+	PermissionCommentsDeleteAny = "comments:delete:any"
+	PermissionUsersRead         = "users:read"
+	PermissionUsersManageRoles  = "users:manage_roles"
+	PermissionRolesManage       = "roles:manage"
+	PermissionMFAPolicyManage   = "mfa:manage_policy"
+	PermissionAuditRead         = "audit:read"
+	PermissionUsersImpersonate  = "users:impersonate"
+	PermissionUsersSuspend      = "users:suspend"
+)
+
+// AllPermissions lists every permission
+var AllPermissions = []string{
+	PermissionPostsUpdateAny,
+	PermissionPostsDeleteAny,
+	PermissionCommentsDeleteAny,
+	PermissionUsersRead,
+	PermissionUsersManageRoles,
+	PermissionRolesManage,
+	PermissionMFAPolicyManage,
+	PermissionAuditRead,
+	PermissionUsersImpersonate,
+	PermissionUsersSuspend,
+}
+
+// Built-in roles
+const (
+	RoleAdmin     = "admin"
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleEditor    = "editor"
+
+	// DefaultRole is given to every new user
+	DefaultRole = RoleUser
+)
+
+// Role maps a role name to the permissions its users have
+type Role struct {
+	Name        string    `bson:"_id" json:"name"`
+	Description string    `bson:"description" json:"description"`
+	Permissions []string  `bson:"permissions" json:"permissions"`
+	BuiltIn     bool      `bson:"built_in" json:"built_in"`
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// HasPermission reports whether the role grants a permission
+func (r *Role) HasPermission(permission string) bool {
+	for _, p := range r.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionChecker answers whether a role grants a permission
+type PermissionChecker interface {
+	HasPermission(ctx context.Context, role, permission string) (bool, error)
+}
+
+// interface for repository to use
+type RoleRepository interface {
+	GetByName(ctx context.Context, name string) (*Role, error)
+	GetAll(ctx context.Context) ([]Role, error)
+	Upsert(ctx context.Context, role *Role) error
+	CreateIfMissing(ctx context.Context, role *Role) error
+	Delete(ctx context.Context, name string) error
+}
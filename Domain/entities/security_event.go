@@ -0,0 +1,69 @@
+package entities
+
+import (
+	"context"
+	"time"
+)
+
+// Security event types
+const (
+	SecurityEventLogin                = "login"
+	SecurityEventLogout               = "logout"
+	SecurityEventPasswordResetRequest = "password_reset_requested"
+	SecurityEventPasswordReset        = "password_reset"
+	SecurityEventPasswordChange       = "password_changed"
+	SecurityEventEmailVerification    = "email_verified"
+	SecurityEventRoleChange           = "role_changed"
+	SecurityEventImpersonation        = "impersonation_started"
+	SecurityEventImpersonatedRequest  = "impersonated_request"
+	SecurityEventDeletionRequest      = "account_deletion_requested"
+	SecurityEventDeletionCancel       = "account_deletion_cancelled"
+	SecurityEventAccountDeleted       = "account_deleted"
+	SecurityEventDataExport           = "data_export_requested"
+	SecurityEventSuspension           = "account_suspended"
+	SecurityEventUnsuspension         = "account_unsuspended"
+	SecurityEventAdminCreated         = "admin_created"
+	SecurityEventMFAVerification      = "mfa_verification"
+)
+
+// Security event outcomes
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// SecurityEvent is an entry in the append-only audit log of authentication
+// activity. ActorID is the user who acted and TargetID the user acted on; they
+// are the same for a user's own logins. Email records the address a failed
+// attempt used when no account matched it.
+type SecurityEvent struct {
+	ID        string    `bson:"_id" json:"id"`
+	Type      string    `bson:"type" json:"type"`
+	Outcome   string    `bson:"outcome" json:"outcome"`
+	ActorID   string    `bson:"actor_id,omitempty" json:"actor_id,omitempty"`
+	TargetID  string    `bson:"target_id,omitempty" json:"target_id,omitempty"`
+	Email     string    `bson:"email,omitempty" json:"email,omitempty"`
+	Details   string    `bson:"details,omitempty" json:"details,omitempty"`
+	IPAddress string    `bson:"ip_address" json:"ip_address"`
+	UserAgent string    `bson:"user_agent" json:"user_agent"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+// SecurityEventFilter selects security events. Empty fields match everything.
+type SecurityEventFilter struct {
+	ActorID   string
+	TargetID  string
+	Type      string
+	Outcome   string
+	IPAddress string
+	From      *time.Time
+	To        *time.Time
+	Page      int64
+	Limit     int64
+}
+
+// interface for repository to use
+type SecurityEventRepository interface {
+	Create(ctx context.Context, event *SecurityEvent) error
+	Find(ctx context.Context, filter SecurityEventFilter) ([]SecurityEvent, int64, error)
+}
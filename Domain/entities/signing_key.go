@@ -0,0 +1,34 @@
+package entities
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrSigningKeyReplaced is returned when creating a key that replaces a key
+// another instance has already replaced
+var ErrSigningKeyReplaced = errors.New("signing key was already replaced")
+
+// SigningKey is an asymmetric key pair used to sign JWTs, identified by its "kid".
+// A key signs new tokens until it is retired, and keeps verifying tokens until it expires.
+// Replaces is unique, so only one instance can rotate away from a given key.
+type SigningKey struct {
+	ID         string     `bson:"_id" json:"kid"`
+	Replaces   string     `bson:"replaces" json:"-"` // kid of the newest key when this one was created, "" for the first
+	Algorithm  string     `bson:"algorithm" json:"algorithm"`   // "RS256" or "EdDSA"
+	PrivateKey string     `bson:"private_key" json:"-"`         // PKCS #8 PEM, encrypted with AES-GCM
+	PublicKey  string     `bson:"public_key" json:"public_key"` // PKIX PEM
+	CreatedAt  time.Time  `bson:"created_at" json:"created_at"`
+	RetiredAt  *time.Time `bson:"retired_at,omitempty" json:"retired_at,omitempty"`
+	ExpiresAt  *time.Time `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+}
+
+// interface for repository to use
+type SigningKeyRepository interface {
+	Create(ctx context.Context, key *SigningKey) error
+	FindUsable(ctx context.Context, now time.Time) ([]SigningKey, error)
+	Retire(ctx context.Context, id string, retiredAt, expiresAt time.Time) error
+	DeleteExpired(ctx context.Context, now time.Time) error
+	UpdatePrivateKey(ctx context.Context, id, oldPrivateKey, newPrivateKey string) error
+}
@@ -1,12 +1,69 @@
 package entities
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
+// Token is one token family: the ID is carried as the "sid" claim and the
+// stored refresh token hash is replaced on every rotation. Each family is one
+// signed-in device, exposed to users as a Session.
+// The raw tokens are only returned to the client and never stored.
 type Token struct {
-    ID           string    `json:"id" bson:"_id,omitempty"`
-    UserID       string    `json:"user_id" bson:"user_id"`
-    AccessToken  string    `json:"access_token" bson:"access_token"`
-    RefreshToken string    `json:"refresh_token" bson:"refresh_token"`
-    ExpiresAt    time.Time `json:"expires_at" bson:"expires_at"` // refresh token expiry
-    CreatedAt    time.Time `json:"created_at" bson:"created_at"`
+	ID               string    `json:"id" bson:"_id,omitempty"`
+	UserID           string    `json:"user_id" bson:"user_id"`
+	AccessToken      string    `json:"access_token" bson:"-"`
+	RefreshToken     string    `json:"refresh_token" bson:"-"`
+	RefreshTokenHash string    `json:"-" bson:"refresh_token_hash"`
+	AccessJTI        string    `json:"access_jti" bson:"access_jti"`               // "jti" of the current access token
+	AccessExpiresAt  time.Time `json:"access_expires_at" bson:"access_expires_at"` // access token expiry
+	DeviceLabel      string    `json:"device_label" bson:"device_label"`
+	UserAgent        string    `json:"user_agent" bson:"user_agent"`
+	IPAddress        string    `json:"ip_address" bson:"ip_address"`
+	ExpiresAt        time.Time `json:"expires_at" bson:"expires_at"` // refresh token expiry
+	CreatedAt        time.Time `json:"created_at" bson:"created_at"`
+	LastUsedAt       time.Time `json:"last_used_at" bson:"last_used_at"`
+}
+
+// interface for repository to use
+type TokenRepository interface {
+	Create(ctx context.Context, token *Token) error
+	FindByUserID(ctx context.Context, userID string) ([]Token, error)
+	FindByID(ctx context.Context, id string) (*Token, error)
+	Rotate(ctx context.Context, id, currentRefreshTokenHash string, rotated *Token) (bool, error)
+	DeleteByID(ctx context.Context, id string) error
+	DeleteByIDForUser(ctx context.Context, id, userID string) (bool, error)
+	DeleteByUserID(ctx context.Context, userID string) error
+}
+
+// ClientInfo describes the device a session is opened from
+type ClientInfo struct {
+	DeviceLabel string
+	UserAgent   string
+	IPAddress   string
+}
+
+// Session is the public view of a Token, without the token values
+type Session struct {
+	ID          string    `json:"id"`
+	DeviceLabel string    `json:"device_label"`
+	UserAgent   string    `json:"user_agent"`
+	IPAddress   string    `json:"ip_address"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastUsedAt  time.Time `json:"last_used_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Current     bool      `json:"current"`
+}
+
+// Session returns the public view of the token family
+func (t *Token) Session() Session {
+	return Session{
+		ID:          t.ID,
+		DeviceLabel: t.DeviceLabel,
+		UserAgent:   t.UserAgent,
+		IPAddress:   t.IPAddress,
+		CreatedAt:   t.CreatedAt,
+		LastUsedAt:  t.LastUsedAt,
+		ExpiresAt:   t.ExpiresAt,
+	}
 }
@@ -9,20 +9,24 @@ import (
 
 // User represents a user document in MongoDB.
 type User struct {
-	ID                  primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	FullName            string             `bson:"full_name" json:"full_name" binding:"required"`
-	Username            string             `bson:"username" json:"username"`   // unique
-	Email               string             `bson:"email" json:"email"`         // unique
-	Password            string             `bson:"password" json:"password"`   // Allow password during registration
-	Role                string             `bson:"role" json:"role,omitempty"` // "admin", "user"
-	IsVerified          bool               `bson:"is_verified" json:"is_verified"`
-	ProfileImage        *string            `bson:"profile_image,omitempty" json:"profile_image,omitempty"`
-	Bio                 *string            `bson:"bio,omitempty" json:"bio,omitempty"`
-	ContactInfo         *ContactInfo       `bson:"contact_info,omitempty" json:"contact_info,omitempty"`
-	ResetToken          *string            `bson:"reset_token,omitempty" json:"reset_token,omitempty"`
-	ResetTokenExpiresAt *time.Time         `bson:"reset_token_expires_at,omitempty" json:"reset_token_expires_at,omitempty"`
-	CreatedAt           time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt           time.Time          `bson:"updated_at" json:"updated_at"`
+	ID              primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	FullName        string              `bson:"full_name" json:"full_name" binding:"required"`
+	Username        string              `bson:"username" json:"username"`            // unique
+	Email           string              `bson:"email" json:"email"`                  // unique
+	Password        string              `bson:"password" json:"password"`            // Allow password during registration
+	PasswordHistory []string            `bson:"password_history,omitempty" json:"-"` // hashes of previous passwords, newest first
+	Role            string              `bson:"role" json:"role,omitempty"`          // name of a Role, e.g. "admin", "user"
+	IsVerified      bool                `bson:"is_verified" json:"is_verified"`
+	ProfileImage    *string             `bson:"profile_image,omitempty" json:"profile_image,omitempty"`
+	Bio             *string             `bson:"bio,omitempty" json:"bio,omitempty"`
+	ContactInfo     *ContactInfo        `bson:"contact_info,omitempty" json:"contact_info,omitempty"`
+	MFA             *MFASettings        `bson:"mfa,omitempty" json:"-"`
+	Identities      []ExternalIdentity  `bson:"identities,omitempty" json:"identities,omitempty"`
+	PendingEmail    *PendingEmailChange `bson:"pending_email,omitempty" json:"pending_email,omitempty"`
+	DeleteAfter     *time.Time          `bson:"delete_after,omitempty" json:"delete_after,omitempty"` // set while a requested account deletion waits out its grace period
+	Suspension      *Suspension         `bson:"suspension,omitempty" json:"suspension,omitempty"`
+	CreatedAt       time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time           `bson:"updated_at" json:"updated_at"`
 }
 
 type ContactInfo struct {
@@ -30,6 +34,67 @@ type ContactInfo struct {
 	Address *string `bson:"address,omitempty" json:"address,omitempty"`
 }
 
+// MFASettings holds a user's TOTP second factor. While Enabled is false the
+// secret belongs to an enrollment that has not been confirmed yet.
+type MFASettings struct {
+	Enabled       bool       `bson:"enabled"`
+	Secret        string     `bson:"secret"`         // encrypted TOTP secret
+	RecoveryCodes []string   `bson:"recovery_codes"` // keyed hashes of unused recovery codes
+	LastUsedStep  int64      `bson:"last_used_step"` // last accepted TOTP time step, blocks code replay
+	EnabledAt     *time.Time `bson:"enabled_at,omitempty"`
+}
+
+// PendingEmailChange is a requested email address that takes effect once it is
+// confirmed from the new address. The old address can cancel it until then.
+// The confirmation and cancellation tokens live in the one-time token store.
+type PendingEmailChange struct {
+	NewEmail    string    `bson:"new_email" json:"new_email"`
+	ExpiresAt   time.Time `bson:"expires_at" json:"expires_at"`
+	RequestedAt time.Time `bson:"requested_at" json:"requested_at"`
+}
+
+// Suspension keeps a user from signing in or using the API until it ends or an
+// admin lifts it
+type Suspension struct {
+	Reason      string     `bson:"reason" json:"reason"`
+	Until       *time.Time `bson:"until,omitempty" json:"until,omitempty"` // nil until lifted by an admin
+	HidePosts   bool       `bson:"hide_posts" json:"hide_posts"`           // leave the user's posts out of post listings
+	SuspendedBy string     `bson:"suspended_by" json:"suspended_by"`
+	SuspendedAt time.Time  `bson:"suspended_at" json:"suspended_at"`
+}
+
+// Active reports whether the suspension is still in force at now
+func (s *Suspension) Active(now time.Time) bool {
+	return s != nil && (s.Until == nil || s.Until.After(now))
+}
+
+// Fields the admin user directory can be sorted by
+const (
+	UserSortCreatedAt = "created_at"
+	UserSortFullName  = "full_name"
+	UserSortUsername  = "username"
+	UserSortEmail     = "email"
+)
+
+// UserFilter selects users for the admin user directory. Empty fields match everything.
+type UserFilter struct {
+	Search      string // matched against full name, username and email
+	Role        string
+	Verified    *bool
+	Suspended   *bool
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	SortBy      string
+	Order       string // "asc" or "desc"
+	Page        int64
+	Limit       int64
+}
+
+// MFAEnabled reports whether the user has a confirmed second factor
+func (u *User) MFAEnabled() bool {
+	return u.MFA != nil && u.MFA.Enabled
+}
+
 // interface for repository to use
 type UserRepository interface {
 	CreateUser(user *User) (*User, error)
@@ -37,10 +102,25 @@ type UserRepository interface {
 	GetUserByEmail(email string) (*User, error)
 	GetUserByUsername(username string) (*User, error)
 	GetUserCount() (int64, error)
+	CountUsersByRole(role string) (int64, error)
 	UpdateUser(user *User) (*User, error)
 	DeleteUser(id string) error
-	UpdateResetToken(userID string, resetToken *string, expiresAt *time.Time) error
-	GetUserByResetToken(resetToken string) (*User, error)
 	UpdateVerificationStatus(userID string, isVerified bool) error
 	FindByName(ctx context.Context, name string) (*User, error)
+	UpdateMFA(userID string, mfa *MFASettings) error
+	UseMFAStep(userID string, step int64) (bool, error)
+	UseMFARecoveryCode(userID string, codeHash string) (bool, error)
+	GetUserByIdentity(provider, subject string) (*User, error)
+	AddIdentity(userID string, identity ExternalIdentity) error
+	SetPendingEmail(userID string, pending *PendingEmailChange) error
+	UpdateEmail(userID, email string) error
+	UpdatePasswordHash(userID, oldHash, newHash string) error
+	UpdatePassword(userID, hash string, history []string) error
+	SetRole(userID, role string) error
+	UpdateProfile(user *User) error
+	ScheduleDeletion(userID string, deleteAfter *time.Time) error
+	GetUsersDueForDeletion(now time.Time, limit int64) ([]User, error)
+	SetSuspension(userID string, suspension *Suspension) error
+	GetSuspendedUsers(now time.Time) ([]User, error)
+	FindUsers(filter UserFilter, now time.Time) ([]User, int64, error)
 }
@@ -0,0 +1,296 @@
+// Package config reads the application's configuration from the environment
+// and builds the services that depend on it, for the API server and blogctl
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+	"g6_starter_project/Infrastructure/db"
+	"g6_starter_project/Infrastructure/redisdb"
+	"g6_starter_project/Infrastructure/services"
+
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func LoadEnvVariables() {
+	// Look for .env file in the current directory (project root)
+	if err := godotenv.Load(".env"); err != nil {
+		log.Println("Warning: .env file not found, using system environment variables")
+	}
+}
+
+func GetAppConfig() (mongoURI string, dbName string, port string) {
+	mongoURI = os.Getenv("MONGODB_URI")
+	if mongoURI == "" {
+		log.Fatal("Environment variable MONGODB_URI is required")
+	}
+
+	dbName = os.Getenv("MONGODB_DATABASE")
+	if dbName == "" {
+		dbName = "blog_api"
+	}
+
+	port = os.Getenv("APP_PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	return
+}
+
+// GetTokenHashKey returns the secret used to hash stored tokens. Changing it
+// invalidates every stored refresh, reset and verification token.
+func GetTokenHashKey() string {
+	key := os.Getenv("TOKEN_HASH_KEY")
+	if key == "" {
+		log.Fatal("Environment variable TOKEN_HASH_KEY is required")
+	}
+	return key
+}
+
+// SetupTOTPService creates the TOTP service. Changing MFA_ENCRYPTION_KEY makes
+// every enrolled authenticator unusable.
+func SetupTOTPService() *services.TOTPService {
+	issuer := os.Getenv("MFA_ISSUER")
+	if issuer == "" {
+		issuer = "Blog API"
+	}
+
+	totpService, err := services.NewTOTPService(issuer, os.Getenv("MFA_ENCRYPTION_KEY"))
+	if err != nil {
+		log.Fatal("Invalid MFA configuration:", err)
+	}
+	return totpService
+}
+
+// SetupPasswordHasher creates the password hasher. PASSWORD_HASH_ALGORITHM picks
+// the algorithm for new hashes (argon2id or bcrypt); existing hashes of either
+// kind keep working and are upgraded when their owner next logs in.
+func SetupPasswordHasher() services.PasswordHasher {
+	algorithm := passwordHashAlgorithm()
+
+	argon2idParams := services.DefaultArgon2idParams
+	argon2idParams.Memory = uint32(GetIntEnv("ARGON2_MEMORY_KB", int(argon2idParams.Memory)))
+	argon2idParams.Iterations = uint32(GetIntEnv("ARGON2_ITERATIONS", int(argon2idParams.Iterations)))
+	argon2idParams.Parallelism = uint8(GetIntEnv("ARGON2_PARALLELISM", int(argon2idParams.Parallelism)))
+
+	passwordHasher, err := services.NewPasswordHasher(services.PasswordHasherConfig{
+		Algorithm:  algorithm,
+		BcryptCost: GetIntEnv("BCRYPT_COST", 10),
+		Argon2id:   argon2idParams,
+	})
+	if err != nil {
+		log.Fatal("Invalid password hashing configuration:", err)
+	}
+	return passwordHasher
+}
+
+// passwordHashAlgorithm returns the algorithm new password hashes use
+func passwordHashAlgorithm() string {
+	if algorithm := os.Getenv("PASSWORD_HASH_ALGORITHM"); algorithm != "" {
+		return algorithm
+	}
+	return services.PasswordHashArgon2id
+}
+
+// SetupPasswordPolicy creates the rules new passwords must follow. By default a
+// password needs 8 to 64 characters with a lowercase and uppercase letter, a
+// number and a symbol. PASSWORD_BREACHED_LIST names an optional file of
+// breached passwords, one per line, that are refused. When new hashes use
+// bcrypt, passwords are also capped at the 72 bytes bcrypt accepts.
+func SetupPasswordPolicy() *services.PasswordPolicy {
+	maxBytes := 0
+	if passwordHashAlgorithm() == services.PasswordHashBcrypt {
+		maxBytes = services.BcryptMaxPasswordBytes
+	}
+
+	passwordPolicy, err := services.NewPasswordPolicy(services.PasswordPolicyConfig{
+		MinLength:        GetIntEnv("PASSWORD_MIN_LENGTH", 8),
+		MaxLength:        GetIntEnv("PASSWORD_MAX_LENGTH", 64),
+		MaxBytes:         maxBytes,
+		RequireLowercase: GetBoolEnv("PASSWORD_REQUIRE_LOWERCASE", true),
+		RequireUppercase: GetBoolEnv("PASSWORD_REQUIRE_UPPERCASE", true),
+		RequireDigit:     GetBoolEnv("PASSWORD_REQUIRE_DIGIT", true),
+		RequireSymbol:    GetBoolEnv("PASSWORD_REQUIRE_SYMBOL", true),
+	})
+	if err != nil {
+		log.Fatal("Invalid password policy:", err)
+	}
+
+	if path := os.Getenv("PASSWORD_BREACHED_LIST"); path != "" {
+		if err := passwordPolicy.LoadBreachedPasswords(path); err != nil {
+			log.Fatal("Failed to load breached password list:", err)
+		}
+	}
+	return passwordPolicy
+}
+
+// SetupAuthCookies configures cookie mode for browser clients. With
+// AUTH_COOKIE_MODE enabled, logins that send "X-Auth-Mode: cookie" get the
+// tokens as HttpOnly cookies instead of in the body, and cookie-authenticated
+// requests must carry the X-CSRF-Token header. Other clients keep using bearer tokens.
+func SetupAuthCookies() *services.AuthCookies {
+	sameSite, err := services.ParseSameSite(os.Getenv("AUTH_COOKIE_SAMESITE"))
+	if err != nil {
+		log.Fatal("Invalid AUTH_COOKIE_SAMESITE:", err)
+	}
+
+	authCookies, err := services.NewAuthCookies(services.AuthCookieConfig{
+		Enabled:  GetBoolEnv("AUTH_COOKIE_MODE", false),
+		Domain:   os.Getenv("AUTH_COOKIE_DOMAIN"),
+		Secure:   GetBoolEnv("AUTH_COOKIE_SECURE", true),
+		SameSite: sameSite,
+	})
+	if err != nil {
+		log.Fatal("Invalid auth cookie configuration:", err)
+	}
+	return authCookies
+}
+
+// SetupOIDCService reads the identity providers listed in OIDC_PROVIDERS. Each
+// provider NAME is configured with OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID,
+// OIDC_<NAME>_CLIENT_SECRET, OIDC_<NAME>_REDIRECT_URL and optionally
+// OIDC_<NAME>_DISCOVERY_URL and OIDC_<NAME>_SCOPES.
+func SetupOIDCService() *services.OIDCService {
+	var configs []services.OIDCProviderConfig
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		configs = append(configs, services.OIDCProviderConfig{
+			Name:         name,
+			IssuerURL:    os.Getenv(prefix + "ISSUER"),
+			DiscoveryURL: os.Getenv(prefix + "DISCOVERY_URL"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		})
+	}
+
+	oidcService, err := services.NewOIDCService(configs)
+	if err != nil {
+		log.Fatal("Invalid OIDC configuration:", err)
+	}
+	return oidcService
+}
+
+// SetupKeyManager loads the JWT signing keys and schedules their rotation. The
+// private keys are stored encrypted with JWT_KEY_ENCRYPTION_KEY.
+func SetupKeyManager(repo entities.SigningKeyRepository) *services.KeyManager {
+	algorithm := os.Getenv("JWT_SIGNING_ALG")
+	if algorithm == "" {
+		algorithm = services.AlgorithmRS256
+	}
+
+	rotationInterval := GetDurationEnv("JWT_KEY_ROTATION_INTERVAL", 30*24*time.Hour)
+	gracePeriod := GetDurationEnv("JWT_KEY_GRACE_PERIOD", 8*24*time.Hour)
+
+	keyManager, err := services.NewKeyManager(repo, algorithm, rotationInterval, gracePeriod, os.Getenv("JWT_KEY_ENCRYPTION_KEY"))
+	if err != nil {
+		log.Fatal("Invalid JWT key configuration:", err)
+	}
+
+	if err := keyManager.Load(context.TODO()); err != nil {
+		log.Fatal("Failed to load JWT signing keys:", err)
+	}
+	keyManager.StartRotation()
+
+	return keyManager
+}
+
+// GetDurationEnv reads a duration such as "15m", exiting if it is malformed
+func GetDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Invalid duration for %s: %v", key, err)
+	}
+	return duration
+}
+
+// GetIntEnv reads a non-negative number, exiting if it is malformed
+func GetIntEnv(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 0 {
+		log.Fatalf("Invalid number for %s: %s", key, value)
+	}
+	return number
+}
+
+// GetBoolEnv reads a true/false value, exiting if it is malformed
+func GetBoolEnv(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	flag, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("Invalid boolean for %s: %s", key, value)
+	}
+	return flag
+}
+
+// NewRevocationStore uses Redis when REDIS_ADDR is set so every instance sees
+// the same revoked tokens, and falls back to an in-memory store otherwise
+func NewRevocationStore() services.TokenRevocationStore {
+	redisAddr := os.Getenv("REDIS_ADDR")
+	if redisAddr == "" {
+		log.Println("Warning: REDIS_ADDR not set, revoked tokens are kept in memory")
+		store := services.NewInMemoryRevocationStore()
+		store.StartCleanup()
+		return store
+	}
+
+	redisdb.InitRedis(redisAddr, os.Getenv("REDIS_PASSWORD"))
+	if err := redisdb.RedisClient.Ping(context.TODO()).Err(); err != nil {
+		log.Fatal("Failed to connect to Redis:", err)
+	}
+	return services.NewRedisRevocationStore(redisdb.RedisClient)
+}
+
+// NewLoginAttemptStore keeps failed login counts in Redis when it is configured,
+// so lockouts hold across instances. It must run after NewRevocationStore has
+// connected to Redis.
+func NewLoginAttemptStore() services.LoginAttemptStore {
+	if os.Getenv("REDIS_ADDR") == "" {
+		log.Println("Warning: REDIS_ADDR not set, failed login attempts are kept in memory")
+		store := services.NewInMemoryLoginAttemptStore()
+		store.StartCleanup()
+		return store
+	}
+	return services.NewRedisLoginAttemptStore(redisdb.RedisClient)
+}
+
+func ConnectToMongoDB(uri string) *mongo.Client {
+	client, err := db.ConnectMongoDB(uri)
+	if err != nil {
+		log.Fatal("Failed to connect to MongoDB:", err)
+	}
+
+	if err := client.Ping(context.TODO(), nil); err != nil {
+		log.Fatal("MongoDB ping failed:", err)
+	}
+
+	return client
+}
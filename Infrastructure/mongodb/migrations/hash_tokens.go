@@ -0,0 +1,66 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"g6_starter_project/Infrastructure/services"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// HashStoredTokens replaces the raw refresh, reset and verification tokens
+// written by older versions with their keyed hash, and drops stored access
+// tokens. It only touches documents that still hold raw tokens, so it is safe
+// to run on every start.
+func HashStoredTokens(ctx context.Context, db *mongo.Database, hasher *services.TokenHasher) error {
+	tokens := db.Collection("token")
+	if err := hashField(ctx, tokens, "refresh_token", "refresh_token_hash", hasher); err != nil {
+		return fmt.Errorf("failed to hash refresh tokens: %v", err)
+	}
+	if _, err := tokens.UpdateMany(ctx, bson.M{"access_token": bson.M{"$exists": true}}, bson.M{"$unset": bson.M{"access_token": ""}}); err != nil {
+		return fmt.Errorf("failed to remove access tokens: %v", err)
+	}
+
+	if err := hashField(ctx, db.Collection("users"), "reset_token", "reset_token_hash", hasher); err != nil {
+		return fmt.Errorf("failed to hash reset tokens: %v", err)
+	}
+
+	return nil
+}
+
+// hashField moves every raw string in rawField to the keyed hash in hashedField
+func hashField(ctx context.Context, collection *mongo.Collection, rawField, hashedField string, hasher *services.TokenHasher) error {
+	cursor, err := collection.Find(ctx, bson.M{rawField: bson.M{"$type": "string"}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	migrated := 0
+	for cursor.Next(ctx) {
+		var doc bson.M
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+		raw, _ := doc[rawField].(string)
+
+		update := bson.M{
+			"$set":   bson.M{hashedField: hasher.Hash(raw)},
+			"$unset": bson.M{rawField: ""},
+		}
+		if _, err := collection.UpdateOne(ctx, bson.M{"_id": doc["_id"]}, update); err != nil {
+			return err
+		}
+		migrated++
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	if migrated > 0 {
+		fmt.Printf("Hashed %d stored %s values in %s\n", migrated, rawField, collection.Name())
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EnsureImpersonationTokenIndexes lets MongoDB delete impersonation tokens once
+// they expire and indexes the lookups by admin and by impersonated user
+func EnsureImpersonationTokenIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("impersonation_tokens").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0).SetName("expires_at_ttl"),
+		},
+		{
+			Keys:    bson.D{{Key: "actor_id", Value: 1}},
+			Options: options.Index().SetName("actor_id"),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}},
+			Options: options.Index().SetName("user_id"),
+		},
+	})
+	return err
+}
@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"g6_starter_project/Infrastructure/services"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Migrate brings the database up to date for this version. It is run on every
+// start of the server and by `blogctl migrate`, and is safe to repeat. Failing
+// to create an index that only speeds things up is logged and ignored.
+func Migrate(ctx context.Context, db *mongo.Database, tokenHasher *services.TokenHasher) error {
+	if err := HashStoredTokens(ctx, db, tokenHasher); err != nil {
+		return fmt.Errorf("failed to hash stored tokens: %v", err)
+	}
+	if err := MoveUserTokensToStore(ctx, db); err != nil {
+		return fmt.Errorf("failed to move user tokens: %v", err)
+	}
+	if err := EnsureOneTimeTokenIndexes(ctx, db); err != nil {
+		return fmt.Errorf("failed to create one-time token indexes: %v", err)
+	}
+	// Email changes rely on this index to keep addresses unique
+	if err := EnsureUserEmailIndex(ctx, db); err != nil {
+		return fmt.Errorf("failed to create unique email index (merge users that share an email first): %v", err)
+	}
+	if err := EnsureSecurityEventIndexes(ctx, db); err != nil {
+		log.Println("Warning: Failed to create security event indexes:", err)
+	}
+	if err := EnsureDataExportIndexes(ctx, db); err != nil {
+		log.Println("Warning: Failed to create data export indexes:", err)
+	}
+	if err := EnsureUserIndexes(ctx, db); err != nil {
+		log.Println("Warning: Failed to create user indexes:", err)
+	}
+	if err := EnsureImpersonationTokenIndexes(ctx, db); err != nil {
+		log.Println("Warning: Failed to create impersonation token indexes:", err)
+	}
+	// Without it, instances starting together may each rotate to their own key
+	if err := EnsureSigningKeyIndexes(ctx, db); err != nil {
+		log.Println("Warning: Failed to create signing key indexes:", err)
+	}
+	return nil
+}
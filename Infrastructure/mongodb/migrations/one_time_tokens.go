@@ -0,0 +1,91 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EnsureOneTimeTokenIndexes lets MongoDB delete one-time tokens once they expire
+// and indexes the lookup used to replace a user's earlier tokens
+func EnsureOneTimeTokenIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("one_time_tokens").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0).SetName("expires_at_ttl"),
+		},
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "purpose", Value: 1}},
+			Options: options.Index().SetName("user_id_purpose"),
+		},
+	})
+	return err
+}
+
+// MoveUserTokensToStore moves verification and reset token hashes kept on user
+// documents by older versions into the one-time token store. A hash on an
+// unverified user was a verification token, on a verified user a reset token.
+// It only touches users that still hold a token, so it is safe to run on every start.
+func MoveUserTokensToStore(ctx context.Context, db *mongo.Database) error {
+	users := db.Collection("users")
+	tokens := db.Collection("one_time_tokens")
+
+	cursor, err := users.Find(ctx, bson.M{"reset_token_hash": bson.M{"$type": "string"}})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	now := time.Now()
+	moved := 0
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID         primitive.ObjectID `bson:"_id"`
+			IsVerified bool               `bson:"is_verified"`
+			TokenHash  string             `bson:"reset_token_hash"`
+			ExpiresAt  *time.Time         `bson:"reset_token_expires_at"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+
+		token := entities.OneTimeToken{
+			TokenHash: doc.TokenHash,
+			Purpose:   entities.TokenPurposeVerifyEmail,
+			UserID:    doc.ID.Hex(),
+			ExpiresAt: now,
+			CreatedAt: now,
+		}
+		if doc.IsVerified {
+			token.Purpose = entities.TokenPurposeResetPassword
+		}
+		if doc.ExpiresAt != nil {
+			token.ExpiresAt = *doc.ExpiresAt
+		}
+
+		if _, err := tokens.InsertOne(ctx, token); err != nil && !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+
+		update := bson.M{"$unset": bson.M{"reset_token_hash": "", "reset_token_expires_at": ""}}
+		if _, err := users.UpdateOne(ctx, bson.M{"_id": doc.ID}, update); err != nil {
+			return err
+		}
+		moved++
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	if moved > 0 {
+		fmt.Printf("Moved %d stored user tokens to one_time_tokens\n", moved)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EnsureSigningKeyIndexes makes "replaces" unique, so instances rotating at the
+// same time agree on one new key. Keys stored before the field existed are left out.
+func EnsureSigningKeyIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("signing_keys").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "replaces", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"replaces": bson.M{"$type": "string"}}).
+			SetName("replaces_unique"),
+	})
+	return err
+}
@@ -0,0 +1,40 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EnsureUserEmailIndex creates the unique index on user emails, which makes
+// email changes safe against two accounts claiming the same address at once.
+// It fails if existing users already share an email; those must be merged by hand.
+func EnsureUserEmailIndex(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("users").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true).SetName("email_unique"),
+	})
+	return err
+}
+
+// EnsureUserIndexes creates the indexes that speed up finding users: those due
+// for purging, suspended users, and the admin user directory
+func EnsureUserIndexes(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection("users").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "delete_after", Value: 1}},
+			Options: options.Index().SetSparse(true).SetName("delete_after"),
+		},
+		{
+			Keys:    bson.D{{Key: "suspension.suspended_at", Value: 1}},
+			Options: options.Index().SetSparse(true).SetName("suspension"),
+		},
+		{
+			Keys:    bson.D{{Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("created_at"),
+		},
+	})
+	return err
+}
@@ -14,16 +14,17 @@ import (
 
 // SearchFilterOptions is a struct to hold all possible criteria for searching/filtering.
 type SearchFilterOptions struct {
-	AuthorID      *primitive.ObjectID
-	Tags          []string
-	Title         string
-	Page          int64
-	Limit         int64
-	StartDate     *time.Time
-	EndDate       *time.Time
-	SortBy        string
-	MinPopularity *int64
-	MaxPopularity *int64
+	AuthorID         *primitive.ObjectID
+	ExcludeAuthorIDs []primitive.ObjectID // authors whose posts are hidden, e.g. suspended users
+	Tags             []string
+	Title            string
+	Page             int64
+	Limit            int64
+	StartDate        *time.Time
+	EndDate          *time.Time
+	SortBy           string
+	MinPopularity    *int64
+	MaxPopularity    *int64
 }
 
 // IBlogRepository defines the contract for all blog data operations.
@@ -37,6 +38,13 @@ type IBlogRepository interface {
 	Find(ctx context.Context, options SearchFilterOptions) ([]entities.Blog, int64, error)
 	UpdateCounts(ctx context.Context, blogID primitive.ObjectID, likes, dislikes int64) error //new
 	IncrementCommentCount(ctx context.Context, blogID primitive.ObjectID) error
+	DecrementCommentCount(ctx context.Context, blogID primitive.ObjectID) error
+	SetCommentCount(ctx context.Context, blogID primitive.ObjectID, count int64) error
+	// Account deletion
+	FindIDsByAuthor(ctx context.Context, authorID primitive.ObjectID) ([]primitive.ObjectID, error)
+	DeleteByAuthor(ctx context.Context, authorID primitive.ObjectID) error
+	// Data export
+	FindByAuthor(ctx context.Context, authorID primitive.ObjectID) ([]entities.Blog, error)
 }
 
 // IBlogInteractionRepository defines the contract for interaction data.
@@ -45,10 +53,21 @@ type IBlogInteractionRepository interface {
 	Upsert(ctx context.Context, interaction *entities.BlogInteraction) error
 	GetPopularityCounts(ctx context.Context, blogID primitive.ObjectID) (likes int64, dislikes int64, views int64, err error)
 	FindByBlogAndUser(ctx context.Context, blogID, userID primitive.ObjectID) (*entities.BlogInteraction, error) //new
+	FindBlogIDsByUser(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error)
+	FindByUser(ctx context.Context, userID primitive.ObjectID) ([]entities.BlogInteraction, error)
+	DeleteByUser(ctx context.Context, userID primitive.ObjectID) error
+	DeleteByBlogs(ctx context.Context, blogIDs []primitive.ObjectID) error
 }
 
 type ICommentRepository interface {
 	Create(ctx context.Context, comment *entities.Comment) (*entities.Comment, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*entities.Comment, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	CountByBlog(ctx context.Context, blogID primitive.ObjectID) (int64, error)
+	FindBlogIDsByAuthor(ctx context.Context, authorID primitive.ObjectID) ([]primitive.ObjectID, error)
+	FindByAuthor(ctx context.Context, authorID primitive.ObjectID) ([]entities.Comment, error)
+	DeleteByAuthor(ctx context.Context, authorID primitive.ObjectID) error
+	DeleteByBlogs(ctx context.Context, blogIDs []primitive.ObjectID) error
 }
 
 type mongoBlogRepository struct {
@@ -119,8 +138,12 @@ func (r *mongoBlogRepository) Find(ctx context.Context, filterOptions SearchFilt
 	// --- STAGE 1: Build the Complete Filter ---
 	filter := bson.M{}
 
-	if filterOptions.AuthorID != nil {
+	if filterOptions.AuthorID != nil && len(filterOptions.ExcludeAuthorIDs) > 0 {
+		filter["author_id"] = bson.M{"$eq": filterOptions.AuthorID, "$nin": filterOptions.ExcludeAuthorIDs}
+	} else if filterOptions.AuthorID != nil {
 		filter["author_id"] = filterOptions.AuthorID
+	} else if len(filterOptions.ExcludeAuthorIDs) > 0 {
+		filter["author_id"] = bson.M{"$nin": filterOptions.ExcludeAuthorIDs}
 	}
 	if filterOptions.Title != "" {
 		filter["title"] = bson.M{"$regex": filterOptions.Title, "$options": "i"}
@@ -285,6 +308,29 @@ func (r *mongoCommentRepository) Create(ctx context.Context, comment *entities.C
 	return comment, nil
 }
 
+func (r *mongoCommentRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*entities.Comment, error) {
+	var comment entities.Comment
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&comment)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("comment not found")
+		}
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (r *mongoCommentRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return errors.New("comment not found")
+	}
+	return nil
+}
+
 func (r *mongoBlogRepository) IncrementCommentCount(ctx context.Context, blogID primitive.ObjectID) error {
 	filter := bson.M{"_id": blogID}
 	// Use the $inc operator for an atomic and fast increment operation.
@@ -292,3 +338,123 @@ func (r *mongoBlogRepository) IncrementCommentCount(ctx context.Context, blogID
 	_, err := r.collection.UpdateOne(ctx, filter, update)
 	return err
 }
+
+func (r *mongoBlogRepository) DecrementCommentCount(ctx context.Context, blogID primitive.ObjectID) error {
+	// Never take the count below zero
+	filter := bson.M{"_id": blogID, "comment_count": bson.M{"$gt": 0}}
+	update := bson.M{"$inc": bson.M{"comment_count": -1}}
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// SetCommentCount overwrites the denormalized comment count with a recount
+func (r *mongoBlogRepository) SetCommentCount(ctx context.Context, blogID primitive.ObjectID, count int64) error {
+	filter := bson.M{"_id": blogID}
+	update := bson.M{"$set": bson.M{"comment_count": count}}
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// FindIDsByAuthor returns the IDs of every post an author wrote
+func (r *mongoBlogRepository) FindIDsByAuthor(ctx context.Context, authorID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	return distinctObjectIDs(ctx, r.collection, "_id", bson.M{"author_id": authorID})
+}
+
+// DeleteByAuthor deletes every post an author wrote
+func (r *mongoBlogRepository) DeleteByAuthor(ctx context.Context, authorID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"author_id": authorID})
+	return err
+}
+
+// FindBlogIDsByUser returns the posts a user has viewed or reacted to
+func (r *mongoBlogInteractionRepository) FindBlogIDsByUser(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	return distinctObjectIDs(ctx, r.collection, "blog_id", bson.M{"user_id": userID})
+}
+
+// DeleteByUser deletes every interaction of a user
+func (r *mongoBlogInteractionRepository) DeleteByUser(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
+
+// DeleteByBlogs deletes every interaction with the given posts
+func (r *mongoBlogInteractionRepository) DeleteByBlogs(ctx context.Context, blogIDs []primitive.ObjectID) error {
+	if len(blogIDs) == 0 {
+		return nil
+	}
+	_, err := r.collection.DeleteMany(ctx, bson.M{"blog_id": bson.M{"$in": blogIDs}})
+	return err
+}
+
+// CountByBlog counts the comments on a post
+func (r *mongoCommentRepository) CountByBlog(ctx context.Context, blogID primitive.ObjectID) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"blog_id": blogID})
+}
+
+// FindBlogIDsByAuthor returns the posts an author has commented on
+func (r *mongoCommentRepository) FindBlogIDsByAuthor(ctx context.Context, authorID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	return distinctObjectIDs(ctx, r.collection, "blog_id", bson.M{"author_id": authorID})
+}
+
+// DeleteByAuthor deletes every comment an author wrote
+func (r *mongoCommentRepository) DeleteByAuthor(ctx context.Context, authorID primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"author_id": authorID})
+	return err
+}
+
+// DeleteByBlogs deletes every comment on the given posts
+func (r *mongoCommentRepository) DeleteByBlogs(ctx context.Context, blogIDs []primitive.ObjectID) error {
+	if len(blogIDs) == 0 {
+		return nil
+	}
+	_, err := r.collection.DeleteMany(ctx, bson.M{"blog_id": bson.M{"$in": blogIDs}})
+	return err
+}
+
+// FindByAuthor returns every post an author wrote, oldest first
+func (r *mongoBlogRepository) FindByAuthor(ctx context.Context, authorID primitive.ObjectID) ([]entities.Blog, error) {
+	blogs := []entities.Blog{}
+	err := findAllOldestFirst(ctx, r.collection, bson.M{"author_id": authorID}, &blogs)
+	return blogs, err
+}
+
+// FindByUser returns every interaction of a user, oldest first
+func (r *mongoBlogInteractionRepository) FindByUser(ctx context.Context, userID primitive.ObjectID) ([]entities.BlogInteraction, error) {
+	interactions := []entities.BlogInteraction{}
+	err := findAllOldestFirst(ctx, r.collection, bson.M{"user_id": userID}, &interactions)
+	return interactions, err
+}
+
+// FindByAuthor returns every comment an author wrote, oldest first
+func (r *mongoCommentRepository) FindByAuthor(ctx context.Context, authorID primitive.ObjectID) ([]entities.Comment, error) {
+	comments := []entities.Comment{}
+	err := findAllOldestFirst(ctx, r.collection, bson.M{"author_id": authorID}, &comments)
+	return comments, err
+}
+
+// findAllOldestFirst decodes every matching document, in insertion order, into results
+func findAllOldestFirst(ctx context.Context, collection *mongo.Collection, filter bson.M, results interface{}) error {
+	cursor, err := collection.Find(ctx, filter, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	return cursor.All(ctx, results)
+}
+
+// distinctObjectIDs returns the distinct ObjectID values of a field in the matching documents
+func distinctObjectIDs(ctx context.Context, collection *mongo.Collection, field string, filter bson.M) ([]primitive.ObjectID, error) {
+	values, err := collection.Distinct(ctx, field, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(values))
+	for _, value := range values {
+		if id, ok := value.(primitive.ObjectID); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
@@ -85,4 +85,15 @@ func (r *ChatRepositoryImpl) DeleteChat(id string) error {
 	filter := bson.M{"_id": objectID}
 	_, err = r.db.DeleteOne(context.TODO(), filter)
 	return err
-}	
+}
+
+// DeleteChatsByUserID deletes every chat of a user
+func (r *ChatRepositoryImpl) DeleteChatsByUserID(userID string) error {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+
+	_, err = r.db.DeleteMany(context.TODO(), bson.M{"user_id": objectID})
+	return err
+}
@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type DataExportRepositoryImpl struct {
+	db *mongo.Collection
+}
+
+func NewDataExportRepository(db *mongo.Collection) entities.DataExportRepository {
+	return &DataExportRepositoryImpl{db: db}
+}
+
+// Create stores a new export
+func (r *DataExportRepositoryImpl) Create(ctx context.Context, export *entities.DataExport) error {
+	_, err := r.db.InsertOne(ctx, export)
+	return err
+}
+
+// HasPending reports whether the user has an unexpired export still being built
+func (r *DataExportRepositoryImpl) HasPending(ctx context.Context, userID string, now time.Time) (bool, error) {
+	filter := bson.M{
+		"user_id":    userID,
+		"status":     entities.DataExportPending,
+		"expires_at": bson.M{"$gt": now},
+	}
+
+	count, err := r.db.CountDocuments(ctx, filter)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Complete stores the finished archive and the hash of its download token
+func (r *DataExportRepositoryImpl) Complete(ctx context.Context, id, tokenHash string, archive []byte, completedAt, expiresAt time.Time) error {
+	update := bson.M{"$set": bson.M{
+		"status":       entities.DataExportReady,
+		"token_hash":   tokenHash,
+		"archive":      archive,
+		"size":         len(archive),
+		"completed_at": completedAt,
+		"expires_at":   expiresAt,
+	}}
+
+	result, err := r.db.UpdateOne(ctx, bson.M{"_id": id, "status": entities.DataExportPending}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("export not found")
+	}
+	return nil
+}
+
+// Fail marks an export that could not be built
+func (r *DataExportRepositoryImpl) Fail(ctx context.Context, id, reason string, completedAt time.Time) error {
+	update := bson.M{"$set": bson.M{
+		"status":       entities.DataExportFailed,
+		"error":        reason,
+		"completed_at": completedAt,
+	}}
+
+	_, err := r.db.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+// FindByTokenHash finds a ready, unexpired export by the keyed hash of its download token
+func (r *DataExportRepositoryImpl) FindByTokenHash(ctx context.Context, tokenHash string, now time.Time) (*entities.DataExport, error) {
+	filter := bson.M{
+		"token_hash": tokenHash,
+		"status":     entities.DataExportReady,
+		"expires_at": bson.M{"$gt": now},
+	}
+
+	var export entities.DataExport
+	err := r.db.FindOne(ctx, filter).Decode(&export)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("export not found")
+		}
+		return nil, err
+	}
+	return &export, nil
+}
+
+// DeleteByUserID deletes all of a user's exports
+func (r *DataExportRepositoryImpl) DeleteByUserID(ctx context.Context, userID string) error {
+	_, err := r.db.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"context"
+
+	"g6_starter_project/Domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ImpersonationTokenRepositoryImpl struct {
+	db *mongo.Collection
+}
+
+func NewImpersonationTokenRepository(db *mongo.Collection) entities.ImpersonationTokenRepository {
+	return &ImpersonationTokenRepositoryImpl{db: db}
+}
+
+// Create stores an impersonation token
+func (r *ImpersonationTokenRepositoryImpl) Create(ctx context.Context, token *entities.ImpersonationToken) error {
+	_, err := r.db.InsertOne(ctx, token)
+	return err
+}
+
+// FindByUser returns the impersonation tokens the user was issued as an admin
+// or is impersonated with
+func (r *ImpersonationTokenRepositoryImpl) FindByUser(ctx context.Context, userID string) ([]entities.ImpersonationToken, error) {
+	cursor, err := r.db.Find(ctx, impersonationUserFilter(userID))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	tokens := []entities.ImpersonationToken{}
+	if err := cursor.All(ctx, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// DeleteByUser deletes the impersonation tokens the user was issued as an admin
+// or is impersonated with
+func (r *ImpersonationTokenRepositoryImpl) DeleteByUser(ctx context.Context, userID string) error {
+	_, err := r.db.DeleteMany(ctx, impersonationUserFilter(userID))
+	return err
+}
+
+func impersonationUserFilter(userID string) bson.M {
+	return bson.M{"$or": []bson.M{
+		{"actor_id": userID},
+		{"user_id": userID},
+	}}
+}
@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"context"
+
+	"g6_starter_project/Domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MFAPolicyRepositoryImpl struct {
+	db *mongo.Collection
+}
+
+func NewMFAPolicyRepository(db *mongo.Collection) entities.MFAPolicyRepository {
+	return &MFAPolicyRepositoryImpl{db: db}
+}
+
+// GetByRole returns the policy of a role, or nil if none was set
+func (r *MFAPolicyRepositoryImpl) GetByRole(ctx context.Context, role string) (*entities.MFAPolicy, error) {
+	var policy entities.MFAPolicy
+	err := r.db.FindOne(ctx, bson.M{"_id": role}).Decode(&policy)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// GetAll returns every configured policy
+func (r *MFAPolicyRepositoryImpl) GetAll(ctx context.Context) ([]entities.MFAPolicy, error) {
+	cursor, err := r.db.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var policies []entities.MFAPolicy
+	if err = cursor.All(ctx, &policies); err != nil {
+		return nil, err
+	}
+
+	// Return empty slice instead of nil if no policies found
+	if policies == nil {
+		policies = []entities.MFAPolicy{}
+	}
+	return policies, nil
+}
+
+// Upsert creates or replaces the policy of a role
+func (r *MFAPolicyRepositoryImpl) Upsert(ctx context.Context, policy *entities.MFAPolicy) error {
+	_, err := r.db.ReplaceOne(ctx, bson.M{"_id": policy.Role}, policy, options.Replace().SetUpsert(true))
+	return err
+}
@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type OIDCStateRepositoryImpl struct {
+	db *mongo.Collection
+}
+
+func NewOIDCStateRepository(db *mongo.Collection) entities.OIDCStateRepository {
+	return &OIDCStateRepositoryImpl{db: db}
+}
+
+// Create stores a pending sign-in
+func (r *OIDCStateRepositoryImpl) Create(ctx context.Context, state *entities.OIDCState) error {
+	_, err := r.db.InsertOne(ctx, state)
+	return err
+}
+
+// Consume removes and returns an unexpired pending sign-in, so a state can only be used once
+func (r *OIDCStateRepositoryImpl) Consume(ctx context.Context, state string, now time.Time) (*entities.OIDCState, error) {
+	filter := bson.M{"_id": state, "expires_at": bson.M{"$gt": now}}
+
+	var pending entities.OIDCState
+	err := r.db.FindOneAndDelete(ctx, filter).Decode(&pending)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("state not found")
+		}
+		return nil, err
+	}
+
+	// Expired states are cleaned up opportunistically; failing to do so does not affect this login
+	r.db.DeleteMany(ctx, bson.M{"expires_at": bson.M{"$lte": now}})
+
+	return &pending, nil
+}
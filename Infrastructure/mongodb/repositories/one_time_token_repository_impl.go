@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type OneTimeTokenRepositoryImpl struct {
+	db *mongo.Collection
+}
+
+func NewOneTimeTokenRepository(db *mongo.Collection) entities.OneTimeTokenRepository {
+	return &OneTimeTokenRepositoryImpl{db: db}
+}
+
+// Create stores a one-time token
+func (r *OneTimeTokenRepositoryImpl) Create(ctx context.Context, token *entities.OneTimeToken) error {
+	_, err := r.db.InsertOne(ctx, token)
+	return err
+}
+
+// Find returns an unexpired token issued for the purpose without using it up
+func (r *OneTimeTokenRepositoryImpl) Find(ctx context.Context, purpose, tokenHash, bindingHash string, now time.Time) (*entities.OneTimeToken, error) {
+	var token entities.OneTimeToken
+	err := r.db.FindOne(ctx, oneTimeTokenFilter(purpose, tokenHash, bindingHash, now)).Decode(&token)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("token not found")
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Consume removes and returns an unexpired token issued for the purpose, so each
+// token works at most once. A token presented without its binding is left in place.
+func (r *OneTimeTokenRepositoryImpl) Consume(ctx context.Context, purpose, tokenHash, bindingHash string, now time.Time) (*entities.OneTimeToken, error) {
+	var token entities.OneTimeToken
+	err := r.db.FindOneAndDelete(ctx, oneTimeTokenFilter(purpose, tokenHash, bindingHash, now)).Decode(&token)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("token not found")
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// DeleteByUser deletes the user's outstanding tokens for the given purposes, or
+// every outstanding token of the user when no purpose is given
+func (r *OneTimeTokenRepositoryImpl) DeleteByUser(ctx context.Context, userID string, purposes ...string) error {
+	filter := bson.M{"user_id": userID}
+	if len(purposes) > 0 {
+		filter["purpose"] = bson.M{"$in": purposes}
+	}
+
+	_, err := r.db.DeleteMany(ctx, filter)
+	return err
+}
+
+// oneTimeTokenFilter matches the unexpired token with the hash, purpose and binding
+func oneTimeTokenFilter(purpose, tokenHash, bindingHash string, now time.Time) bson.M {
+	return bson.M{
+		"_id":          tokenHash,
+		"purpose":      purpose,
+		"binding_hash": bindingHash,
+		"expires_at":   bson.M{"$gt": now},
+	}
+}
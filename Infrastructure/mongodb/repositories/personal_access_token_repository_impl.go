@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PersonalAccessTokenRepositoryImpl struct {
+	db *mongo.Collection
+}
+
+func NewPersonalAccessTokenRepository(db *mongo.Collection) entities.PersonalAccessTokenRepository {
+	return &PersonalAccessTokenRepositoryImpl{db: db}
+}
+
+// Create stores a new personal access token
+func (r *PersonalAccessTokenRepositoryImpl) Create(ctx context.Context, token *entities.PersonalAccessToken) error {
+	_, err := r.db.InsertOne(ctx, token)
+	return err
+}
+
+// FindByHash finds a token by the keyed hash of its value
+func (r *PersonalAccessTokenRepositoryImpl) FindByHash(ctx context.Context, tokenHash string) (*entities.PersonalAccessToken, error) {
+	var token entities.PersonalAccessToken
+	err := r.db.FindOne(ctx, bson.M{"token_hash": tokenHash}).Decode(&token)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("token not found")
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// FindByUserID returns a user's tokens, newest first
+func (r *PersonalAccessTokenRepositoryImpl) FindByUserID(ctx context.Context, userID string) ([]entities.PersonalAccessToken, error) {
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+	cursor, err := r.db.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tokens []entities.PersonalAccessToken
+	if err = cursor.All(ctx, &tokens); err != nil {
+		return nil, err
+	}
+
+	// Return empty slice instead of nil if no tokens found
+	if tokens == nil {
+		tokens = []entities.PersonalAccessToken{}
+	}
+	return tokens, nil
+}
+
+// CountByUserID counts a user's tokens
+func (r *PersonalAccessTokenRepositoryImpl) CountByUserID(ctx context.Context, userID string) (int64, error) {
+	return r.db.CountDocuments(ctx, bson.M{"user_id": userID})
+}
+
+// TouchLastUsed records when a token was last used
+func (r *PersonalAccessTokenRepositoryImpl) TouchLastUsed(ctx context.Context, id string, usedAt time.Time) error {
+	_, err := r.db.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"last_used_at": usedAt}})
+	return err
+}
+
+// DeleteByIDForUser deletes a token only if it belongs to the user
+func (r *PersonalAccessTokenRepositoryImpl) DeleteByIDForUser(ctx context.Context, id, userID string) (bool, error) {
+	result, err := r.db.DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount == 1, nil
+}
+
+// DeleteByUserID deletes every token of a user
+func (r *PersonalAccessTokenRepositoryImpl) DeleteByUserID(ctx context.Context, userID string) error {
+	_, err := r.db.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
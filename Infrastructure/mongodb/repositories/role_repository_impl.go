@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"g6_starter_project/Domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RoleRepositoryImpl struct {
+	db *mongo.Collection
+}
+
+func NewRoleRepository(db *mongo.Collection) entities.RoleRepository {
+	return &RoleRepositoryImpl{db: db}
+}
+
+// GetByName finds a role by name
+func (r *RoleRepositoryImpl) GetByName(ctx context.Context, name string) (*entities.Role, error) {
+	var role entities.Role
+	err := r.db.FindOne(ctx, bson.M{"_id": name}).Decode(&role)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("role not found")
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+// GetAll returns every role sorted by name
+func (r *RoleRepositoryImpl) GetAll(ctx context.Context) ([]entities.Role, error) {
+	cursor, err := r.db.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var roles []entities.Role
+	if err = cursor.All(ctx, &roles); err != nil {
+		return nil, err
+	}
+
+	// Return empty slice instead of nil if no roles found
+	if roles == nil {
+		roles = []entities.Role{}
+	}
+	return roles, nil
+}
+
+// Upsert creates or replaces a role
+func (r *RoleRepositoryImpl) Upsert(ctx context.Context, role *entities.Role) error {
+	_, err := r.db.ReplaceOne(ctx, bson.M{"_id": role.Name}, role, options.Replace().SetUpsert(true))
+	return err
+}
+
+// CreateIfMissing inserts a role unless one with the same name exists, keeping
+// any changes made to it since
+func (r *RoleRepositoryImpl) CreateIfMissing(ctx context.Context, role *entities.Role) error {
+	fields := bson.M{
+		"description": role.Description,
+		"permissions": role.Permissions,
+		"built_in":    role.BuiltIn,
+		"created_at":  role.CreatedAt,
+		"updated_at":  role.UpdatedAt,
+	}
+	_, err := r.db.UpdateOne(ctx,
+		bson.M{"_id": role.Name},
+		bson.M{"$setOnInsert": fields},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// Delete removes a role
+func (r *RoleRepositoryImpl) Delete(ctx context.Context, name string) error {
+	result, err := r.db.DeleteOne(ctx, bson.M{"_id": name})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return errors.New("role not found")
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"context"
+
+	"g6_starter_project/Domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SecurityEventRepositoryImpl stores the audit log. It only ever inserts; events
+// are never updated or deleted through it.
+type SecurityEventRepositoryImpl struct {
+	db *mongo.Collection
+}
+
+func NewSecurityEventRepository(db *mongo.Collection) entities.SecurityEventRepository {
+	return &SecurityEventRepositoryImpl{db: db}
+}
+
+// Create appends an event to the log
+func (r *SecurityEventRepositoryImpl) Create(ctx context.Context, event *entities.SecurityEvent) error {
+	_, err := r.db.InsertOne(ctx, event)
+	return err
+}
+
+// Find returns one page of the events matching a filter, newest first, and the
+// total number of matching events
+func (r *SecurityEventRepositoryImpl) Find(ctx context.Context, filter entities.SecurityEventFilter) ([]entities.SecurityEvent, int64, error) {
+	query := bson.M{}
+	if filter.ActorID != "" {
+		query["actor_id"] = filter.ActorID
+	}
+	if filter.TargetID != "" {
+		query["target_id"] = filter.TargetID
+	}
+	if filter.Type != "" {
+		query["type"] = filter.Type
+	}
+	if filter.Outcome != "" {
+		query["outcome"] = filter.Outcome
+	}
+	if filter.IPAddress != "" {
+		query["ip_address"] = filter.IPAddress
+	}
+	if filter.From != nil || filter.To != nil {
+		createdAt := bson.M{}
+		if filter.From != nil {
+			createdAt["$gte"] = *filter.From
+		}
+		if filter.To != nil {
+			createdAt["$lte"] = *filter.To
+		}
+		query["created_at"] = createdAt
+	}
+
+	total, err := r.db.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip((filter.Page - 1) * filter.Limit).
+		SetLimit(filter.Limit)
+
+	cursor, err := r.db.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var events []entities.SecurityEvent
+	if err = cursor.All(ctx, &events); err != nil {
+		return nil, 0, err
+	}
+
+	// Return empty slice instead of nil if no events found
+	if events == nil {
+		events = []entities.SecurityEvent{}
+	}
+	return events, total, nil
+}
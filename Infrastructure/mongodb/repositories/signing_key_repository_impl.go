@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SigningKeyRepositoryImpl struct {
+	db *mongo.Collection
+}
+
+func NewSigningKeyRepository(db *mongo.Collection) entities.SigningKeyRepository {
+	return &SigningKeyRepositoryImpl{db: db}
+}
+
+// Create stores a new signing key. It returns entities.ErrSigningKeyReplaced
+// when a key replacing the same key exists already.
+func (r *SigningKeyRepositoryImpl) Create(ctx context.Context, key *entities.SigningKey) error {
+	_, err := r.db.InsertOne(ctx, key)
+	if mongo.IsDuplicateKeyError(err) {
+		return entities.ErrSigningKeyReplaced
+	}
+	return err
+}
+
+// FindUsable returns every key that can still verify tokens, newest first
+func (r *SigningKeyRepositoryImpl) FindUsable(ctx context.Context, now time.Time) ([]entities.SigningKey, error) {
+	filter := bson.M{
+		"$or": []bson.M{
+			{"expires_at": bson.M{"$exists": false}},
+			{"expires_at": nil},
+			{"expires_at": bson.M{"$gt": now}},
+		},
+	}
+	opts := options.Find().SetSort(bson.M{"created_at": -1})
+
+	cursor, err := r.db.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var keys []entities.SigningKey
+	if err = cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+
+	// Return empty slice instead of nil if no keys found
+	if keys == nil {
+		keys = []entities.SigningKey{}
+	}
+
+	return keys, nil
+}
+
+// Retire stops a key from signing and schedules the end of its grace period
+func (r *SigningKeyRepositoryImpl) Retire(ctx context.Context, id string, retiredAt, expiresAt time.Time) error {
+	filter := bson.M{"_id": id, "retired_at": bson.M{"$exists": false}}
+	update := bson.M{
+		"$set": bson.M{
+			"retired_at": retiredAt,
+			"expires_at": expiresAt,
+		},
+	}
+
+	result, err := r.db.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("signing key not found or already retired")
+	}
+	return nil
+}
+
+// DeleteExpired removes keys whose grace period is over
+func (r *SigningKeyRepositoryImpl) DeleteExpired(ctx context.Context, now time.Time) error {
+	_, err := r.db.DeleteMany(ctx, bson.M{"expires_at": bson.M{"$lte": now}})
+	return err
+}
+
+// UpdatePrivateKey replaces the stored private key, if it is still the one it
+// was read as, e.g. to encrypt a key stored in plain text
+func (r *SigningKeyRepositoryImpl) UpdatePrivateKey(ctx context.Context, id, oldPrivateKey, newPrivateKey string) error {
+	filter := bson.M{"_id": id, "private_key": oldPrivateKey}
+	update := bson.M{"$set": bson.M{"private_key": newPrivateKey}}
+
+	_, err := r.db.UpdateOne(ctx, filter, update)
+	return err
+}
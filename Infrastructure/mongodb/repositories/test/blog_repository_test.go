@@ -298,6 +298,37 @@ func TestBlogRepository_Find(t *testing.T) {
 		assert.Len(t, blogs, 2)
 	})
 
+	t.Run("should leave out blogs of excluded authors", func(t *testing.T) {
+		ts := setupBlogTestSuite(t)
+		defer ts.teardown(t)
+
+		authorID := primitive.NewObjectID()
+		hiddenAuthorID := primitive.NewObjectID()
+
+		_, err := ts.blogRepo.Create(context.TODO(), createTestBlogWithCustomFields(authorID, "Blog 1", "Content 1", []string{"test"}))
+		require.NoError(t, err)
+		_, err = ts.blogRepo.Create(context.TODO(), createTestBlogWithCustomFields(hiddenAuthorID, "Blog 2", "Content 2", []string{"test"}))
+		require.NoError(t, err)
+
+		options := repositories.SearchFilterOptions{
+			ExcludeAuthorIDs: []primitive.ObjectID{hiddenAuthorID},
+		}
+
+		blogs, count, err := ts.blogRepo.Find(context.TODO(), options)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+		require.Len(t, blogs, 1)
+		assert.Equal(t, authorID, blogs[0].AuthorID)
+
+		options.AuthorID = &hiddenAuthorID
+		blogs, count, err = ts.blogRepo.Find(context.TODO(), options)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+		assert.Empty(t, blogs)
+	})
+
 	t.Run("should find blogs by tags", func(t *testing.T) {
 		ts := setupBlogTestSuite(t)
 		defer ts.teardown(t)
@@ -523,4 +554,152 @@ func TestCommentRepository_Create(t *testing.T) {
 		assert.Equal(t, authorID, createdComment.AuthorID)
 		assert.Equal(t, comment.Content, createdComment.Content)
 	})
-} 
\ No newline at end of file
+} 
+func TestBlogRepository_DecrementCommentCount(t *testing.T) {
+	ts := setupBlogTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should decrement comment count but not below zero", func(t *testing.T) {
+		authorID := primitive.NewObjectID()
+		blog := createTestBlog(authorID)
+
+		createdBlog, err := ts.blogRepo.Create(context.TODO(), blog)
+		require.NoError(t, err)
+		require.NoError(t, ts.blogRepo.IncrementCommentCount(context.TODO(), createdBlog.ID))
+
+		assert.NoError(t, ts.blogRepo.DecrementCommentCount(context.TODO(), createdBlog.ID))
+		assert.NoError(t, ts.blogRepo.DecrementCommentCount(context.TODO(), createdBlog.ID))
+
+		updatedBlog, err := ts.blogRepo.FindByID(context.TODO(), createdBlog.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, updatedBlog.CommentCount)
+	})
+}
+
+func TestCommentRepository_FindByIDAndDelete(t *testing.T) {
+	ts := setupBlogTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should find and delete comment", func(t *testing.T) {
+		comment := createTestComment(primitive.NewObjectID(), primitive.NewObjectID())
+		createdComment, err := ts.commentRepo.Create(context.TODO(), comment)
+		require.NoError(t, err)
+
+		foundComment, err := ts.commentRepo.FindByID(context.TODO(), createdComment.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, comment.Content, foundComment.Content)
+
+		err = ts.commentRepo.Delete(context.TODO(), createdComment.ID)
+		assert.NoError(t, err)
+
+		_, err = ts.commentRepo.FindByID(context.TODO(), createdComment.ID)
+		assert.Error(t, err)
+		assert.Equal(t, "comment not found", err.Error())
+	})
+
+	t.Run("should return error deleting non-existent comment", func(t *testing.T) {
+		err := ts.commentRepo.Delete(context.TODO(), primitive.NewObjectID())
+
+		assert.Error(t, err)
+		assert.Equal(t, "comment not found", err.Error())
+	})
+}
+
+func TestBlogRepository_DeleteByAuthor(t *testing.T) {
+	ts := setupBlogTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should delete only the author's posts", func(t *testing.T) {
+		authorID := primitive.NewObjectID()
+		otherAuthorID := primitive.NewObjectID()
+		ownBlog, err := ts.blogRepo.Create(context.TODO(), createTestBlog(authorID))
+		require.NoError(t, err)
+		otherBlog, err := ts.blogRepo.Create(context.TODO(), createTestBlog(otherAuthorID))
+		require.NoError(t, err)
+
+		ids, err := ts.blogRepo.FindIDsByAuthor(context.TODO(), authorID)
+		assert.NoError(t, err)
+		assert.Equal(t, []primitive.ObjectID{ownBlog.ID}, ids)
+
+		err = ts.blogRepo.DeleteByAuthor(context.TODO(), authorID)
+		assert.NoError(t, err)
+
+		_, err = ts.blogRepo.FindByID(context.TODO(), ownBlog.ID)
+		assert.Error(t, err)
+		_, err = ts.blogRepo.FindByID(context.TODO(), otherBlog.ID)
+		assert.NoError(t, err)
+	})
+}
+
+func TestCommentRepository_DeleteByAuthor(t *testing.T) {
+	ts := setupBlogTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should delete the author's comments and allow a recount", func(t *testing.T) {
+		blog, err := ts.blogRepo.Create(context.TODO(), createTestBlog(primitive.NewObjectID()))
+		require.NoError(t, err)
+		authorID := primitive.NewObjectID()
+		_, err = ts.commentRepo.Create(context.TODO(), createTestComment(blog.ID, authorID))
+		require.NoError(t, err)
+		_, err = ts.commentRepo.Create(context.TODO(), createTestComment(blog.ID, primitive.NewObjectID()))
+		require.NoError(t, err)
+
+		blogIDs, err := ts.commentRepo.FindBlogIDsByAuthor(context.TODO(), authorID)
+		assert.NoError(t, err)
+		assert.Equal(t, []primitive.ObjectID{blog.ID}, blogIDs)
+
+		err = ts.commentRepo.DeleteByAuthor(context.TODO(), authorID)
+		assert.NoError(t, err)
+
+		count, err := ts.commentRepo.CountByBlog(context.TODO(), blog.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+
+		err = ts.blogRepo.SetCommentCount(context.TODO(), blog.ID, count)
+		assert.NoError(t, err)
+
+		updatedBlog, err := ts.blogRepo.FindByID(context.TODO(), blog.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, updatedBlog.CommentCount)
+	})
+
+	t.Run("should delete every comment on the given posts", func(t *testing.T) {
+		blogID := primitive.NewObjectID()
+		_, err := ts.commentRepo.Create(context.TODO(), createTestComment(blogID, primitive.NewObjectID()))
+		require.NoError(t, err)
+
+		err = ts.commentRepo.DeleteByBlogs(context.TODO(), []primitive.ObjectID{blogID})
+		assert.NoError(t, err)
+
+		count, err := ts.commentRepo.CountByBlog(context.TODO(), blogID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+	})
+}
+
+func TestBlogInteractionRepository_DeleteByUser(t *testing.T) {
+	ts := setupBlogTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should delete the user's reactions", func(t *testing.T) {
+		blogID := primitive.NewObjectID()
+		userID := primitive.NewObjectID()
+		like := "like"
+		interaction := createTestBlogInteraction(blogID, userID)
+		interaction.Reaction = &like
+		require.NoError(t, ts.interactionRepo.Upsert(context.TODO(), interaction))
+		require.NoError(t, ts.interactionRepo.Upsert(context.TODO(), createTestBlogInteraction(blogID, primitive.NewObjectID())))
+
+		blogIDs, err := ts.interactionRepo.FindBlogIDsByUser(context.TODO(), userID)
+		assert.NoError(t, err)
+		assert.Equal(t, []primitive.ObjectID{blogID}, blogIDs)
+
+		err = ts.interactionRepo.DeleteByUser(context.TODO(), userID)
+		assert.NoError(t, err)
+
+		likes, _, views, err := ts.interactionRepo.GetPopularityCounts(context.TODO(), blogID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), likes)
+		assert.Equal(t, int64(1), views)
+	})
+}
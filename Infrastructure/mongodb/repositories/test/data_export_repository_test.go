@@ -0,0 +1,135 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+	"g6_starter_project/Infrastructure/mongodb/repositories"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type DataExportTestSuite struct {
+	client           *mongo.Client
+	database         *mongo.Database
+	exportCollection *mongo.Collection
+	exportRepo       entities.DataExportRepository
+	config           *TestConfig
+}
+
+func setupDataExportTestSuite(t *testing.T) *DataExportTestSuite {
+	config := GetTestConfig()
+	client, database, _ := SetupTestDatabase(t, config)
+
+	// Create collection for data export testing
+	exportCollection := database.Collection("data_exports")
+
+	// Clear collection before each test
+	_, err := exportCollection.DeleteMany(context.TODO(), bson.M{})
+	require.NoError(t, err)
+
+	// Create repository
+	exportRepo := repositories.NewDataExportRepository(exportCollection)
+
+	return &DataExportTestSuite{
+		client:           client,
+		database:         database,
+		exportCollection: exportCollection,
+		exportRepo:       exportRepo,
+		config:           config,
+	}
+}
+
+func (ts *DataExportTestSuite) teardown(t *testing.T) {
+	CleanupTestDatabase(t, ts.client, ts.database)
+}
+
+func createTestDataExport(userID string) *entities.DataExport {
+	now := time.Now()
+	return &entities.DataExport{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		Status:    entities.DataExportPending,
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Hour),
+	}
+}
+
+func TestDataExportRepository_Lifecycle(t *testing.T) {
+	ts := setupDataExportTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should report a pending export until it completes", func(t *testing.T) {
+		export := createTestDataExport("user-1")
+		require.NoError(t, ts.exportRepo.Create(context.TODO(), export))
+
+		pending, err := ts.exportRepo.HasPending(context.TODO(), "user-1", time.Now())
+		assert.NoError(t, err)
+		assert.True(t, pending)
+
+		err = ts.exportRepo.Complete(context.TODO(), export.ID, "hash-1", []byte("archive"), time.Now(), time.Now().Add(time.Hour))
+		assert.NoError(t, err)
+
+		pending, err = ts.exportRepo.HasPending(context.TODO(), "user-1", time.Now())
+		assert.NoError(t, err)
+		assert.False(t, pending)
+
+		found, err := ts.exportRepo.FindByTokenHash(context.TODO(), "hash-1", time.Now())
+		assert.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, entities.DataExportReady, found.Status)
+		assert.Equal(t, []byte("archive"), found.Archive)
+		assert.Equal(t, len("archive"), found.Size)
+	})
+
+	t.Run("should not report an abandoned pending export", func(t *testing.T) {
+		export := createTestDataExport("user-2")
+		export.ExpiresAt = time.Now().Add(-time.Minute)
+		require.NoError(t, ts.exportRepo.Create(context.TODO(), export))
+
+		pending, err := ts.exportRepo.HasPending(context.TODO(), "user-2", time.Now())
+		assert.NoError(t, err)
+		assert.False(t, pending)
+	})
+
+	t.Run("should not find an expired export", func(t *testing.T) {
+		export := createTestDataExport("user-3")
+		require.NoError(t, ts.exportRepo.Create(context.TODO(), export))
+		err := ts.exportRepo.Complete(context.TODO(), export.ID, "hash-3", []byte("archive"), time.Now(), time.Now().Add(-time.Minute))
+		require.NoError(t, err)
+
+		found, err := ts.exportRepo.FindByTokenHash(context.TODO(), "hash-3", time.Now())
+		assert.Error(t, err)
+		assert.Nil(t, found)
+	})
+
+	t.Run("should not find a failed export", func(t *testing.T) {
+		export := createTestDataExport("user-4")
+		require.NoError(t, ts.exportRepo.Create(context.TODO(), export))
+		require.NoError(t, ts.exportRepo.Fail(context.TODO(), export.ID, "export is too large", time.Now()))
+
+		err := ts.exportRepo.Complete(context.TODO(), export.ID, "hash-4", []byte("archive"), time.Now(), time.Now().Add(time.Hour))
+		assert.Error(t, err)
+
+		found, err := ts.exportRepo.FindByTokenHash(context.TODO(), "hash-4", time.Now())
+		assert.Error(t, err)
+		assert.Nil(t, found)
+	})
+
+	t.Run("should delete a user's exports", func(t *testing.T) {
+		export := createTestDataExport("user-5")
+		require.NoError(t, ts.exportRepo.Create(context.TODO(), export))
+
+		assert.NoError(t, ts.exportRepo.DeleteByUserID(context.TODO(), "user-5"))
+
+		pending, err := ts.exportRepo.HasPending(context.TODO(), "user-5", time.Now())
+		assert.NoError(t, err)
+		assert.False(t, pending)
+	})
+}
@@ -0,0 +1,109 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+	"g6_starter_project/Infrastructure/mongodb/repositories"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ImpersonationTokenTestSuite struct {
+	client          *mongo.Client
+	database        *mongo.Database
+	tokenCollection *mongo.Collection
+	tokenRepo       entities.ImpersonationTokenRepository
+	config          *TestConfig
+}
+
+func setupImpersonationTokenTestSuite(t *testing.T) *ImpersonationTokenTestSuite {
+	config := GetTestConfig()
+	client, database, _ := SetupTestDatabase(t, config)
+
+	// Create collection for impersonation token testing
+	tokenCollection := database.Collection("impersonation_tokens")
+
+	// Clear collection before each test
+	_, err := tokenCollection.DeleteMany(context.TODO(), bson.M{})
+	require.NoError(t, err)
+
+	// Create repository
+	tokenRepo := repositories.NewImpersonationTokenRepository(tokenCollection)
+
+	return &ImpersonationTokenTestSuite{
+		client:          client,
+		database:        database,
+		tokenCollection: tokenCollection,
+		tokenRepo:       tokenRepo,
+		config:          config,
+	}
+}
+
+func (ts *ImpersonationTokenTestSuite) teardown(t *testing.T) {
+	CleanupTestDatabase(t, ts.client, ts.database)
+}
+
+func createTestImpersonationToken(actorID, userID string) *entities.ImpersonationToken {
+	return &entities.ImpersonationToken{
+		JTI:       uuid.NewString(),
+		ActorID:   actorID,
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(10 * time.Minute),
+		CreatedAt: time.Now(),
+	}
+}
+
+func TestImpersonationTokenRepository_FindByUser(t *testing.T) {
+	ts := setupImpersonationTokenTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should find tokens by admin and by impersonated user", func(t *testing.T) {
+		issued := createTestImpersonationToken("admin-1", "user-1")
+		require.NoError(t, ts.tokenRepo.Create(context.TODO(), issued))
+		other := createTestImpersonationToken("admin-2", "user-2")
+		require.NoError(t, ts.tokenRepo.Create(context.TODO(), other))
+
+		tokens, err := ts.tokenRepo.FindByUser(context.TODO(), "admin-1")
+		assert.NoError(t, err)
+		require.Len(t, tokens, 1)
+		assert.Equal(t, issued.JTI, tokens[0].JTI)
+
+		tokens, err = ts.tokenRepo.FindByUser(context.TODO(), "user-1")
+		assert.NoError(t, err)
+		require.Len(t, tokens, 1)
+		assert.Equal(t, issued.JTI, tokens[0].JTI)
+	})
+
+	t.Run("should find nothing for a user without tokens", func(t *testing.T) {
+		tokens, err := ts.tokenRepo.FindByUser(context.TODO(), "nobody")
+		assert.NoError(t, err)
+		assert.Empty(t, tokens)
+	})
+}
+
+func TestImpersonationTokenRepository_DeleteByUser(t *testing.T) {
+	ts := setupImpersonationTokenTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should delete only the user's tokens", func(t *testing.T) {
+		asActor := createTestImpersonationToken("admin-1", "user-1")
+		require.NoError(t, ts.tokenRepo.Create(context.TODO(), asActor))
+		asUser := createTestImpersonationToken("admin-2", "admin-1")
+		require.NoError(t, ts.tokenRepo.Create(context.TODO(), asUser))
+		other := createTestImpersonationToken("admin-2", "user-2")
+		require.NoError(t, ts.tokenRepo.Create(context.TODO(), other))
+
+		require.NoError(t, ts.tokenRepo.DeleteByUser(context.TODO(), "admin-1"))
+
+		count, err := ts.tokenCollection.CountDocuments(context.TODO(), bson.M{})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+}
@@ -0,0 +1,89 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+	"g6_starter_project/Infrastructure/mongodb/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MFAPolicyTestSuite struct {
+	client           *mongo.Client
+	database         *mongo.Database
+	policyCollection *mongo.Collection
+	policyRepo       entities.MFAPolicyRepository
+	config           *TestConfig
+}
+
+func setupMFAPolicyTestSuite(t *testing.T) *MFAPolicyTestSuite {
+	config := GetTestConfig()
+	client, database, _ := SetupTestDatabase(t, config)
+
+	// Create collection for MFA policy testing
+	policyCollection := database.Collection("mfa_policies")
+
+	// Clear collection before each test
+	_, err := policyCollection.DeleteMany(context.TODO(), bson.M{})
+	require.NoError(t, err)
+
+	// Create repository
+	policyRepo := repositories.NewMFAPolicyRepository(policyCollection)
+
+	return &MFAPolicyTestSuite{
+		client:           client,
+		database:         database,
+		policyCollection: policyCollection,
+		policyRepo:       policyRepo,
+		config:           config,
+	}
+}
+
+func (ts *MFAPolicyTestSuite) teardown(t *testing.T) {
+	CleanupTestDatabase(t, ts.client, ts.database)
+}
+
+func TestMFAPolicyRepository_GetByRole(t *testing.T) {
+	ts := setupMFAPolicyTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should return nil when no policy is set", func(t *testing.T) {
+		policy, err := ts.policyRepo.GetByRole(context.TODO(), "admin")
+
+		assert.NoError(t, err)
+		assert.Nil(t, policy)
+	})
+}
+
+func TestMFAPolicyRepository_Upsert(t *testing.T) {
+	ts := setupMFAPolicyTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should create and replace a role policy", func(t *testing.T) {
+		policy := &entities.MFAPolicy{Role: "admin", Required: true, UpdatedBy: "admin-id", UpdatedAt: time.Now()}
+		require.NoError(t, ts.policyRepo.Upsert(context.TODO(), policy))
+
+		found, err := ts.policyRepo.GetByRole(context.TODO(), "admin")
+		assert.NoError(t, err)
+		require.NotNil(t, found)
+		assert.True(t, found.Required)
+
+		policy.Required = false
+		require.NoError(t, ts.policyRepo.Upsert(context.TODO(), policy))
+
+		found, err = ts.policyRepo.GetByRole(context.TODO(), "admin")
+		assert.NoError(t, err)
+		require.NotNil(t, found)
+		assert.False(t, found.Required)
+
+		policies, err := ts.policyRepo.GetAll(context.TODO())
+		assert.NoError(t, err)
+		assert.Len(t, policies, 1)
+	})
+}
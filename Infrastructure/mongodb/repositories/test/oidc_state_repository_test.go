@@ -0,0 +1,90 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+	"g6_starter_project/Infrastructure/mongodb/repositories"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type OIDCStateTestSuite struct {
+	client          *mongo.Client
+	database        *mongo.Database
+	stateCollection *mongo.Collection
+	stateRepo       entities.OIDCStateRepository
+	config          *TestConfig
+}
+
+func setupOIDCStateTestSuite(t *testing.T) *OIDCStateTestSuite {
+	config := GetTestConfig()
+	client, database, _ := SetupTestDatabase(t, config)
+
+	// Create collection for OIDC state testing
+	stateCollection := database.Collection("oidc_states")
+
+	// Clear collection before each test
+	_, err := stateCollection.DeleteMany(context.TODO(), bson.M{})
+	require.NoError(t, err)
+
+	// Create repository
+	stateRepo := repositories.NewOIDCStateRepository(stateCollection)
+
+	return &OIDCStateTestSuite{
+		client:          client,
+		database:        database,
+		stateCollection: stateCollection,
+		stateRepo:       stateRepo,
+		config:          config,
+	}
+}
+
+func (ts *OIDCStateTestSuite) teardown(t *testing.T) {
+	CleanupTestDatabase(t, ts.client, ts.database)
+}
+
+func createTestOIDCState(expiresAt time.Time) *entities.OIDCState {
+	return &entities.OIDCState{
+		State:        uuid.NewString(),
+		Provider:     "google",
+		Nonce:        "test-nonce",
+		CodeVerifier: "test-verifier",
+		ExpiresAt:    expiresAt,
+		CreatedAt:    time.Now(),
+	}
+}
+
+func TestOIDCStateRepository_Consume(t *testing.T) {
+	ts := setupOIDCStateTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should consume a state only once", func(t *testing.T) {
+		state := createTestOIDCState(time.Now().Add(10 * time.Minute))
+		require.NoError(t, ts.stateRepo.Create(context.TODO(), state))
+
+		consumed, err := ts.stateRepo.Consume(context.TODO(), state.State, time.Now())
+		assert.NoError(t, err)
+		require.NotNil(t, consumed)
+		assert.Equal(t, "test-verifier", consumed.CodeVerifier)
+
+		consumed, err = ts.stateRepo.Consume(context.TODO(), state.State, time.Now())
+		assert.Error(t, err)
+		assert.Nil(t, consumed)
+	})
+
+	t.Run("should not consume an expired state", func(t *testing.T) {
+		state := createTestOIDCState(time.Now().Add(-time.Minute))
+		require.NoError(t, ts.stateRepo.Create(context.TODO(), state))
+
+		consumed, err := ts.stateRepo.Consume(context.TODO(), state.State, time.Now())
+		assert.Error(t, err)
+		assert.Nil(t, consumed)
+	})
+}
@@ -0,0 +1,161 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+	"g6_starter_project/Infrastructure/mongodb/repositories"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type OneTimeTokenTestSuite struct {
+	client          *mongo.Client
+	database        *mongo.Database
+	tokenCollection *mongo.Collection
+	tokenRepo       entities.OneTimeTokenRepository
+	config          *TestConfig
+}
+
+func setupOneTimeTokenTestSuite(t *testing.T) *OneTimeTokenTestSuite {
+	config := GetTestConfig()
+	client, database, _ := SetupTestDatabase(t, config)
+
+	// Create collection for one-time token testing
+	tokenCollection := database.Collection("one_time_tokens")
+
+	// Clear collection before each test
+	_, err := tokenCollection.DeleteMany(context.TODO(), bson.M{})
+	require.NoError(t, err)
+
+	// Create repository
+	tokenRepo := repositories.NewOneTimeTokenRepository(tokenCollection)
+
+	return &OneTimeTokenTestSuite{
+		client:          client,
+		database:        database,
+		tokenCollection: tokenCollection,
+		tokenRepo:       tokenRepo,
+		config:          config,
+	}
+}
+
+func (ts *OneTimeTokenTestSuite) teardown(t *testing.T) {
+	CleanupTestDatabase(t, ts.client, ts.database)
+}
+
+func createTestOneTimeToken(purpose, bindingHash string, expiresAt time.Time) *entities.OneTimeToken {
+	return &entities.OneTimeToken{
+		TokenHash:   "token-" + uuid.NewString(),
+		Purpose:     purpose,
+		UserID:      "user-1",
+		BindingHash: bindingHash,
+		ExpiresAt:   expiresAt,
+		CreatedAt:   time.Now(),
+	}
+}
+
+func TestOneTimeTokenRepository_Consume(t *testing.T) {
+	ts := setupOneTimeTokenTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should consume a token only once", func(t *testing.T) {
+		token := createTestOneTimeToken(entities.TokenPurposeVerifyEmail, "", time.Now().Add(time.Hour))
+		require.NoError(t, ts.tokenRepo.Create(context.TODO(), token))
+
+		consumed, err := ts.tokenRepo.Consume(context.TODO(), entities.TokenPurposeVerifyEmail, token.TokenHash, "", time.Now())
+		assert.NoError(t, err)
+		require.NotNil(t, consumed)
+		assert.Equal(t, "user-1", consumed.UserID)
+
+		consumed, err = ts.tokenRepo.Consume(context.TODO(), entities.TokenPurposeVerifyEmail, token.TokenHash, "", time.Now())
+		assert.Error(t, err)
+		assert.Nil(t, consumed)
+	})
+
+	t.Run("should not consume a token for another purpose", func(t *testing.T) {
+		token := createTestOneTimeToken(entities.TokenPurposeVerifyEmail, "", time.Now().Add(time.Hour))
+		require.NoError(t, ts.tokenRepo.Create(context.TODO(), token))
+
+		consumed, err := ts.tokenRepo.Consume(context.TODO(), entities.TokenPurposeResetPassword, token.TokenHash, "", time.Now())
+		assert.Error(t, err)
+		assert.Nil(t, consumed)
+	})
+
+	t.Run("should keep a token presented with the wrong binding", func(t *testing.T) {
+		token := createTestOneTimeToken(entities.TokenPurposeMagicLink, "nonce-hash", time.Now().Add(time.Hour))
+		require.NoError(t, ts.tokenRepo.Create(context.TODO(), token))
+
+		consumed, err := ts.tokenRepo.Consume(context.TODO(), entities.TokenPurposeMagicLink, token.TokenHash, "other-nonce", time.Now())
+		assert.Error(t, err)
+		assert.Nil(t, consumed)
+
+		consumed, err = ts.tokenRepo.Consume(context.TODO(), entities.TokenPurposeMagicLink, token.TokenHash, "nonce-hash", time.Now())
+		assert.NoError(t, err)
+		assert.NotNil(t, consumed)
+	})
+
+	t.Run("should not consume an expired token", func(t *testing.T) {
+		token := createTestOneTimeToken(entities.TokenPurposeResetPassword, "", time.Now().Add(-time.Minute))
+		require.NoError(t, ts.tokenRepo.Create(context.TODO(), token))
+
+		consumed, err := ts.tokenRepo.Consume(context.TODO(), entities.TokenPurposeResetPassword, token.TokenHash, "", time.Now())
+		assert.Error(t, err)
+		assert.Nil(t, consumed)
+	})
+}
+
+func TestOneTimeTokenRepository_DeleteByUser(t *testing.T) {
+	ts := setupOneTimeTokenTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should only delete tokens for the given purposes", func(t *testing.T) {
+		verify := createTestOneTimeToken(entities.TokenPurposeVerifyEmail, "", time.Now().Add(time.Hour))
+		reset := createTestOneTimeToken(entities.TokenPurposeResetPassword, "", time.Now().Add(time.Hour))
+		require.NoError(t, ts.tokenRepo.Create(context.TODO(), verify))
+		require.NoError(t, ts.tokenRepo.Create(context.TODO(), reset))
+
+		err := ts.tokenRepo.DeleteByUser(context.TODO(), "user-1", entities.TokenPurposeResetPassword)
+		assert.NoError(t, err)
+
+		_, err = ts.tokenRepo.Consume(context.TODO(), entities.TokenPurposeResetPassword, reset.TokenHash, "", time.Now())
+		assert.Error(t, err)
+
+		_, err = ts.tokenRepo.Consume(context.TODO(), entities.TokenPurposeVerifyEmail, verify.TokenHash, "", time.Now())
+		assert.NoError(t, err)
+	})
+}
+
+func TestOneTimeTokenRepository_Find(t *testing.T) {
+	ts := setupOneTimeTokenTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should find a token without using it up", func(t *testing.T) {
+		token := createTestOneTimeToken(entities.TokenPurposeResetPassword, "", time.Now().Add(time.Hour))
+		require.NoError(t, ts.tokenRepo.Create(context.TODO(), token))
+
+		found, err := ts.tokenRepo.Find(context.TODO(), entities.TokenPurposeResetPassword, token.TokenHash, "", time.Now())
+		assert.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, "user-1", found.UserID)
+
+		consumed, err := ts.tokenRepo.Consume(context.TODO(), entities.TokenPurposeResetPassword, token.TokenHash, "", time.Now())
+		assert.NoError(t, err)
+		assert.NotNil(t, consumed)
+	})
+
+	t.Run("should not find an expired token", func(t *testing.T) {
+		token := createTestOneTimeToken(entities.TokenPurposeResetPassword, "", time.Now().Add(-time.Minute))
+		require.NoError(t, ts.tokenRepo.Create(context.TODO(), token))
+
+		found, err := ts.tokenRepo.Find(context.TODO(), entities.TokenPurposeResetPassword, token.TokenHash, "", time.Now())
+		assert.Error(t, err)
+		assert.Nil(t, found)
+	})
+}
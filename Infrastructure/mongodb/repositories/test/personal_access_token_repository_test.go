@@ -0,0 +1,154 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+	"g6_starter_project/Infrastructure/mongodb/repositories"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type PersonalAccessTokenTestSuite struct {
+	client          *mongo.Client
+	database        *mongo.Database
+	tokenCollection *mongo.Collection
+	tokenRepo       entities.PersonalAccessTokenRepository
+	config          *TestConfig
+}
+
+func setupPersonalAccessTokenTestSuite(t *testing.T) *PersonalAccessTokenTestSuite {
+	config := GetTestConfig()
+	client, database, _ := SetupTestDatabase(t, config)
+
+	// Create collection for personal access token testing
+	tokenCollection := database.Collection("personal_access_tokens")
+
+	// Clear collection before each test
+	_, err := tokenCollection.DeleteMany(context.TODO(), bson.M{})
+	require.NoError(t, err)
+
+	// Create repository
+	tokenRepo := repositories.NewPersonalAccessTokenRepository(tokenCollection)
+
+	return &PersonalAccessTokenTestSuite{
+		client:          client,
+		database:        database,
+		tokenCollection: tokenCollection,
+		tokenRepo:       tokenRepo,
+		config:          config,
+	}
+}
+
+func (ts *PersonalAccessTokenTestSuite) teardown(t *testing.T) {
+	CleanupTestDatabase(t, ts.client, ts.database)
+}
+
+func createTestPersonalAccessToken(userID string) *entities.PersonalAccessToken {
+	now := time.Now()
+	return &entities.PersonalAccessToken{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		Name:      "CI publisher",
+		TokenHash: "hash-" + uuid.NewString(),
+		Prefix:    "bpat_abcdefg",
+		Scopes:    []string{entities.ScopeBlogWrite},
+		ExpiresAt: now.Add(90 * 24 * time.Hour),
+		CreatedAt: now,
+	}
+}
+
+func TestPersonalAccessTokenRepository_CreateAndFind(t *testing.T) {
+	ts := setupPersonalAccessTokenTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should find token by hash", func(t *testing.T) {
+		token := createTestPersonalAccessToken("user-1")
+		require.NoError(t, ts.tokenRepo.Create(context.TODO(), token))
+
+		found, err := ts.tokenRepo.FindByHash(context.TODO(), token.TokenHash)
+
+		assert.NoError(t, err)
+		assert.Equal(t, token.ID, found.ID)
+		assert.True(t, found.HasScope(entities.ScopeBlogWrite))
+		assert.False(t, found.HasScope(entities.ScopeAIUse))
+	})
+
+	t.Run("should return error for unknown hash", func(t *testing.T) {
+		found, err := ts.tokenRepo.FindByHash(context.TODO(), "unknown")
+
+		assert.Error(t, err)
+		assert.Nil(t, found)
+		assert.Equal(t, "token not found", err.Error())
+	})
+
+	t.Run("should list and count tokens by user", func(t *testing.T) {
+		require.NoError(t, ts.tokenRepo.Create(context.TODO(), createTestPersonalAccessToken("user-2")))
+		require.NoError(t, ts.tokenRepo.Create(context.TODO(), createTestPersonalAccessToken("user-2")))
+
+		tokens, err := ts.tokenRepo.FindByUserID(context.TODO(), "user-2")
+		assert.NoError(t, err)
+		assert.Len(t, tokens, 2)
+
+		count, err := ts.tokenRepo.CountByUserID(context.TODO(), "user-2")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+
+		tokens, err = ts.tokenRepo.FindByUserID(context.TODO(), "nobody")
+		assert.NoError(t, err)
+		assert.Empty(t, tokens)
+	})
+}
+
+func TestPersonalAccessTokenRepository_TouchLastUsed(t *testing.T) {
+	ts := setupPersonalAccessTokenTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should record last use", func(t *testing.T) {
+		token := createTestPersonalAccessToken("user-1")
+		require.NoError(t, ts.tokenRepo.Create(context.TODO(), token))
+
+		err := ts.tokenRepo.TouchLastUsed(context.TODO(), token.ID, time.Now())
+		assert.NoError(t, err)
+
+		found, err := ts.tokenRepo.FindByHash(context.TODO(), token.TokenHash)
+		assert.NoError(t, err)
+		assert.NotNil(t, found.LastUsedAt)
+	})
+}
+
+func TestPersonalAccessTokenRepository_Delete(t *testing.T) {
+	ts := setupPersonalAccessTokenTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should only delete the owner's token", func(t *testing.T) {
+		token := createTestPersonalAccessToken("user-1")
+		require.NoError(t, ts.tokenRepo.Create(context.TODO(), token))
+
+		deleted, err := ts.tokenRepo.DeleteByIDForUser(context.TODO(), token.ID, "user-2")
+		assert.NoError(t, err)
+		assert.False(t, deleted)
+
+		deleted, err = ts.tokenRepo.DeleteByIDForUser(context.TODO(), token.ID, "user-1")
+		assert.NoError(t, err)
+		assert.True(t, deleted)
+	})
+
+	t.Run("should delete every token of a user", func(t *testing.T) {
+		require.NoError(t, ts.tokenRepo.Create(context.TODO(), createTestPersonalAccessToken("user-3")))
+		require.NoError(t, ts.tokenRepo.Create(context.TODO(), createTestPersonalAccessToken("user-3")))
+
+		err := ts.tokenRepo.DeleteByUserID(context.TODO(), "user-3")
+		assert.NoError(t, err)
+
+		count, err := ts.tokenRepo.CountByUserID(context.TODO(), "user-3")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+	})
+}
@@ -0,0 +1,142 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+	"g6_starter_project/Infrastructure/mongodb/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type RoleTestSuite struct {
+	client         *mongo.Client
+	database       *mongo.Database
+	roleCollection *mongo.Collection
+	roleRepo       entities.RoleRepository
+	config         *TestConfig
+}
+
+func setupRoleTestSuite(t *testing.T) *RoleTestSuite {
+	config := GetTestConfig()
+	client, database, _ := SetupTestDatabase(t, config)
+
+	// Create collection for role testing
+	roleCollection := database.Collection("roles")
+
+	// Clear collection before each test
+	_, err := roleCollection.DeleteMany(context.TODO(), bson.M{})
+	require.NoError(t, err)
+
+	// Create repository
+	roleRepo := repositories.NewRoleRepository(roleCollection)
+
+	return &RoleTestSuite{
+		client:         client,
+		database:       database,
+		roleCollection: roleCollection,
+		roleRepo:       roleRepo,
+		config:         config,
+	}
+}
+
+func (ts *RoleTestSuite) teardown(t *testing.T) {
+	CleanupTestDatabase(t, ts.client, ts.database)
+}
+
+func createTestRole(name string, permissions ...string) *entities.Role {
+	now := time.Now()
+	return &entities.Role{
+		Name:        name,
+		Description: "Test role",
+		Permissions: permissions,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+func TestRoleRepository_Upsert(t *testing.T) {
+	ts := setupRoleTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should create and find role", func(t *testing.T) {
+		role := createTestRole("moderator", entities.PermissionPostsDeleteAny)
+		require.NoError(t, ts.roleRepo.Upsert(context.TODO(), role))
+
+		found, err := ts.roleRepo.GetByName(context.TODO(), "moderator")
+
+		assert.NoError(t, err)
+		assert.True(t, found.HasPermission(entities.PermissionPostsDeleteAny))
+		assert.False(t, found.HasPermission(entities.PermissionRolesManage))
+	})
+
+	t.Run("should replace permissions of existing role", func(t *testing.T) {
+		role := createTestRole("moderator", entities.PermissionCommentsDeleteAny)
+		require.NoError(t, ts.roleRepo.Upsert(context.TODO(), role))
+
+		found, err := ts.roleRepo.GetByName(context.TODO(), "moderator")
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{entities.PermissionCommentsDeleteAny}, found.Permissions)
+	})
+
+	t.Run("should return error for unknown role", func(t *testing.T) {
+		found, err := ts.roleRepo.GetByName(context.TODO(), "unknown")
+
+		assert.Error(t, err)
+		assert.Nil(t, found)
+		assert.Equal(t, "role not found", err.Error())
+	})
+}
+
+func TestRoleRepository_CreateIfMissing(t *testing.T) {
+	ts := setupRoleTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should not overwrite an existing role", func(t *testing.T) {
+		require.NoError(t, ts.roleRepo.Upsert(context.TODO(), createTestRole("editor")))
+
+		err := ts.roleRepo.CreateIfMissing(context.TODO(), createTestRole("editor", entities.PermissionPostsUpdateAny))
+		assert.NoError(t, err)
+
+		found, err := ts.roleRepo.GetByName(context.TODO(), "editor")
+		assert.NoError(t, err)
+		assert.Empty(t, found.Permissions)
+	})
+
+	t.Run("should create a missing role", func(t *testing.T) {
+		err := ts.roleRepo.CreateIfMissing(context.TODO(), createTestRole("writer", entities.PermissionPostsUpdateAny))
+		assert.NoError(t, err)
+
+		roles, err := ts.roleRepo.GetAll(context.TODO())
+		assert.NoError(t, err)
+		assert.Len(t, roles, 2)
+	})
+}
+
+func TestRoleRepository_Delete(t *testing.T) {
+	ts := setupRoleTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should delete role", func(t *testing.T) {
+		require.NoError(t, ts.roleRepo.Upsert(context.TODO(), createTestRole("writer")))
+
+		err := ts.roleRepo.Delete(context.TODO(), "writer")
+		assert.NoError(t, err)
+
+		_, err = ts.roleRepo.GetByName(context.TODO(), "writer")
+		assert.Error(t, err)
+	})
+
+	t.Run("should return error for unknown role", func(t *testing.T) {
+		err := ts.roleRepo.Delete(context.TODO(), "unknown")
+
+		assert.Error(t, err)
+		assert.Equal(t, "role not found", err.Error())
+	})
+}
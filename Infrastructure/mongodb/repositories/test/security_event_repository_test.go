@@ -0,0 +1,121 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+	"g6_starter_project/Infrastructure/mongodb/repositories"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type SecurityEventTestSuite struct {
+	client          *mongo.Client
+	database        *mongo.Database
+	eventCollection *mongo.Collection
+	eventRepo       entities.SecurityEventRepository
+	config          *TestConfig
+}
+
+func setupSecurityEventTestSuite(t *testing.T) *SecurityEventTestSuite {
+	config := GetTestConfig()
+	client, database, _ := SetupTestDatabase(t, config)
+
+	// Create collection for security event testing
+	eventCollection := database.Collection("security_events")
+
+	// Clear collection before each test
+	_, err := eventCollection.DeleteMany(context.TODO(), bson.M{})
+	require.NoError(t, err)
+
+	// Create repository
+	eventRepo := repositories.NewSecurityEventRepository(eventCollection)
+
+	return &SecurityEventTestSuite{
+		client:          client,
+		database:        database,
+		eventCollection: eventCollection,
+		eventRepo:       eventRepo,
+		config:          config,
+	}
+}
+
+func (ts *SecurityEventTestSuite) teardown(t *testing.T) {
+	CleanupTestDatabase(t, ts.client, ts.database)
+}
+
+func createTestSecurityEvent(eventType, outcome, targetID string, createdAt time.Time) *entities.SecurityEvent {
+	return &entities.SecurityEvent{
+		ID:        uuid.NewString(),
+		Type:      eventType,
+		Outcome:   outcome,
+		ActorID:   targetID,
+		TargetID:  targetID,
+		IPAddress: "127.0.0.1",
+		UserAgent: "test-agent",
+		CreatedAt: createdAt,
+	}
+}
+
+func TestSecurityEventRepository(t *testing.T) {
+	ts := setupSecurityEventTestSuite(t)
+	defer ts.teardown(t)
+
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Millisecond)
+
+	events := []*entities.SecurityEvent{
+		createTestSecurityEvent(entities.SecurityEventLogin, entities.OutcomeFailure, "user-1", now.Add(-3*time.Hour)),
+		createTestSecurityEvent(entities.SecurityEventLogin, entities.OutcomeSuccess, "user-1", now.Add(-2*time.Hour)),
+		createTestSecurityEvent(entities.SecurityEventLogout, entities.OutcomeSuccess, "user-1", now.Add(-time.Hour)),
+		createTestSecurityEvent(entities.SecurityEventLogin, entities.OutcomeSuccess, "user-2", now),
+	}
+	for _, event := range events {
+		require.NoError(t, ts.eventRepo.Create(ctx, event))
+	}
+
+	t.Run("should return a user's events newest first", func(t *testing.T) {
+		found, total, err := ts.eventRepo.Find(ctx, entities.SecurityEventFilter{TargetID: "user-1", Page: 1, Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), total)
+		require.Len(t, found, 3)
+		assert.Equal(t, entities.SecurityEventLogout, found[0].Type)
+		assert.Equal(t, entities.OutcomeFailure, found[2].Outcome)
+	})
+
+	t.Run("should filter by type, outcome and time", func(t *testing.T) {
+		from := now.Add(-150 * time.Minute)
+		found, total, err := ts.eventRepo.Find(ctx, entities.SecurityEventFilter{
+			Type:    entities.SecurityEventLogin,
+			Outcome: entities.OutcomeSuccess,
+			From:    &from,
+			Page:    1,
+			Limit:   10,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Len(t, found, 2)
+	})
+
+	t.Run("should paginate and count every match", func(t *testing.T) {
+		found, total, err := ts.eventRepo.Find(ctx, entities.SecurityEventFilter{Page: 2, Limit: 3})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), total)
+		require.Len(t, found, 1)
+		assert.Equal(t, "user-1", found[0].TargetID)
+	})
+
+	t.Run("should return an empty slice when nothing matches", func(t *testing.T) {
+		found, total, err := ts.eventRepo.Find(ctx, entities.SecurityEventFilter{IPAddress: "10.0.0.1", Page: 1, Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), total)
+		assert.NotNil(t, found)
+		assert.Empty(t, found)
+	})
+}
@@ -0,0 +1,189 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+	"g6_starter_project/Infrastructure/mongodb/migrations"
+	"g6_starter_project/Infrastructure/mongodb/repositories"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type SigningKeyTestSuite struct {
+	client        *mongo.Client
+	database      *mongo.Database
+	keyCollection *mongo.Collection
+	keyRepo       entities.SigningKeyRepository
+	config        *TestConfig
+}
+
+func setupSigningKeyTestSuite(t *testing.T) *SigningKeyTestSuite {
+	config := GetTestConfig()
+	client, database, _ := SetupTestDatabase(t, config)
+
+	// Create collection for signing key testing
+	keyCollection := database.Collection("signing_keys")
+
+	// Clear collection before each test
+	_, err := keyCollection.DeleteMany(context.TODO(), bson.M{})
+	require.NoError(t, err)
+
+	// Create repository
+	keyRepo := repositories.NewSigningKeyRepository(keyCollection)
+
+	return &SigningKeyTestSuite{
+		client:        client,
+		database:      database,
+		keyCollection: keyCollection,
+		keyRepo:       keyRepo,
+		config:        config,
+	}
+}
+
+func (ts *SigningKeyTestSuite) teardown(t *testing.T) {
+	CleanupTestDatabase(t, ts.client, ts.database)
+}
+
+func createTestSigningKey(createdAt time.Time) *entities.SigningKey {
+	return &entities.SigningKey{
+		ID:         uuid.NewString(),
+		Algorithm:  "EdDSA",
+		PrivateKey: "test-private-key",
+		PublicKey:  "test-public-key",
+		CreatedAt:  createdAt.UTC().Truncate(time.Millisecond),
+	}
+}
+
+func TestSigningKeyRepository_CreateAndFindUsable(t *testing.T) {
+	ts := setupSigningKeyTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should return keys newest first", func(t *testing.T) {
+		older := createTestSigningKey(time.Now().Add(-time.Hour))
+		newer := createTestSigningKey(time.Now())
+
+		require.NoError(t, ts.keyRepo.Create(context.TODO(), older))
+		require.NoError(t, ts.keyRepo.Create(context.TODO(), newer))
+
+		keys, err := ts.keyRepo.FindUsable(context.TODO(), time.Now())
+
+		assert.NoError(t, err)
+		require.Len(t, keys, 2)
+		assert.Equal(t, newer.ID, keys[0].ID)
+		assert.Equal(t, older.ID, keys[1].ID)
+		assert.Equal(t, "test-private-key", keys[0].PrivateKey)
+	})
+}
+
+func TestSigningKeyRepository_Retire(t *testing.T) {
+	ts := setupSigningKeyTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should keep retired key usable during grace period", func(t *testing.T) {
+		key := createTestSigningKey(time.Now())
+		require.NoError(t, ts.keyRepo.Create(context.TODO(), key))
+
+		now := time.Now()
+		err := ts.keyRepo.Retire(context.TODO(), key.ID, now, now.Add(time.Hour))
+		assert.NoError(t, err)
+
+		keys, err := ts.keyRepo.FindUsable(context.TODO(), now)
+		assert.NoError(t, err)
+		require.Len(t, keys, 1)
+		assert.NotNil(t, keys[0].RetiredAt)
+
+		// After the grace period the key is no longer usable
+		keys, err = ts.keyRepo.FindUsable(context.TODO(), now.Add(2*time.Hour))
+		assert.NoError(t, err)
+		assert.Empty(t, keys)
+	})
+
+	t.Run("should not retire a key twice", func(t *testing.T) {
+		key := createTestSigningKey(time.Now())
+		require.NoError(t, ts.keyRepo.Create(context.TODO(), key))
+
+		now := time.Now()
+		require.NoError(t, ts.keyRepo.Retire(context.TODO(), key.ID, now, now.Add(time.Hour)))
+
+		err := ts.keyRepo.Retire(context.TODO(), key.ID, now, now.Add(time.Hour))
+		assert.Error(t, err)
+	})
+}
+
+func TestSigningKeyRepository_DeleteExpired(t *testing.T) {
+	ts := setupSigningKeyTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should delete only expired keys", func(t *testing.T) {
+		active := createTestSigningKey(time.Now())
+		expired := createTestSigningKey(time.Now().Add(-48 * time.Hour))
+		require.NoError(t, ts.keyRepo.Create(context.TODO(), active))
+		require.NoError(t, ts.keyRepo.Create(context.TODO(), expired))
+
+		past := time.Now().Add(-24 * time.Hour)
+		require.NoError(t, ts.keyRepo.Retire(context.TODO(), expired.ID, past, past.Add(time.Hour)))
+
+		err := ts.keyRepo.DeleteExpired(context.TODO(), time.Now())
+		assert.NoError(t, err)
+
+		count, err := ts.keyCollection.CountDocuments(context.TODO(), bson.M{})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+}
+
+func TestSigningKeyRepository_UpdatePrivateKey(t *testing.T) {
+	ts := setupSigningKeyTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should replace the key it was read as", func(t *testing.T) {
+		key := createTestSigningKey(time.Now())
+		require.NoError(t, ts.keyRepo.Create(context.TODO(), key))
+
+		err := ts.keyRepo.UpdatePrivateKey(context.TODO(), key.ID, "test-private-key", "encrypted-private-key")
+		assert.NoError(t, err)
+
+		err = ts.keyRepo.UpdatePrivateKey(context.TODO(), key.ID, "test-private-key", "other-private-key")
+		assert.NoError(t, err)
+
+		keys, err := ts.keyRepo.FindUsable(context.TODO(), time.Now())
+		assert.NoError(t, err)
+		require.Len(t, keys, 1)
+		assert.Equal(t, "encrypted-private-key", keys[0].PrivateKey)
+	})
+}
+
+func TestSigningKeyRepository_CreateReplacement(t *testing.T) {
+	ts := setupSigningKeyTestSuite(t)
+	defer ts.teardown(t)
+	require.NoError(t, migrations.EnsureSigningKeyIndexes(context.TODO(), ts.database))
+
+	t.Run("should store only one key replacing the same key", func(t *testing.T) {
+		first := createTestSigningKey(time.Now())
+		first.Replaces = "old-kid"
+		second := createTestSigningKey(time.Now())
+		second.Replaces = "old-kid"
+
+		require.NoError(t, ts.keyRepo.Create(context.TODO(), first))
+		err := ts.keyRepo.Create(context.TODO(), second)
+		assert.ErrorIs(t, err, entities.ErrSigningKeyReplaced)
+
+		count, err := ts.keyCollection.CountDocuments(context.TODO(), bson.M{"replaces": "old-kid"})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("should ignore keys stored before keys named what they replace", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			_, err := ts.keyCollection.InsertOne(context.TODO(), bson.M{"_id": uuid.NewString(), "algorithm": "EdDSA"})
+			assert.NoError(t, err)
+		}
+	})
+}
@@ -171,16 +171,6 @@ func CreateUserWithBio() *entities.User {
 	return user
 }
 
-// CreateUserWithResetToken creates a test user with reset token
-func CreateUserWithResetToken() *entities.User {
-	user := CreateTestUser()
-	token := "test-reset-token-123"
-	expiresAt := time.Now().Add(15 * time.Minute)
-	user.ResetToken = &token
-	user.ResetTokenExpiresAt = &expiresAt
-	return user
-}
-
 // Helper function to create string pointer
 func StringPtr(s string) *string {
 	return &s
@@ -8,6 +8,7 @@ import (
 	"g6_starter_project/Domain/entities"
 	"g6_starter_project/Infrastructure/mongodb/repositories"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson"
@@ -52,22 +53,22 @@ func (ts *TokenTestSuite) teardown(t *testing.T) {
 func createTestToken(userID string) *entities.Token {
 	now := time.Now().UTC().Truncate(time.Second)
 	return &entities.Token{
-		UserID:       userID,
-		AccessToken:  "test-access-token",
-		RefreshToken: "test-refresh-token",
-		ExpiresAt:    now.Add(24 * time.Hour),
-		CreatedAt:    now,
+		UserID:           userID,
+		AccessJTI:        "test-access-jti",
+		RefreshTokenHash: "test-refresh-token-hash",
+		ExpiresAt:        now.Add(24 * time.Hour),
+		CreatedAt:        now,
 	}
 }
 
-func createTestTokenWithCustomFields(userID, accessToken, refreshToken string, expiresAt time.Time) *entities.Token {
+func createTestTokenWithCustomFields(userID, accessJTI, refreshTokenHash string, expiresAt time.Time) *entities.Token {
 	now := time.Now().UTC().Truncate(time.Second)
 	return &entities.Token{
-		UserID:       userID,
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresAt:    expiresAt.Truncate(time.Second),
-		CreatedAt:    now,
+		UserID:           userID,
+		AccessJTI:        accessJTI,
+		RefreshTokenHash: refreshTokenHash,
+		ExpiresAt:        expiresAt.Truncate(time.Second),
+		CreatedAt:        now,
 	}
 }
 
@@ -87,19 +88,19 @@ func TestTokenRepository_Create(t *testing.T) {
 
 	t.Run("should create token with custom fields", func(t *testing.T) {
 		userID := "test-user-id-2"
-		accessToken := "custom-access-token"
-		refreshToken := "custom-refresh-token"
+		accessJTI := "custom-access-jti"
+		refreshTokenHash := "custom-refresh-token-hash"
 		expiresAt := time.Now().Add(48 * time.Hour)
 		
-		token := createTestTokenWithCustomFields(userID, accessToken, refreshToken, expiresAt)
+		token := createTestTokenWithCustomFields(userID, accessJTI, refreshTokenHash, expiresAt)
 
 		err := ts.tokenRepo.Create(context.TODO(), token)
 
 		assert.NoError(t, err)
 		assert.NotEmpty(t, token.ID)
 		assert.Equal(t, userID, token.UserID)
-		assert.Equal(t, accessToken, token.AccessToken)
-		assert.Equal(t, refreshToken, token.RefreshToken)
+		assert.Equal(t, accessJTI, token.AccessJTI)
+		assert.Equal(t, refreshTokenHash, token.RefreshTokenHash)
 		assert.WithinDuration(t, expiresAt, token.ExpiresAt, time.Second)
 	})
 }
@@ -115,24 +116,45 @@ func TestTokenRepository_FindByUserID(t *testing.T) {
 		err := ts.tokenRepo.Create(context.TODO(), token)
 		require.NoError(t, err)
 
-		foundToken, err := ts.tokenRepo.FindByUserID(context.TODO(), userID)
+		foundTokens, err := ts.tokenRepo.FindByUserID(context.TODO(), userID)
 
 		assert.NoError(t, err)
-		assert.NotNil(t, foundToken)
+		require.Len(t, foundTokens, 1)
+		foundToken := foundTokens[0]
 		assert.Equal(t, userID, foundToken.UserID)
-		assert.Equal(t, token.AccessToken, foundToken.AccessToken)
-		assert.Equal(t, token.RefreshToken, foundToken.RefreshToken)
+		assert.Equal(t, token.AccessJTI, foundToken.AccessJTI)
+		assert.Equal(t, token.RefreshTokenHash, foundToken.RefreshTokenHash)
 		// Compare time with tolerance for precision differences
 		assert.WithinDuration(t, token.ExpiresAt, foundToken.ExpiresAt, time.Second)
 	})
 
-	t.Run("should return error for non-existent user ID", func(t *testing.T) {
+	t.Run("should find every session of a user", func(t *testing.T) {
+		userID := "test-user-id-2"
+		older := createTestToken(userID)
+		older.ID = uuid.NewString()
+		older.LastUsedAt = time.Now().Add(-time.Hour)
+		newer := createTestTokenWithCustomFields(userID, "access-2", "refresh-2", time.Now().Add(24*time.Hour))
+		newer.ID = uuid.NewString()
+		newer.LastUsedAt = time.Now()
+
+		require.NoError(t, ts.tokenRepo.Create(context.TODO(), older))
+		require.NoError(t, ts.tokenRepo.Create(context.TODO(), newer))
+
+		foundTokens, err := ts.tokenRepo.FindByUserID(context.TODO(), userID)
+
+		assert.NoError(t, err)
+		require.Len(t, foundTokens, 2)
+		assert.Equal(t, newer.ID, foundTokens[0].ID) // most recently used first
+		assert.Equal(t, older.ID, foundTokens[1].ID)
+	})
+
+	t.Run("should return empty list for non-existent user ID", func(t *testing.T) {
 		nonExistentUserID := "non-existent-user-id"
 
-		_, err := ts.tokenRepo.FindByUserID(context.TODO(), nonExistentUserID)
+		foundTokens, err := ts.tokenRepo.FindByUserID(context.TODO(), nonExistentUserID)
 
-		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "token not found for user")
+		assert.NoError(t, err)
+		assert.Empty(t, foundTokens)
 	})
 }
 
@@ -148,13 +170,13 @@ func TestTokenRepository_Update(t *testing.T) {
 		require.NoError(t, err)
 
 		// Update token
-		newAccessToken := "updated-access-token"
-		newRefreshToken := "updated-refresh-token"
+		newAccessJTI := "updated-access-jti"
+		newRefreshTokenHash := "updated-refresh-token-hash"
 		newExpiresAt := time.Now().Add(72 * time.Hour)
 
 		update := bson.M{
-			"access_token":  newAccessToken,
-			"refresh_token": newRefreshToken,
+			"access_jti":         newAccessJTI,
+			"refresh_token_hash": newRefreshTokenHash,
 			"expires_at":    newExpiresAt,
 		}
 
@@ -163,10 +185,12 @@ func TestTokenRepository_Update(t *testing.T) {
 		assert.NoError(t, err)
 
 		// Verify update
-		updatedToken, err := ts.tokenRepo.FindByUserID(context.TODO(), userID)
+		updatedTokens, err := ts.tokenRepo.FindByUserID(context.TODO(), userID)
 		assert.NoError(t, err)
-		assert.Equal(t, newAccessToken, updatedToken.AccessToken)
-		assert.Equal(t, newRefreshToken, updatedToken.RefreshToken)
+		require.Len(t, updatedTokens, 1)
+		updatedToken := updatedTokens[0]
+		assert.Equal(t, newAccessJTI, updatedToken.AccessJTI)
+		assert.Equal(t, newRefreshTokenHash, updatedToken.RefreshTokenHash)
 		assert.WithinDuration(t, newExpiresAt, updatedToken.ExpiresAt, time.Second)
 	})
 
@@ -178,9 +202,9 @@ func TestTokenRepository_Update(t *testing.T) {
 		require.NoError(t, err)
 
 		// Update only access token
-		newAccessToken := "partial-updated-access-token"
+		newAccessJTI := "partial-updated-access-jti"
 		update := bson.M{
-			"access_token": newAccessToken,
+			"access_jti": newAccessJTI,
 		}
 
 		err = ts.tokenRepo.Update(context.TODO(), userID, update)
@@ -188,17 +212,19 @@ func TestTokenRepository_Update(t *testing.T) {
 		assert.NoError(t, err)
 
 		// Verify only access token was updated
-		updatedToken, err := ts.tokenRepo.FindByUserID(context.TODO(), userID)
+		updatedTokens, err := ts.tokenRepo.FindByUserID(context.TODO(), userID)
 		assert.NoError(t, err)
-		assert.Equal(t, newAccessToken, updatedToken.AccessToken)
-		assert.Equal(t, token.RefreshToken, updatedToken.RefreshToken) // Should remain unchanged
+		require.Len(t, updatedTokens, 1)
+		updatedToken := updatedTokens[0]
+		assert.Equal(t, newAccessJTI, updatedToken.AccessJTI)
+		assert.Equal(t, token.RefreshTokenHash, updatedToken.RefreshTokenHash) // Should remain unchanged
 		assert.WithinDuration(t, token.ExpiresAt, updatedToken.ExpiresAt, time.Second) // Should remain unchanged
 	})
 
 	t.Run("should return error for non-existent user ID", func(t *testing.T) {
 		nonExistentUserID := "non-existent-user-id"
 		update := bson.M{
-			"access_token": "new-token",
+			"access_jti": "new-token",
 		}
 
 		err := ts.tokenRepo.Update(context.TODO(), nonExistentUserID, update)
@@ -223,9 +249,9 @@ func TestTokenRepository_DeleteByUserID(t *testing.T) {
 		assert.NoError(t, err)
 
 		// Verify token is deleted
-		_, err = ts.tokenRepo.FindByUserID(context.TODO(), userID)
-		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "token not found for user")
+		remaining, err := ts.tokenRepo.FindByUserID(context.TODO(), userID)
+		assert.NoError(t, err)
+		assert.Empty(t, remaining)
 	})
 
 	t.Run("should handle deletion of non-existent user ID", func(t *testing.T) {
@@ -251,9 +277,127 @@ func TestTokenRepository_DeleteByUserID(t *testing.T) {
 		assert.NoError(t, err)
 
 		// Verify all tokens are deleted
-		_, err = ts.tokenRepo.FindByUserID(context.TODO(), userID)
+		remaining, err := ts.tokenRepo.FindByUserID(context.TODO(), userID)
+		assert.NoError(t, err)
+		assert.Empty(t, remaining)
+	})
+}
+
+func TestTokenRepository_FindByID(t *testing.T) {
+	ts := setupTokenTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should find token by session ID", func(t *testing.T) {
+		token := createTestToken("test-user-id")
+		token.ID = uuid.NewString()
+
+		err := ts.tokenRepo.Create(context.TODO(), token)
+		require.NoError(t, err)
+
+		foundToken, err := ts.tokenRepo.FindByID(context.TODO(), token.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, token.ID, foundToken.ID)
+		assert.Equal(t, token.RefreshTokenHash, foundToken.RefreshTokenHash)
+	})
+
+	t.Run("should return error for non-existent ID", func(t *testing.T) {
+		_, err := ts.tokenRepo.FindByID(context.TODO(), uuid.NewString())
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "token not found")
+	})
+}
+
+func TestTokenRepository_Rotate(t *testing.T) {
+	ts := setupTokenTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should rotate when the current refresh token matches", func(t *testing.T) {
+		token := createTestToken("test-user-id")
+		token.ID = uuid.NewString()
+		require.NoError(t, ts.tokenRepo.Create(context.TODO(), token))
+
+		rotated := createTestTokenWithCustomFields(token.UserID, "rotated-access", "rotated-refresh", time.Now().Add(48*time.Hour))
+
+		ok, err := ts.tokenRepo.Rotate(context.TODO(), token.ID, token.RefreshTokenHash, rotated)
+
+		assert.NoError(t, err)
+		assert.True(t, ok)
+
+		foundToken, err := ts.tokenRepo.FindByID(context.TODO(), token.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "rotated-access", foundToken.AccessJTI)
+		assert.Equal(t, "rotated-refresh", foundToken.RefreshTokenHash)
+	})
+
+	t.Run("should not rotate with a stale refresh token", func(t *testing.T) {
+		token := createTestToken("test-user-id-2")
+		token.ID = uuid.NewString()
+		require.NoError(t, ts.tokenRepo.Create(context.TODO(), token))
+
+		rotated := createTestTokenWithCustomFields(token.UserID, "rotated-access", "rotated-refresh", time.Now().Add(48*time.Hour))
+
+		ok, err := ts.tokenRepo.Rotate(context.TODO(), token.ID, "stale-refresh-token", rotated)
+
+		assert.NoError(t, err)
+		assert.False(t, ok)
+
+		foundToken, err := ts.tokenRepo.FindByID(context.TODO(), token.ID)
+		require.NoError(t, err)
+		assert.Equal(t, token.RefreshTokenHash, foundToken.RefreshTokenHash)
+	})
+}
+
+func TestTokenRepository_DeleteByID(t *testing.T) {
+	ts := setupTokenTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should delete only the given token family", func(t *testing.T) {
+		userID := "test-user-id"
+		token1 := createTestToken(userID)
+		token1.ID = uuid.NewString()
+		token2 := createTestToken(userID)
+		token2.ID = uuid.NewString()
+		require.NoError(t, ts.tokenRepo.Create(context.TODO(), token1))
+		require.NoError(t, ts.tokenRepo.Create(context.TODO(), token2))
+
+		err := ts.tokenRepo.DeleteByID(context.TODO(), token1.ID)
+
+		assert.NoError(t, err)
+		_, err = ts.tokenRepo.FindByID(context.TODO(), token1.ID)
 		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "token not found for user")
+		_, err = ts.tokenRepo.FindByID(context.TODO(), token2.ID)
+		assert.NoError(t, err)
+	})
+}
+
+func TestTokenRepository_DeleteByIDForUser(t *testing.T) {
+	ts := setupTokenTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should delete a session owned by the user", func(t *testing.T) {
+		token := createTestToken("test-user-id")
+		token.ID = uuid.NewString()
+		require.NoError(t, ts.tokenRepo.Create(context.TODO(), token))
+
+		deleted, err := ts.tokenRepo.DeleteByIDForUser(context.TODO(), token.ID, "test-user-id")
+
+		assert.NoError(t, err)
+		assert.True(t, deleted)
+	})
+
+	t.Run("should not delete another user's session", func(t *testing.T) {
+		token := createTestToken("test-user-id")
+		token.ID = uuid.NewString()
+		require.NoError(t, ts.tokenRepo.Create(context.TODO(), token))
+
+		deleted, err := ts.tokenRepo.DeleteByIDForUser(context.TODO(), token.ID, "other-user-id")
+
+		assert.NoError(t, err)
+		assert.False(t, deleted)
+		_, err = ts.tokenRepo.FindByID(context.TODO(), token.ID)
+		assert.NoError(t, err)
 	})
 }
 
@@ -270,30 +414,32 @@ func TestTokenRepository_Integration(t *testing.T) {
 		assert.NoError(t, err)
 
 		// Find token
-		foundToken, err := ts.tokenRepo.FindByUserID(context.TODO(), userID)
+		foundTokens, err := ts.tokenRepo.FindByUserID(context.TODO(), userID)
 		assert.NoError(t, err)
-		assert.NotNil(t, foundToken)
+		assert.Len(t, foundTokens, 1)
 
 		// Update token
-		newAccessToken := "new-access-token"
+		newAccessJTI := "new-access-jti"
 		update := bson.M{
-			"access_token": newAccessToken,
+			"access_jti": newAccessJTI,
 		}
 		err = ts.tokenRepo.Update(context.TODO(), userID, update)
 		assert.NoError(t, err)
 
 		// Verify update
-		updatedToken, err := ts.tokenRepo.FindByUserID(context.TODO(), userID)
+		updatedTokens, err := ts.tokenRepo.FindByUserID(context.TODO(), userID)
 		assert.NoError(t, err)
-		assert.Equal(t, newAccessToken, updatedToken.AccessToken)
+		require.Len(t, updatedTokens, 1)
+		updatedToken := updatedTokens[0]
+		assert.Equal(t, newAccessJTI, updatedToken.AccessJTI)
 
 		// Delete token
 		err = ts.tokenRepo.DeleteByUserID(context.TODO(), userID)
 		assert.NoError(t, err)
 
 		// Verify deletion
-		_, err = ts.tokenRepo.FindByUserID(context.TODO(), userID)
-		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "token not found for user")
+		remaining, err := ts.tokenRepo.FindByUserID(context.TODO(), userID)
+		assert.NoError(t, err)
+		assert.Empty(t, remaining)
 	})
 } 
\ No newline at end of file
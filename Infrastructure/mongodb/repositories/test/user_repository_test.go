@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"g6_starter_project/Domain/entities"
+	"g6_starter_project/Infrastructure/mongodb/migrations"
 	"g6_starter_project/Infrastructure/mongodb/repositories"
 
 	"github.com/stretchr/testify/assert"
@@ -373,128 +374,599 @@ func TestDeleteUser(t *testing.T) {
 	})
 }
 
-func TestUpdateResetToken(t *testing.T) {
+func TestUpdateVerificationStatus(t *testing.T) {
 	ts := setupTestSuite(t)
 	defer ts.teardown(t)
 
-	t.Run("should update reset token successfully", func(t *testing.T) {
+	t.Run("should update verification status successfully", func(t *testing.T) {
 		user := CreateTestUser()
 		createdUser, err := ts.repo.CreateUser(user)
 		require.NoError(t, err)
 
-		resetToken := "test-reset-token"
-		expiresAt := time.Now().Add(15 * time.Minute)
-
-		err = ts.repo.UpdateResetToken(createdUser.ID.Hex(), &resetToken, &expiresAt)
+		err = ts.repo.UpdateVerificationStatus(createdUser.ID.Hex(), true)
 
 		assert.NoError(t, err)
 
-		// Verify token was updated
-		foundUser, err := ts.repo.GetUserByResetToken(resetToken)
-		assert.NoError(t, err)
-		assert.Equal(t, createdUser.ID, foundUser.ID)
-		assert.Equal(t, resetToken, *foundUser.ResetToken)
+		// Verify status was updated
+		foundUser := AssertUserExists(t, ts.repo, createdUser.ID.Hex())
+		assert.True(t, foundUser.IsVerified)
 	})
 
 	t.Run("should return error for invalid user ID", func(t *testing.T) {
-		resetToken := "test-reset-token"
-		expiresAt := time.Now().Add(15 * time.Minute)
-
-		err := ts.repo.UpdateResetToken("invalid-id", &resetToken, &expiresAt)
+		err := ts.repo.UpdateVerificationStatus("invalid-id", true)
 
 		assert.Error(t, err)
 		assert.Equal(t, "invalid user ID", err.Error())
 	})
 }
 
-func TestGetUserByResetToken(t *testing.T) {
+func TestFindByName(t *testing.T) {
 	ts := setupTestSuite(t)
 	defer ts.teardown(t)
 
-	t.Run("should find user by reset token", func(t *testing.T) {
+	t.Run("should find user by name", func(t *testing.T) {
 		user := CreateTestUser()
 		createdUser, err := ts.repo.CreateUser(user)
 		require.NoError(t, err)
 
-		resetToken := "test-reset-token"
-		expiresAt := time.Now().Add(15 * time.Minute)
+		foundUser, err := ts.repo.FindByName(context.TODO(), "Test")
+
+		assert.NoError(t, err)
+		assert.NotNil(t, foundUser)
+		assert.Equal(t, createdUser.ID, foundUser.ID)
+	})
+
+	t.Run("should return nil for non-existent name", func(t *testing.T) {
+		foundUser, err := ts.repo.FindByName(context.TODO(), "NonExistent")
+
+		assert.NoError(t, err)
+		assert.Nil(t, foundUser)
+	})
 
-		err = ts.repo.UpdateResetToken(createdUser.ID.Hex(), &resetToken, &expiresAt)
+	t.Run("should be case insensitive", func(t *testing.T) {
+		user := CreateTestUser()
+		_, err := ts.repo.CreateUser(user)
 		require.NoError(t, err)
 
-		foundUser, err := ts.repo.GetUserByResetToken(resetToken)
+		foundUser, err := ts.repo.FindByName(context.TODO(), "test")
 
 		assert.NoError(t, err)
 		assert.NotNil(t, foundUser)
-		assert.Equal(t, createdUser.ID, foundUser.ID)
-		assert.Equal(t, resetToken, *foundUser.ResetToken)
 	})
+} 
+func TestUpdateMFA(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.teardown(t)
 
-	t.Run("should return error for invalid reset token", func(t *testing.T) {
-		_, err := ts.repo.GetUserByResetToken("invalid-token")
+	t.Run("should store and remove MFA settings", func(t *testing.T) {
+		user := CreateTestUser()
+		createdUser, err := ts.repo.CreateUser(user)
+		require.NoError(t, err)
+
+		mfa := &entities.MFASettings{
+			Enabled:       true,
+			Secret:        "encrypted-secret",
+			RecoveryCodes: []string{"hash-1", "hash-2"},
+			LastUsedStep:  100,
+		}
+		err = ts.repo.UpdateMFA(createdUser.ID.Hex(), mfa)
+		assert.NoError(t, err)
+
+		foundUser := AssertUserExists(t, ts.repo, createdUser.ID.Hex())
+		require.NotNil(t, foundUser.MFA)
+		assert.True(t, foundUser.MFAEnabled())
+		assert.Equal(t, "encrypted-secret", foundUser.MFA.Secret)
+		assert.Len(t, foundUser.MFA.RecoveryCodes, 2)
+
+		err = ts.repo.UpdateMFA(createdUser.ID.Hex(), nil)
+		assert.NoError(t, err)
+
+		foundUser = AssertUserExists(t, ts.repo, createdUser.ID.Hex())
+		assert.False(t, foundUser.MFAEnabled())
+	})
+
+	t.Run("should return error for invalid user ID", func(t *testing.T) {
+		err := ts.repo.UpdateMFA("invalid-id", nil)
 
 		assert.Error(t, err)
-		assert.Equal(t, "invalid reset token", err.Error())
+		assert.Equal(t, "invalid user ID", err.Error())
 	})
 }
 
-func TestUpdateVerificationStatus(t *testing.T) {
+func TestUseMFAStep(t *testing.T) {
 	ts := setupTestSuite(t)
 	defer ts.teardown(t)
 
-	t.Run("should update verification status successfully", func(t *testing.T) {
+	t.Run("should accept each time step only once", func(t *testing.T) {
 		user := CreateTestUser()
 		createdUser, err := ts.repo.CreateUser(user)
 		require.NoError(t, err)
+		require.NoError(t, ts.repo.UpdateMFA(createdUser.ID.Hex(), &entities.MFASettings{Enabled: true, LastUsedStep: 100}))
 
-		err = ts.repo.UpdateVerificationStatus(createdUser.ID.Hex(), true)
+		fresh, err := ts.repo.UseMFAStep(createdUser.ID.Hex(), 101)
+		assert.NoError(t, err)
+		assert.True(t, fresh)
 
+		// Replaying the same step, or an earlier one, is rejected
+		fresh, err = ts.repo.UseMFAStep(createdUser.ID.Hex(), 101)
 		assert.NoError(t, err)
+		assert.False(t, fresh)
+
+		fresh, err = ts.repo.UseMFAStep(createdUser.ID.Hex(), 100)
+		assert.NoError(t, err)
+		assert.False(t, fresh)
+	})
+}
+
+func TestUseMFARecoveryCode(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should use each recovery code only once", func(t *testing.T) {
+		user := CreateTestUser()
+		createdUser, err := ts.repo.CreateUser(user)
+		require.NoError(t, err)
+		require.NoError(t, ts.repo.UpdateMFA(createdUser.ID.Hex(), &entities.MFASettings{
+			Enabled:       true,
+			RecoveryCodes: []string{"hash-1", "hash-2"},
+		}))
+
+		used, err := ts.repo.UseMFARecoveryCode(createdUser.ID.Hex(), "hash-1")
+		assert.NoError(t, err)
+		assert.True(t, used)
+
+		used, err = ts.repo.UseMFARecoveryCode(createdUser.ID.Hex(), "hash-1")
+		assert.NoError(t, err)
+		assert.False(t, used)
 
-		// Verify status was updated
 		foundUser := AssertUserExists(t, ts.repo, createdUser.ID.Hex())
-		assert.True(t, foundUser.IsVerified)
+		assert.Equal(t, []string{"hash-2"}, foundUser.MFA.RecoveryCodes)
+	})
+
+	t.Run("should reject unknown recovery code", func(t *testing.T) {
+		user := CreateTestUserWithCustomFields("Other User", "otheruser", "other@example.com")
+		createdUser, err := ts.repo.CreateUser(user)
+		require.NoError(t, err)
+
+		used, err := ts.repo.UseMFARecoveryCode(createdUser.ID.Hex(), "unknown")
+		assert.NoError(t, err)
+		assert.False(t, used)
+	})
+}
+
+func TestUserIdentities(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should find user by linked identity", func(t *testing.T) {
+		user := CreateVerifiedUser()
+		createdUser, err := ts.repo.CreateUser(user)
+		require.NoError(t, err)
+
+		identity := entities.ExternalIdentity{
+			Provider: "google",
+			Subject:  "google-subject-1",
+			Email:    "test@example.com",
+			LinkedAt: time.Now(),
+		}
+		err = ts.repo.AddIdentity(createdUser.ID.Hex(), identity)
+		assert.NoError(t, err)
+
+		foundUser, err := ts.repo.GetUserByIdentity("google", "google-subject-1")
+		assert.NoError(t, err)
+		assert.Equal(t, createdUser.ID, foundUser.ID)
+		require.Len(t, foundUser.Identities, 1)
+		assert.Equal(t, "google", foundUser.Identities[0].Provider)
+	})
+
+	t.Run("should not match subject from another provider", func(t *testing.T) {
+		foundUser, err := ts.repo.GetUserByIdentity("github", "google-subject-1")
+
+		assert.Error(t, err)
+		assert.Nil(t, foundUser)
+		assert.Equal(t, "user not found", err.Error())
 	})
 
 	t.Run("should return error for invalid user ID", func(t *testing.T) {
-		err := ts.repo.UpdateVerificationStatus("invalid-id", true)
+		err := ts.repo.AddIdentity("invalid-id", entities.ExternalIdentity{Provider: "google", Subject: "x"})
 
 		assert.Error(t, err)
 		assert.Equal(t, "invalid user ID", err.Error())
 	})
 }
 
-func TestFindByName(t *testing.T) {
+func TestCountUsersByRole(t *testing.T) {
 	ts := setupTestSuite(t)
 	defer ts.teardown(t)
 
-	t.Run("should find user by name", func(t *testing.T) {
-		user := CreateTestUser()
+	t.Run("should count users with a role", func(t *testing.T) {
+		_, err := ts.repo.CreateUser(CreateTestUserWithCustomFields("User One", "userone", "one@example.com"))
+		require.NoError(t, err)
+		_, err = ts.repo.CreateUser(CreateAdminUser())
+		require.NoError(t, err)
+
+		count, err := ts.repo.CountUsersByRole("admin")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+
+		count, err = ts.repo.CountUsersByRole("moderator")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+	})
+}
+
+func TestPasswordHistory(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should store previous password hashes", func(t *testing.T) {
+		user := CreateVerifiedUser()
 		createdUser, err := ts.repo.CreateUser(user)
 		require.NoError(t, err)
 
-		foundUser, err := ts.repo.FindByName(context.TODO(), "Test")
+		originalHash := createdUser.Password
+		createdUser.PasswordHistory = []string{originalHash, "older-hash"}
+		createdUser.Password = "new-hash"
+		_, err = ts.repo.UpdateUser(createdUser)
+		assert.NoError(t, err)
 
+		foundUser, err := ts.repo.GetUserByID(createdUser.ID.Hex())
+		assert.NoError(t, err)
+		assert.Equal(t, "new-hash", foundUser.Password)
+		assert.Equal(t, []string{originalHash, "older-hash"}, foundUser.PasswordHistory)
+	})
+}
+
+func TestEmailChange(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.teardown(t)
+	require.NoError(t, migrations.EnsureUserIndexes(context.TODO(), ts.database))
+
+	t.Run("should store and clear a pending change", func(t *testing.T) {
+		createdUser, err := ts.repo.CreateUser(CreateTestUserWithCustomFields("Pending", "pending", "pending@example.com"))
+		require.NoError(t, err)
+
+		pending := &entities.PendingEmailChange{
+			NewEmail:    "elsewhere@example.com",
+			ExpiresAt:   time.Now().Add(time.Hour),
+			RequestedAt: time.Now(),
+		}
+		require.NoError(t, ts.repo.SetPendingEmail(createdUser.ID.Hex(), pending))
+
+		foundUser, err := ts.repo.GetUserByID(createdUser.ID.Hex())
+		assert.NoError(t, err)
+		require.NotNil(t, foundUser.PendingEmail)
+		assert.Equal(t, "elsewhere@example.com", foundUser.PendingEmail.NewEmail)
+
+		require.NoError(t, ts.repo.SetPendingEmail(createdUser.ID.Hex(), nil))
+
+		foundUser, err = ts.repo.GetUserByID(createdUser.ID.Hex())
+		assert.NoError(t, err)
+		assert.Nil(t, foundUser.PendingEmail)
+	})
+
+	t.Run("should switch email and drop the pending change", func(t *testing.T) {
+		createdUser, err := ts.repo.CreateUser(CreateTestUserWithCustomFields("Old Address", "oldaddress", "old@example.com"))
+		require.NoError(t, err)
+		require.NoError(t, ts.repo.SetPendingEmail(createdUser.ID.Hex(), &entities.PendingEmailChange{NewEmail: "new@example.com"}))
+
+		err = ts.repo.UpdateEmail(createdUser.ID.Hex(), "New@Example.com")
+		assert.NoError(t, err)
+
+		foundUser, err := ts.repo.GetUserByEmail("new@example.com")
 		assert.NoError(t, err)
-		assert.NotNil(t, foundUser)
 		assert.Equal(t, createdUser.ID, foundUser.ID)
+		assert.Nil(t, foundUser.PendingEmail)
 	})
 
-	t.Run("should return nil for non-existent name", func(t *testing.T) {
-		foundUser, err := ts.repo.FindByName(context.TODO(), "NonExistent")
+	t.Run("should reject an address taken by another account", func(t *testing.T) {
+		createdUser, err := ts.repo.CreateUser(CreateTestUserWithCustomFields("Taker", "taker", "taker@example.com"))
+		require.NoError(t, err)
+
+		// new@example.com belongs to the user from the previous subtest
+		err = ts.repo.UpdateEmail(createdUser.ID.Hex(), "new@example.com")
+		assert.Error(t, err)
+		assert.Equal(t, "email already exists", err.Error())
+	})
+}
+
+func TestUpdatePasswordHash(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should replace the hash it was read with", func(t *testing.T) {
+		user := CreateVerifiedUser()
+		createdUser, err := ts.repo.CreateUser(user)
+		require.NoError(t, err)
 
+		err = ts.repo.UpdatePasswordHash(createdUser.ID.Hex(), createdUser.Password, "rehashed")
 		assert.NoError(t, err)
-		assert.Nil(t, foundUser)
+
+		foundUser, err := ts.repo.GetUserByID(createdUser.ID.Hex())
+		assert.NoError(t, err)
+		assert.Equal(t, "rehashed", foundUser.Password)
 	})
 
-	t.Run("should be case insensitive", func(t *testing.T) {
-		user := CreateTestUser()
+	t.Run("should not overwrite a password changed meanwhile", func(t *testing.T) {
+		user := CreateTestUserWithCustomFields("Rehash User", "rehashuser", "rehash@example.com")
+		createdUser, err := ts.repo.CreateUser(user)
+		require.NoError(t, err)
+
+		err = ts.repo.UpdatePasswordHash(createdUser.ID.Hex(), "stale-hash", "rehashed")
+		assert.NoError(t, err)
+
+		foundUser, err := ts.repo.GetUserByID(createdUser.ID.Hex())
+		assert.NoError(t, err)
+		assert.Equal(t, createdUser.Password, foundUser.Password)
+	})
+}
+
+func TestUpdatePassword(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should store the hash and history without touching other fields", func(t *testing.T) {
+		user := CreateVerifiedUser()
+		createdUser, err := ts.repo.CreateUser(user)
+		require.NoError(t, err)
+
+		history := []string{createdUser.Password}
+		err = ts.repo.UpdatePassword(createdUser.ID.Hex(), "new-hash", history)
+		assert.NoError(t, err)
+
+		foundUser, err := ts.repo.GetUserByID(createdUser.ID.Hex())
+		assert.NoError(t, err)
+		assert.Equal(t, "new-hash", foundUser.Password)
+		assert.Equal(t, history, foundUser.PasswordHistory)
+		assert.Equal(t, createdUser.Email, foundUser.Email)
+		assert.Equal(t, createdUser.Role, foundUser.Role)
+	})
+
+	t.Run("should fail for an unknown user", func(t *testing.T) {
+		err := ts.repo.UpdatePassword(primitive.NewObjectID().Hex(), "new-hash", nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestSetRole(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should change the role without undoing a concurrent password change", func(t *testing.T) {
+		user := CreateVerifiedUser()
+		createdUser, err := ts.repo.CreateUser(user)
+		require.NoError(t, err)
+
+		err = ts.repo.UpdatePassword(createdUser.ID.Hex(), "new-hash", nil)
+		require.NoError(t, err)
+
+		err = ts.repo.SetRole(createdUser.ID.Hex(), entities.RoleAdmin)
+		assert.NoError(t, err)
+
+		foundUser, err := ts.repo.GetUserByID(createdUser.ID.Hex())
+		assert.NoError(t, err)
+		assert.Equal(t, entities.RoleAdmin, foundUser.Role)
+		assert.Equal(t, "new-hash", foundUser.Password)
+	})
+
+	t.Run("should fail for an unknown user", func(t *testing.T) {
+		err := ts.repo.SetRole(primitive.NewObjectID().Hex(), entities.RoleAdmin)
+		assert.Error(t, err)
+	})
+}
+
+func TestUpdateProfile(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should store the profile fields and leave the rest alone", func(t *testing.T) {
+		user := CreateVerifiedUser()
+		createdUser, err := ts.repo.CreateUser(user)
+		require.NoError(t, err)
+
+		// Another request changes the role after this copy was read
+		err = ts.repo.SetRole(createdUser.ID.Hex(), entities.RoleAdmin)
+		require.NoError(t, err)
+
+		bio := "Writes about Go"
+		createdUser.FullName = "New Name"
+		createdUser.Bio = &bio
+		createdUser.Role = entities.RoleUser
+		err = ts.repo.UpdateProfile(createdUser)
+		assert.NoError(t, err)
+
+		foundUser, err := ts.repo.GetUserByID(createdUser.ID.Hex())
+		assert.NoError(t, err)
+		assert.Equal(t, "New Name", foundUser.FullName)
+		require.NotNil(t, foundUser.Bio)
+		assert.Equal(t, bio, *foundUser.Bio)
+		assert.Equal(t, entities.RoleAdmin, foundUser.Role)
+	})
+
+}
+
+func TestScheduleDeletion(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should return users once their grace period ends", func(t *testing.T) {
+		user := CreateVerifiedUser()
+		createdUser, err := ts.repo.CreateUser(user)
+		require.NoError(t, err)
+
+		deleteAfter := time.Now().Add(time.Hour)
+		err = ts.repo.ScheduleDeletion(createdUser.ID.Hex(), &deleteAfter)
+		assert.NoError(t, err)
+
+		due, err := ts.repo.GetUsersDueForDeletion(time.Now(), 10)
+		assert.NoError(t, err)
+		assert.Empty(t, due)
+
+		due, err = ts.repo.GetUsersDueForDeletion(deleteAfter.Add(time.Minute), 10)
+		assert.NoError(t, err)
+		require.Len(t, due, 1)
+		assert.Equal(t, createdUser.ID, due[0].ID)
+	})
+
+	t.Run("should cancel a scheduled deletion", func(t *testing.T) {
+		user := CreateTestUserWithCustomFields("Leaving User", "leavinguser", "leaving@example.com")
+		createdUser, err := ts.repo.CreateUser(user)
+		require.NoError(t, err)
+
+		deleteAfter := time.Now().Add(-time.Minute)
+		require.NoError(t, ts.repo.ScheduleDeletion(createdUser.ID.Hex(), &deleteAfter))
+
+		err = ts.repo.ScheduleDeletion(createdUser.ID.Hex(), nil)
+		assert.NoError(t, err)
+
+		foundUser, err := ts.repo.GetUserByID(createdUser.ID.Hex())
+		assert.NoError(t, err)
+		assert.Nil(t, foundUser.DeleteAfter)
+	})
+}
+
+func TestSetSuspension(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.teardown(t)
+
+	t.Run("should return users while their suspension is in force", func(t *testing.T) {
+		user := CreateVerifiedUser()
+		createdUser, err := ts.repo.CreateUser(user)
+		require.NoError(t, err)
+
+		until := time.Now().Add(time.Hour)
+		err = ts.repo.SetSuspension(createdUser.ID.Hex(), &entities.Suspension{
+			Reason:      "spam",
+			Until:       &until,
+			HidePosts:   true,
+			SuspendedBy: "admin-1",
+			SuspendedAt: time.Now(),
+		})
+		assert.NoError(t, err)
+
+		suspended, err := ts.repo.GetSuspendedUsers(time.Now())
+		assert.NoError(t, err)
+		require.Len(t, suspended, 1)
+		assert.Equal(t, createdUser.ID, suspended[0].ID)
+		assert.Equal(t, "spam", suspended[0].Suspension.Reason)
+		assert.True(t, suspended[0].Suspension.HidePosts)
+
+		suspended, err = ts.repo.GetSuspendedUsers(until.Add(time.Minute))
+		assert.NoError(t, err)
+		assert.Empty(t, suspended)
+	})
+
+	t.Run("should keep a suspension without an end until it is lifted", func(t *testing.T) {
+		user := CreateTestUserWithCustomFields("Banned User", "banneduser", "banned@example.com")
+		createdUser, err := ts.repo.CreateUser(user)
+		require.NoError(t, err)
+
+		err = ts.repo.SetSuspension(createdUser.ID.Hex(), &entities.Suspension{
+			Reason:      "abuse",
+			SuspendedBy: "admin-1",
+			SuspendedAt: time.Now(),
+		})
+		require.NoError(t, err)
+
+		suspended, err := ts.repo.GetSuspendedUsers(time.Now().Add(24 * 365 * time.Hour))
+		assert.NoError(t, err)
+		found := false
+		for _, u := range suspended {
+			if u.ID == createdUser.ID {
+				found = true
+			}
+		}
+		assert.True(t, found)
+
+		err = ts.repo.SetSuspension(createdUser.ID.Hex(), nil)
+		assert.NoError(t, err)
+
+		foundUser, err := ts.repo.GetUserByID(createdUser.ID.Hex())
+		assert.NoError(t, err)
+		assert.Nil(t, foundUser.Suspension)
+	})
+}
+
+func TestFindUsers(t *testing.T) {
+	ts := setupTestSuite(t)
+	defer ts.teardown(t)
+
+	base := time.Now().Add(-time.Hour)
+	alice := CreateTestUserWithCustomFields("Alice Admin", "alice", "alice@example.com")
+	alice.Role = "admin"
+	alice.IsVerified = true
+	alice.CreatedAt = base
+	bob := CreateTestUserWithCustomFields("Bob Builder", "bob", "bob@example.org")
+	bob.CreatedAt = base.Add(10 * time.Minute)
+	carol := CreateTestUserWithCustomFields("Carol Writer", "carol", "carol@example.com")
+	carol.IsVerified = true
+	carol.CreatedAt = base.Add(20 * time.Minute)
+
+	for _, user := range []*entities.User{alice, bob, carol} {
 		_, err := ts.repo.CreateUser(user)
 		require.NoError(t, err)
+	}
+	err := ts.repo.SetSuspension(bob.ID.Hex(), &entities.Suspension{
+		Reason:      "spam",
+		SuspendedBy: "admin-1",
+		SuspendedAt: time.Now(),
+	})
+	require.NoError(t, err)
+
+	usernames := func(users []entities.User) []string {
+		names := make([]string, len(users))
+		for i, user := range users {
+			names[i] = user.Username
+		}
+		return names
+	}
 
-		foundUser, err := ts.repo.FindByName(context.TODO(), "test")
+	t.Run("should search name, username and email", func(t *testing.T) {
+		users, total, err := ts.repo.FindUsers(entities.UserFilter{Search: "example.org", Page: 1, Limit: 10}, time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, []string{"bob"}, usernames(users))
 
+		users, _, err = ts.repo.FindUsers(entities.UserFilter{Search: "WRITER", Page: 1, Limit: 10}, time.Now())
 		assert.NoError(t, err)
-		assert.NotNil(t, foundUser)
+		assert.Equal(t, []string{"carol"}, usernames(users))
 	})
-} 
\ No newline at end of file
+
+	t.Run("should treat the search as plain text", func(t *testing.T) {
+		_, total, err := ts.repo.FindUsers(entities.UserFilter{Search: ".*", Page: 1, Limit: 10}, time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), total)
+	})
+
+	t.Run("should filter by role, verification and suspension", func(t *testing.T) {
+		verified, suspended, notSuspended := true, true, false
+
+		users, _, err := ts.repo.FindUsers(entities.UserFilter{Role: "admin", Page: 1, Limit: 10}, time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"alice"}, usernames(users))
+
+		users, _, err = ts.repo.FindUsers(entities.UserFilter{Verified: &verified, SortBy: entities.UserSortUsername, Order: "asc", Page: 1, Limit: 10}, time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"alice", "carol"}, usernames(users))
+
+		users, _, err = ts.repo.FindUsers(entities.UserFilter{Suspended: &suspended, Page: 1, Limit: 10}, time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"bob"}, usernames(users))
+
+		users, _, err = ts.repo.FindUsers(entities.UserFilter{Suspended: &notSuspended, SortBy: entities.UserSortUsername, Order: "asc", Page: 1, Limit: 10}, time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"alice", "carol"}, usernames(users))
+	})
+
+	t.Run("should filter by creation date and paginate", func(t *testing.T) {
+		from := base.Add(5 * time.Minute)
+		users, total, err := ts.repo.FindUsers(entities.UserFilter{CreatedFrom: &from, SortBy: entities.UserSortCreatedAt, Order: "desc", Page: 1, Limit: 1}, time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+		assert.Equal(t, []string{"carol"}, usernames(users))
+
+		users, _, err = ts.repo.FindUsers(entities.UserFilter{CreatedFrom: &from, SortBy: entities.UserSortCreatedAt, Order: "desc", Page: 2, Limit: 1}, time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"bob"}, usernames(users))
+	})
+}
@@ -9,6 +9,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type TokenRepository struct {
@@ -35,14 +36,34 @@ func (r *TokenRepository) Create(ctx context.Context, token *entities.Token) err
 	return nil
 }
 
-// FindByUserID retrieves a token by user ID
-func (r *TokenRepository) FindByUserID(ctx context.Context, userID string) (*entities.Token, error) {
+// FindByUserID retrieves every token family (session) of a user, most recently used first
+func (r *TokenRepository) FindByUserID(ctx context.Context, userID string) ([]entities.Token, error) {
+	opts := options.Find().SetSort(bson.M{"last_used_at": -1})
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tokens: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	var tokens []entities.Token
+	if err := cursor.All(ctx, &tokens); err != nil {
+		return nil, fmt.Errorf("failed to decode tokens: %v", err)
+	}
+
+	// Return empty slice instead of nil if no tokens found
+	if tokens == nil {
+		tokens = []entities.Token{}
+	}
+	return tokens, nil
+}
+
+// FindByID retrieves a token family by its ID (the "sid" claim of its JWTs)
+func (r *TokenRepository) FindByID(ctx context.Context, id string) (*entities.Token, error) {
 	var token entities.Token
-	err := r.collection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&token)
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&token)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			// No token found for the given use
-			return nil, fmt.Errorf("token not found for user %s", userID)
+			return nil, fmt.Errorf("token not found")
 		}
 
 		return nil, fmt.Errorf("failed to find token: %v", err)
@@ -50,6 +71,43 @@ func (r *TokenRepository) FindByUserID(ctx context.Context, userID string) (*ent
 	return &token, nil
 }
 
+// Rotate swaps the stored token hash only if currentRefreshTokenHash is still the
+// latest one, so two concurrent refreshes cannot both succeed. It reports
+// whether the swap happened.
+func (r *TokenRepository) Rotate(ctx context.Context, id, currentRefreshTokenHash string, rotated *entities.Token) (bool, error) {
+	result, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "refresh_token_hash": currentRefreshTokenHash},
+		bson.M{"$set": bson.M{
+			"refresh_token_hash": rotated.RefreshTokenHash,
+			"access_jti":         rotated.AccessJTI,
+			"access_expires_at":  rotated.AccessExpiresAt,
+			"expires_at":         rotated.ExpiresAt,
+			"last_used_at":       rotated.LastUsedAt,
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+// DeleteByID removes a whole token family
+func (r *TokenRepository) DeleteByID(ctx context.Context, id string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// DeleteByIDForUser removes a token family only if it belongs to the given user.
+// It reports whether a token was deleted.
+func (r *TokenRepository) DeleteByIDForUser(ctx context.Context, id, userID string) (bool, error) {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount == 1, nil
+}
+
 // Update modifies token fields for a specific user
 func (r *TokenRepository) Update(ctx context.Context, userID string, update bson.M) error {
 	_, err := r.collection.UpdateOne(
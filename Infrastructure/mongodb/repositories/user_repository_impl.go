@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 	"errors"
+	"regexp"
 	"strings"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type UserRepositoryImpl struct {
@@ -80,6 +82,14 @@ func (r *UserRepositoryImpl) GetUserCount() (int64, error) {
 	return count, nil
 }
 
+func (r *UserRepositoryImpl) CountUsersByRole(role string) (int64, error) {
+	count, err := r.db.CountDocuments(context.TODO(), bson.M{"role": role})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
 func (r *UserRepositoryImpl) GetUserByID(id string) (*entities.User, error) {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -128,7 +138,7 @@ func (r *UserRepositoryImpl) DeleteUser(id string) error {
 	return err
 }
 
-func (r *UserRepositoryImpl) UpdateResetToken(userID string, resetToken *string, expiresAt *time.Time) error {
+func (r *UserRepositoryImpl) UpdateVerificationStatus(userID string, isVerified bool) error {
 	objectID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return errors.New("invalid user ID")
@@ -137,9 +147,8 @@ func (r *UserRepositoryImpl) UpdateResetToken(userID string, resetToken *string,
 	filter := bson.M{"_id": objectID}
 	update := bson.M{
 		"$set": bson.M{
-			"reset_token":            resetToken,
-			"reset_token_expires_at": expiresAt,
-			"updated_at":             time.Now(),
+			"is_verified": isVerified,
+			"updated_at":  time.Now(),
 		},
 	}
 
@@ -147,21 +156,22 @@ func (r *UserRepositoryImpl) UpdateResetToken(userID string, resetToken *string,
 	return err
 }
 
-func (r *UserRepositoryImpl) GetUserByResetToken(resetToken string) (*entities.User, error) {
-	filter := bson.M{"reset_token": resetToken}
+func (r *UserRepositoryImpl) FindByName(ctx context.Context, name string) (*entities.User, error) {
 	var user entities.User
-	err := r.db.FindOne(context.TODO(), filter).Decode(&user)
+	filter := bson.M{"full_name": bson.M{"$regex": name, "$options": "i"}}
 
+	err := r.db.FindOne(ctx, filter).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, errors.New("invalid reset token")
+			return nil, nil
 		}
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *UserRepositoryImpl) UpdateVerificationStatus(userID string, isVerified bool) error {
+// UpdateMFA replaces the user's second-factor settings; nil removes them
+func (r *UserRepositoryImpl) UpdateMFA(userID string, mfa *entities.MFASettings) error {
 	objectID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
 		return errors.New("invalid user ID")
@@ -170,25 +180,410 @@ func (r *UserRepositoryImpl) UpdateVerificationStatus(userID string, isVerified
 	filter := bson.M{"_id": objectID}
 	update := bson.M{
 		"$set": bson.M{
-			"is_verified": isVerified,
-			"updated_at":  time.Now(),
+			"mfa":        mfa,
+			"updated_at": time.Now(),
 		},
 	}
 
-	_, err = r.db.UpdateOne(context.TODO(), filter, update)
-	return err
+	result, err := r.db.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("user not found")
+	}
+	return nil
 }
 
-func (r *UserRepositoryImpl) FindByName(ctx context.Context, name string) (*entities.User, error) {
+// UseMFAStep records an accepted TOTP time step. It reports false if that step
+// (or a later one) was already used, so a code cannot be replayed.
+func (r *UserRepositoryImpl) UseMFAStep(userID string, step int64) (bool, error) {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return false, errors.New("invalid user ID")
+	}
+
+	filter := bson.M{"_id": objectID, "mfa.last_used_step": bson.M{"$lt": step}}
+	update := bson.M{"$set": bson.M{"mfa.last_used_step": step}}
+
+	result, err := r.db.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount == 1, nil
+}
+
+// UseMFARecoveryCode removes a recovery code hash. It reports false if the code
+// does not exist or was already used.
+func (r *UserRepositoryImpl) UseMFARecoveryCode(userID string, codeHash string) (bool, error) {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return false, errors.New("invalid user ID")
+	}
+
+	filter := bson.M{"_id": objectID, "mfa.recovery_codes": codeHash}
+	update := bson.M{"$pull": bson.M{"mfa.recovery_codes": codeHash}}
+
+	result, err := r.db.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// GetUserByIdentity finds the user linked to an identity provider account
+func (r *UserRepositoryImpl) GetUserByIdentity(provider, subject string) (*entities.User, error) {
+	filter := bson.M{"identities": bson.M{"$elemMatch": bson.M{"provider": provider, "subject": subject}}}
 	var user entities.User
-	filter := bson.M{"full_name": bson.M{"$regex": name, "$options": "i"}}
+	err := r.db.FindOne(context.TODO(), filter).Decode(&user)
 
-	err := r.db.FindOne(ctx, filter).Decode(&user)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, nil
+			return nil, errors.New("user not found")
 		}
 		return nil, err
 	}
 	return &user, nil
 }
+
+// AddIdentity links an identity provider account to a user
+func (r *UserRepositoryImpl) AddIdentity(userID string, identity entities.ExternalIdentity) error {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+
+	filter := bson.M{"_id": objectID}
+	update := bson.M{
+		"$push": bson.M{"identities": identity},
+		"$set":  bson.M{"updated_at": time.Now()},
+	}
+
+	result, err := r.db.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+// SetPendingEmail stores a requested email change; nil removes it
+func (r *UserRepositoryImpl) SetPendingEmail(userID string, pending *entities.PendingEmailChange) error {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+
+	filter := bson.M{"_id": objectID}
+	update := bson.M{
+		"$set": bson.M{
+			"pending_email": pending,
+			"updated_at":    time.Now(),
+		},
+	}
+
+	result, err := r.db.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+// UpdateEmail moves the user to a new email address and drops the pending change.
+// The unique index on email rejects the update if another account has the address.
+func (r *UserRepositoryImpl) UpdateEmail(userID, email string) error {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+
+	filter := bson.M{"_id": objectID}
+	update := bson.M{
+		"$set": bson.M{
+			"email":      strings.ToLower(email),
+			"updated_at": time.Now(),
+		},
+		"$unset": bson.M{"pending_email": ""},
+	}
+
+	result, err := r.db.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return errors.New("email already exists")
+		}
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+// UpdatePasswordHash replaces a password hash with a rehash of the same password.
+// Nothing changes if the password was changed since oldHash was read.
+func (r *UserRepositoryImpl) UpdatePasswordHash(userID, oldHash, newHash string) error {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+
+	filter := bson.M{"_id": objectID, "password": oldHash}
+	update := bson.M{"$set": bson.M{"password": newHash}}
+
+	_, err = r.db.UpdateOne(context.TODO(), filter, update)
+	return err
+}
+
+// UpdatePassword stores a new password hash and the hashes of the passwords it replaces
+func (r *UserRepositoryImpl) UpdatePassword(userID, hash string, history []string) error {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+
+	filter := bson.M{"_id": objectID}
+	update := bson.M{
+		"$set": bson.M{
+			"password":         hash,
+			"password_history": history,
+			"updated_at":       time.Now(),
+		},
+	}
+
+	result, err := r.db.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+// SetRole gives a user a role without touching any other field
+func (r *UserRepositoryImpl) SetRole(userID, role string) error {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+
+	filter := bson.M{"_id": objectID}
+	update := bson.M{"$set": bson.M{"role": role, "updated_at": time.Now()}}
+
+	result, err := r.db.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+// UpdateProfile stores the user's username, full name, profile image, bio and
+// contact info. Other fields, such as the password or MFA settings, may have
+// been changed since the user was read and are left alone.
+func (r *UserRepositoryImpl) UpdateProfile(user *entities.User) error {
+	set := bson.M{
+		"username":   user.Username,
+		"full_name":  user.FullName,
+		"updated_at": time.Now(),
+	}
+	if user.ProfileImage != nil {
+		set["profile_image"] = user.ProfileImage
+	}
+	if user.Bio != nil {
+		set["bio"] = user.Bio
+	}
+	if user.ContactInfo != nil {
+		set["contact_info"] = user.ContactInfo
+	}
+
+	result, err := r.db.UpdateOne(context.TODO(), bson.M{"_id": user.ID}, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+// ScheduleDeletion sets when the user is purged, or cancels a requested deletion when deleteAfter is nil
+func (r *UserRepositoryImpl) ScheduleDeletion(userID string, deleteAfter *time.Time) error {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+
+	filter := bson.M{"_id": objectID}
+	update := bson.M{"$set": bson.M{"delete_after": deleteAfter, "updated_at": time.Now()}}
+	if deleteAfter == nil {
+		update = bson.M{
+			"$set":   bson.M{"updated_at": time.Now()},
+			"$unset": bson.M{"delete_after": ""},
+		}
+	}
+
+	result, err := r.db.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+// GetUsersDueForDeletion returns users whose deletion grace period ended before now
+func (r *UserRepositoryImpl) GetUsersDueForDeletion(now time.Time, limit int64) ([]entities.User, error) {
+	filter := bson.M{"delete_after": bson.M{"$lte": now}}
+	opts := options.Find().SetSort(bson.M{"delete_after": 1}).SetLimit(limit)
+
+	cursor, err := r.db.Find(context.TODO(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	users := []entities.User{}
+	if err := cursor.All(context.TODO(), &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// SetSuspension suspends the user, or lifts the suspension when suspension is nil
+func (r *UserRepositoryImpl) SetSuspension(userID string, suspension *entities.Suspension) error {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return errors.New("invalid user ID")
+	}
+
+	filter := bson.M{"_id": objectID}
+	update := bson.M{"$set": bson.M{"suspension": suspension, "updated_at": time.Now()}}
+	if suspension == nil {
+		update = bson.M{
+			"$set":   bson.M{"updated_at": time.Now()},
+			"$unset": bson.M{"suspension": ""},
+		}
+	}
+
+	result, err := r.db.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("user not found")
+	}
+	return nil
+}
+
+// GetSuspendedUsers returns the users whose suspension is still in force at now
+func (r *UserRepositoryImpl) GetSuspendedUsers(now time.Time) ([]entities.User, error) {
+	filter := bson.M{
+		"suspension.suspended_at": bson.M{"$exists": true},
+		"$or": bson.A{
+			bson.M{"suspension.until": bson.M{"$exists": false}},
+			bson.M{"suspension.until": bson.M{"$gt": now}},
+		},
+	}
+
+	cursor, err := r.db.Find(context.TODO(), filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.TODO())
+
+	users := []entities.User{}
+	if err := cursor.All(context.TODO(), &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// FindUsers returns a page of the users matching the filter, along with the
+// number of matches. A suspension counts while it is in force at now.
+func (r *UserRepositoryImpl) FindUsers(filter entities.UserFilter, now time.Time) ([]entities.User, int64, error) {
+	conditions := bson.A{}
+	if filter.Search != "" {
+		pattern := bson.M{"$regex": regexp.QuoteMeta(filter.Search), "$options": "i"}
+		conditions = append(conditions, bson.M{"$or": bson.A{
+			bson.M{"full_name": pattern},
+			bson.M{"username": pattern},
+			bson.M{"email": pattern},
+		}})
+	}
+	if filter.Role != "" {
+		conditions = append(conditions, bson.M{"role": filter.Role})
+	}
+	if filter.Verified != nil {
+		conditions = append(conditions, bson.M{"is_verified": *filter.Verified})
+	}
+	if filter.Suspended != nil {
+		suspended := bson.M{
+			"suspension.suspended_at": bson.M{"$exists": true},
+			"$or": bson.A{
+				bson.M{"suspension.until": bson.M{"$exists": false}},
+				bson.M{"suspension.until": bson.M{"$gt": now}},
+			},
+		}
+		if *filter.Suspended {
+			conditions = append(conditions, suspended)
+		} else {
+			conditions = append(conditions, bson.M{"$nor": bson.A{suspended}})
+		}
+	}
+	if filter.CreatedFrom != nil || filter.CreatedTo != nil {
+		createdAt := bson.M{}
+		if filter.CreatedFrom != nil {
+			createdAt["$gte"] = *filter.CreatedFrom
+		}
+		if filter.CreatedTo != nil {
+			createdAt["$lte"] = *filter.CreatedTo
+		}
+		conditions = append(conditions, bson.M{"created_at": createdAt})
+	}
+
+	query := bson.M{}
+	if len(conditions) > 0 {
+		query["$and"] = conditions
+	}
+
+	total, err := r.db.CountDocuments(context.TODO(), query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sortBy := filter.SortBy
+	if sortBy == "" {
+		sortBy = entities.UserSortCreatedAt
+	}
+	direction := -1
+	if filter.Order == "asc" {
+		direction = 1
+	}
+
+	// Sorting by _id as well keeps pages stable when sort values repeat
+	opts := options.Find().
+		SetSort(bson.D{{Key: sortBy, Value: direction}, {Key: "_id", Value: direction}}).
+		SetSkip((filter.Page - 1) * filter.Limit).
+		SetLimit(filter.Limit)
+
+	cursor, err := r.db.Find(context.TODO(), query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(context.TODO())
+
+	users := []entities.User{}
+	if err := cursor.All(context.TODO(), &users); err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
@@ -6,8 +6,13 @@ import (
 
 var RedisClient *redis.Client
 
-func InitRedis() {
+func InitRedis(addr, password string) {
+	if addr == "" {
+		addr = "localhost:6379" // default Redis address
+	}
+
 	RedisClient = redis.NewClient(&redis.Options{
-		Addr: "localhost:6379", // default Redis address
+		Addr:     addr,
+		Password: password,
 	})
 }
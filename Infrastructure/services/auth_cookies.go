@@ -0,0 +1,219 @@
+package services
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	AccessTokenCookie  = "access_token"
+	RefreshTokenCookie = "refresh_token"
+	CSRFCookie         = "csrf_token"
+	CSRFHeader         = "X-CSRF-Token"
+
+	// Clients ask for cookies with "X-Auth-Mode: cookie"; others get the tokens in the body
+	AuthModeHeader = "X-Auth-Mode"
+	AuthModeCookie = "cookie"
+
+	// authModeCookie remembers a cookie-mode sign-in across identity provider
+	// and email redirects, which cannot carry the header
+	authModeCookie = "auth_mode"
+
+	// The refresh token is only ever sent to the refresh endpoint
+	refreshTokenCookiePath = "/auth/refresh"
+)
+
+// AuthCookieConfig configures the cookies browser clients are signed in with
+type AuthCookieConfig struct {
+	Enabled  bool
+	Domain   string
+	Secure   bool
+	SameSite http.SameSite
+}
+
+// AuthCookies keeps access and refresh tokens in HttpOnly cookies for browser
+// clients, so scripts never see them. Requests authenticated by cookie must
+// repeat the readable CSRF cookie in the X-CSRF-Token header (double submit).
+type AuthCookies struct {
+	config AuthCookieConfig
+}
+
+// NewAuthCookies validates the cookie configuration
+func NewAuthCookies(config AuthCookieConfig) (*AuthCookies, error) {
+	if config.SameSite == 0 {
+		config.SameSite = http.SameSiteLaxMode
+	}
+	if config.SameSite == http.SameSiteNoneMode && !config.Secure {
+		return nil, errors.New("SameSite=None cookies must be secure")
+	}
+
+	return &AuthCookies{config: config}, nil
+}
+
+// ParseSameSite converts "strict", "lax" or "none" to a SameSite mode
+func ParseSameSite(value string) (http.SameSite, error) {
+	switch strings.ToLower(value) {
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "lax", "":
+		return http.SameSiteLaxMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	default:
+		return 0, errors.New("SameSite must be strict, lax or none")
+	}
+}
+
+// Enabled reports whether browser clients are signed in with cookies
+func (a *AuthCookies) Enabled() bool {
+	return a != nil && a.config.Enabled
+}
+
+// Requested reports whether the client asked to be signed in with cookies, with
+// the X-Auth-Mode header, an auth_mode query parameter on a redirect it begins,
+// or a mode remembered by RememberMode. Always false when cookie mode is off.
+func (a *AuthCookies) Requested(c *gin.Context) bool {
+	if !a.Enabled() {
+		return false
+	}
+	return strings.EqualFold(c.GetHeader(AuthModeHeader), AuthModeCookie) ||
+		strings.EqualFold(c.Query("auth_mode"), AuthModeCookie) ||
+		a.cookie(c, authModeCookie) == AuthModeCookie
+}
+
+// RememberMode keeps a cookie-mode request for the callback at path, which the
+// browser reaches by a top-level redirect
+func (a *AuthCookies) RememberMode(c *gin.Context, path string, maxAge int) {
+	if !a.Requested(c) {
+		return
+	}
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     authModeCookie,
+		Value:    AuthModeCookie,
+		Path:     path,
+		MaxAge:   maxAge,
+		Secure:   a.config.Secure,
+		HttpOnly: true,
+		// Lax, so the cookie comes back on the redirect
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// ForgetMode removes the mode remembered for path
+func (a *AuthCookies) ForgetMode(c *gin.Context, path string) {
+	if a.cookie(c, authModeCookie) == "" {
+		return
+	}
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     authModeCookie,
+		Path:     path,
+		MaxAge:   -1,
+		Secure:   a.config.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// SetTokens stores the token pair in cookies and returns the new CSRF token,
+// which is also readable by the page from the CSRF cookie
+func (a *AuthCookies) SetTokens(c *gin.Context, token *entities.Token) (string, error) {
+	csrfToken, err := RandomURLToken()
+	if err != nil {
+		return "", err
+	}
+
+	a.set(c, AccessTokenCookie, token.AccessToken, "/", secondsUntil(token.AccessExpiresAt), true)
+	a.set(c, RefreshTokenCookie, token.RefreshToken, refreshTokenCookiePath, secondsUntil(token.ExpiresAt), true)
+	a.set(c, CSRFCookie, csrfToken, "/", secondsUntil(token.ExpiresAt), false)
+	return csrfToken, nil
+}
+
+// Clear removes the token and CSRF cookies
+func (a *AuthCookies) Clear(c *gin.Context) {
+	a.set(c, AccessTokenCookie, "", "/", -1, true)
+	a.set(c, RefreshTokenCookie, "", refreshTokenCookiePath, -1, true)
+	a.set(c, CSRFCookie, "", "/", -1, false)
+}
+
+// AccessToken returns the access token cookie, or an empty string
+func (a *AuthCookies) AccessToken(c *gin.Context) string {
+	return a.cookie(c, AccessTokenCookie)
+}
+
+// RefreshToken returns the refresh token cookie, or an empty string
+func (a *AuthCookies) RefreshToken(c *gin.Context) string {
+	return a.cookie(c, RefreshTokenCookie)
+}
+
+func (a *AuthCookies) cookie(c *gin.Context, name string) string {
+	if !a.Enabled() {
+		return ""
+	}
+	value, err := c.Cookie(name)
+	if err != nil {
+		return ""
+	}
+	return value
+}
+
+func (a *AuthCookies) set(c *gin.Context, name, value, path string, maxAge int, httpOnly bool) {
+	if maxAge == 0 {
+		// Zero would make a session cookie out of a token that is about to expire
+		maxAge = -1
+	}
+
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   a.config.Domain,
+		MaxAge:   maxAge,
+		Secure:   a.config.Secure,
+		HttpOnly: httpOnly,
+		SameSite: a.config.SameSite,
+	})
+}
+
+func secondsUntil(t time.Time) int {
+	return int(time.Until(t).Seconds())
+}
+
+// GinCSRFMiddleware rejects state-changing requests that are authenticated by
+// cookie unless the X-CSRF-Token header matches the CSRF cookie. Requests with
+// an Authorization header are not checked: other sites cannot set headers.
+func GinCSRFMiddleware(cookies *AuthCookies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !cookies.Enabled() || c.GetHeader("Authorization") != "" {
+			c.Next()
+			return
+		}
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		if cookies.AccessToken(c) == "" && cookies.RefreshToken(c) == "" {
+			c.Next()
+			return
+		}
+
+		expected := cookies.cookie(c, CSRFCookie)
+		provided := c.GetHeader(CSRFHeader)
+		if expected == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(provided)) != 1 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "invalid CSRF token"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func newTestAuthCookies(t *testing.T, enabled bool) *AuthCookies {
+	cookies, err := NewAuthCookies(AuthCookieConfig{Enabled: enabled, Secure: true})
+	require.NoError(t, err)
+	return cookies
+}
+
+// newCSRFTestRouter answers every method on /posts with 200 behind the CSRF middleware
+func newCSRFTestRouter(cookies *AuthCookies) *gin.Engine {
+	router := gin.New()
+	router.Use(GinCSRFMiddleware(cookies))
+	router.Any("/posts", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func TestGinCSRFMiddleware(t *testing.T) {
+	router := newCSRFTestRouter(newTestAuthCookies(t, true))
+
+	tests := []struct {
+		name          string
+		method        string
+		accessCookie  string
+		refreshCookie string
+		csrfCookie    string
+		csrfHeader    string
+		authorization string
+		want          int
+	}{
+		{"should let safe methods through", http.MethodGet, "access", "", "csrf", "", "", http.StatusOK},
+		{"should accept a matching header", http.MethodPost, "access", "", "csrf", "csrf", "", http.StatusOK},
+		{"should reject a missing header", http.MethodPost, "access", "", "csrf", "", "", http.StatusForbidden},
+		{"should reject a different header", http.MethodDelete, "access", "", "csrf", "other", "", http.StatusForbidden},
+		{"should reject a header without the CSRF cookie", http.MethodPut, "access", "", "", "csrf", "", http.StatusForbidden},
+		{"should check requests carrying only the refresh cookie", http.MethodPost, "", "refresh", "csrf", "", "", http.StatusForbidden},
+		{"should skip requests without token cookies", http.MethodPost, "", "", "", "", "", http.StatusOK},
+		{"should skip bearer-token requests", http.MethodPatch, "access", "", "csrf", "", "Bearer token", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/posts", nil)
+			if tt.accessCookie != "" {
+				req.AddCookie(&http.Cookie{Name: AccessTokenCookie, Value: tt.accessCookie})
+			}
+			if tt.refreshCookie != "" {
+				req.AddCookie(&http.Cookie{Name: RefreshTokenCookie, Value: tt.refreshCookie})
+			}
+			if tt.csrfCookie != "" {
+				req.AddCookie(&http.Cookie{Name: CSRFCookie, Value: tt.csrfCookie})
+			}
+			if tt.csrfHeader != "" {
+				req.Header.Set(CSRFHeader, tt.csrfHeader)
+			}
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+			assert.Equal(t, tt.want, recorder.Code)
+		})
+	}
+}
+
+func TestGinCSRFMiddleware_Disabled(t *testing.T) {
+	router := newCSRFTestRouter(newTestAuthCookies(t, false))
+
+	t.Run("should not check anything without cookie mode", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/posts", nil)
+		req.AddCookie(&http.Cookie{Name: AccessTokenCookie, Value: "access"})
+
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	})
+}
+
+func TestAuthCookies_SetTokens(t *testing.T) {
+	cookies := newTestAuthCookies(t, true)
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	token := &entities.Token{
+		AccessToken:     "access",
+		RefreshToken:    "refresh",
+		AccessExpiresAt: time.Now().Add(15 * time.Minute),
+		ExpiresAt:       time.Now().Add(7 * 24 * time.Hour),
+	}
+
+	csrfToken, err := cookies.SetTokens(c, token)
+	require.NoError(t, err)
+	assert.NotEmpty(t, csrfToken)
+
+	set := map[string]*http.Cookie{}
+	for _, cookie := range recorder.Result().Cookies() {
+		set[cookie.Name] = cookie
+	}
+
+	tests := []struct {
+		name     string
+		value    string
+		path     string
+		httpOnly bool
+	}{
+		{AccessTokenCookie, "access", "/", true},
+		{RefreshTokenCookie, "refresh", refreshTokenCookiePath, true},
+		{CSRFCookie, csrfToken, "/", false},
+	}
+
+	for _, tt := range tests {
+		t.Run("should set "+tt.name, func(t *testing.T) {
+			cookie, ok := set[tt.name]
+			require.True(t, ok)
+			assert.Equal(t, tt.value, cookie.Value)
+			assert.Equal(t, tt.path, cookie.Path)
+			assert.Equal(t, tt.httpOnly, cookie.HttpOnly)
+			assert.True(t, cookie.Secure)
+			assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+		})
+	}
+}
+
+func TestNewAuthCookies(t *testing.T) {
+	t.Run("should reject SameSite=None without Secure", func(t *testing.T) {
+		_, err := NewAuthCookies(AuthCookieConfig{Enabled: true, SameSite: http.SameSiteNoneMode})
+		assert.Error(t, err)
+	})
+
+	tests := []struct {
+		value string
+		want  http.SameSite
+		valid bool
+	}{
+		{"strict", http.SameSiteStrictMode, true},
+		{"Lax", http.SameSiteLaxMode, true},
+		{"", http.SameSiteLaxMode, true},
+		{"none", http.SameSiteNoneMode, true},
+		{"sometimes", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run("should parse SameSite "+tt.value, func(t *testing.T) {
+			sameSite, err := ParseSameSite(tt.value)
+			assert.Equal(t, tt.valid, err == nil)
+			assert.Equal(t, tt.want, sameSite)
+		})
+	}
+}
+
+func TestAuthCookies_Requested(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		target  string
+		header  string
+		cookie  string
+		want    bool
+	}{
+		{"should use the body for clients that do not ask", true, "/login", "", "", false},
+		{"should use cookies when the header asks", true, "/login", "cookie", "", true},
+		{"should read the header case-insensitively", true, "/login", "Cookie", "", true},
+		{"should use cookies when the query asks", true, "/auth/oidc/google/login?auth_mode=cookie", "", "", true},
+		{"should use cookies a redirect remembered", true, "/auth/oidc/google/callback", "", "cookie", true},
+		{"should ignore other modes", true, "/login", "bearer", "", false},
+		{"should never use cookies without cookie mode", false, "/login", "cookie", "cookie", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, tt.target, nil)
+			if tt.header != "" {
+				c.Request.Header.Set(AuthModeHeader, tt.header)
+			}
+			if tt.cookie != "" {
+				c.Request.AddCookie(&http.Cookie{Name: authModeCookie, Value: tt.cookie})
+			}
+
+			assert.Equal(t, tt.want, newTestAuthCookies(t, tt.enabled).Requested(c))
+		})
+	}
+}
+
+func TestAuthCookies_RememberMode(t *testing.T) {
+	cookies := newTestAuthCookies(t, true)
+
+	t.Run("should remember a cookie-mode request for the callback", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Request = httptest.NewRequest(http.MethodGet, "/auth/oidc/google/login?auth_mode=cookie", nil)
+
+		cookies.RememberMode(c, "/auth/oidc", 600)
+
+		set := recorder.Result().Cookies()
+		require.Len(t, set, 1)
+		assert.Equal(t, authModeCookie, set[0].Name)
+		assert.Equal(t, "/auth/oidc", set[0].Path)
+		assert.Equal(t, http.SameSiteLaxMode, set[0].SameSite)
+		assert.True(t, set[0].HttpOnly)
+	})
+
+	t.Run("should remember nothing for other clients", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(recorder)
+		c.Request = httptest.NewRequest(http.MethodGet, "/auth/oidc/google/login", nil)
+
+		cookies.RememberMode(c, "/auth/oidc", 600)
+		assert.Empty(t, recorder.Result().Cookies())
+	})
+}
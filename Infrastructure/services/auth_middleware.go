@@ -1,8 +1,13 @@
 package services
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"strings"
+
+	"g6_starter_project/Domain/entities"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -15,87 +20,278 @@ const (
 	RoleKey   contextKey = "userRole"
 )
 
-// AuthMiddleware verifies JWT access tokens on incoming Gin HTTP requests
-func AuthMiddleware(authSvc JWTServiceInterface) gin.HandlerFunc {
+// PersonalAccessTokenPrefix starts every personal access token, which tells them apart from JWTs
+const PersonalAccessTokenPrefix = "bpat_"
+
+// PersonalAccessTokenValidator resolves a personal access token to its owner and scopes
+type PersonalAccessTokenValidator interface {
+	ValidatePersonalAccessToken(ctx context.Context, token string) (userID string, role string, scopes []string, err error)
+}
+
+// SuspensionChecker answers whether a user is suspended
+type SuspensionChecker interface {
+	IsSuspended(ctx context.Context, userID string) (bool, error)
+}
+
+// SecurityEventRecorder appends entries to the security event log
+type SecurityEventRecorder interface {
+	Record(event entities.SecurityEvent, client entities.ClientInfo)
+}
+
+// errAccountSuspended is returned with 403 instead of 401: the token is valid
+const errAccountSuspended = "Account is suspended"
+
+// AuthMiddleware verifies JWT access tokens on incoming Gin HTTP requests.
+// It is kept for existing routes and behaves exactly like GinAuthMiddleware.
+func AuthMiddleware(authSvc JWTServiceInterface, revocationStore TokenRevocationStore, patValidator PersonalAccessTokenValidator, suspensions SuspensionChecker, cookies *AuthCookies) gin.HandlerFunc {
+	return GinAuthMiddleware(authSvc, revocationStore, patValidator, suspensions, cookies)
+}
+
+// GinAuthMiddleware verifies the bearer access token or personal access token, or
+// the access token cookie in cookie mode, and stores the caller in the Gin context. Personal access tokens only reach routes
+// guarded by GinRequireScope; GinRequireSession keeps them out of the rest.
+func GinAuthMiddleware(authSvc JWTServiceInterface, revocationStore TokenRevocationStore, patValidator PersonalAccessTokenValidator, suspensions SuspensionChecker, cookies *AuthCookies) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			return
+		if errorMessage := authenticate(c, authSvc, revocationStore, patValidator, suspensions, cookies); errorMessage != "" {
+			status := http.StatusUnauthorized
+			if errorMessage == errAccountSuspended {
+				status = http.StatusForbidden
 			}
+			c.JSON(status, gin.H{"error": errorMessage})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// GinOptionalAuthMiddleware stores the caller in the Gin context like
+// GinAuthMiddleware when the request carries a valid token, and lets every
+// other request through anonymously, for public routes that personalize
+// their response for signed-in users
+func GinOptionalAuthMiddleware(authSvc JWTServiceInterface, revocationStore TokenRevocationStore, patValidator PersonalAccessTokenValidator, suspensions SuspensionChecker, cookies *AuthCookies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// A missing, expired or revoked token, or a suspended user, is treated as no token at all
+		authenticate(c, authSvc, revocationStore, patValidator, suspensions, cookies)
 
+		c.Next()
+	}
+}
+
+// authenticate verifies the request's bearer token, or a browser client's access
+// token cookie when there is no Authorization header, and stores the caller in
+// the Gin context. It returns the error message for the client when the token
+// is missing or invalid or its user is suspended, and an empty string on success.
+func authenticate(c *gin.Context, authSvc JWTServiceInterface, revocationStore TokenRevocationStore, patValidator PersonalAccessTokenValidator, suspensions SuspensionChecker, cookies *AuthCookies) string {
+	var tokenString string
+	fromCookie := false
+
+	authHeader := c.GetHeader("Authorization")
+	if authHeader != "" {
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header format must be Bearer {token}"})
-			return
+			return "Authorization header format must be Bearer {token}"
 		}
+		tokenString = parts[1]
+	} else if tokenString = cookies.AccessToken(c); tokenString != "" {
+		fromCookie = true
+	} else {
+		return "Authorization header required"
+	}
 
-		tokenString := parts[1]
-
-		claims, err := authSvc.ValidateToken(tokenString)
+	// Personal access tokens are for scripts and are never kept in cookies
+	if strings.HasPrefix(tokenString, PersonalAccessTokenPrefix) && patValidator != nil && !fromCookie {
+		userID, role, scopes, err := patValidator.ValidatePersonalAccessToken(c.Request.Context(), tokenString)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			return "Invalid or expired token"
+		}
+		if isSuspended(c, suspensions, userID) {
+			return errAccountSuspended
+		}
+
+		c.Set("userID", userID)
+		c.Set("userRole", role)
+		c.Set("tokenScopes", scopes)
+		return ""
+	}
+
+	// Validate the access token using JWTService
+	claims, err := authSvc.ValidateToken(tokenString)
+	if err != nil {
+		return "Invalid or expired token"
+	}
+
+	// Refresh and MFA tokens are signed with the same key; only access tokens may authenticate
+	if tokenType, _ := claims["type"].(string); tokenType != AccessTokenType {
+		return "Invalid token type"
+	}
+
+	// Reject access tokens revoked by logout, password reset or a role change.
+	// A token without an ID could never be revoked, so it is refused.
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return "Invalid token ID"
+	}
+	revoked, err := revocationStore.IsRevoked(c.Request.Context(), jti)
+	if err != nil || revoked {
+		return "Token has been revoked"
+	}
+
+	// Extract user ID ("sub" claim) from token
+	sub, ok := claims["sub"].(string)
+	if !ok {
+		return "Invalid token subject"
+	}
+
+	if isSuspended(c, suspensions, sub) {
+		return errAccountSuspended
+	}
+
+	// An impersonation token stops working when the admin behind it is suspended
+	actorID := ActorID(claims)
+	if actorID != "" && isSuspended(c, suspensions, actorID) {
+		return errAccountSuspended
+	}
+
+	// Extract user role (may be empty)
+	role, _ := claims["role"].(string)
+
+	// Extract session ID (empty for tokens issued before sessions existed)
+	sessionID, _ := claims["sid"].(string)
+
+	// Store user ID, role and session in Gin context
+	c.Set("userID", sub)
+	c.Set("userRole", role)
+	c.Set("sessionID", sessionID)
+
+	if actorID != "" {
+		c.Set("actorID", actorID)
+	}
+	return ""
+}
+
+// isSuspended reports whether the user is suspended. Errors let the request
+// through so an outage does not lock everyone out; the user's sessions were
+// already revoked when the suspension started.
+func isSuspended(c *gin.Context, suspensions SuspensionChecker, userID string) bool {
+	if suspensions == nil {
+		return false
+	}
+
+	suspended, err := suspensions.IsSuspended(c.Request.Context(), userID)
+	if err != nil {
+		fmt.Printf("Warning: Failed to check suspension: %v\n", err)
+		return false
+	}
+	return suspended
+}
+
+// GinAuditImpersonation records every request that may change state and was
+// made with an impersonation token, with the admin as the actor and the user
+// as the target. It runs the rest of the chain first, so it can be installed
+// before the routes' own auth middleware.
+func GinAuditImpersonation(events SecurityEventRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		actorID, impersonated := GinGetActorID(c)
+		if !impersonated {
+			return
+		}
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
 			return
 		}
 
-		// Extract user ID ("sub") and role
-		sub, ok := claims["sub"].(string)
-		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token subject"})
+		userID, _ := GinGetUserID(c)
+		outcome := entities.OutcomeSuccess
+		if c.Writer.Status() >= http.StatusBadRequest {
+			outcome = entities.OutcomeFailure
+		}
+		events.Record(entities.SecurityEvent{
+			Type:     entities.SecurityEventImpersonatedRequest,
+			Outcome:  outcome,
+			ActorID:  actorID,
+			TargetID: userID,
+			Details:  fmt.Sprintf("%s %s: %d", c.Request.Method, c.Request.URL.Path, c.Writer.Status()),
+		}, entities.ClientInfo{
+			UserAgent: c.Request.UserAgent(),
+			IPAddress: c.ClientIP(),
+		})
+	}
+}
+
+// GinRequireScope lets personal access tokens through only if they were granted
+// the scope. Requests authenticated with a login session are not affected.
+func GinRequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scopes, isPersonalAccessToken := GinGetTokenScopes(c)
+		if !isPersonalAccessToken {
+			c.Next()
 			return
 		}
-		role, _ := claims["role"].(string)
 
-		// Store in Gin context
-		c.Set("userID", sub)
-		c.Set("userRole", role)
+		for _, granted := range scopes {
+			if granted == scope {
+				c.Next()
+				return
+			}
+		}
 
-		c.Next()
+		c.JSON(http.StatusForbidden, gin.H{"error": "Token is missing the required scope: " + scope})
+		c.Abort()
 	}
 }
 
-// GinAuthMiddleware is the Gin-compatible version of AuthMiddleware
-func GinAuthMiddleware(authSvc JWTServiceInterface) gin.HandlerFunc {
+// GinRequireSession rejects personal access tokens, for routes that manage the
+// account itself (profile, sessions, tokens, admin)
+func GinRequireSession() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+		if _, isPersonalAccessToken := GinGetTokenScopes(c); isPersonalAccessToken {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Personal access tokens cannot be used for this route"})
 			c.Abort()
 			return
 		}
+		c.Next()
+	}
+}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header format must be Bearer {token}"})
+// GinForbidImpersonation rejects requests made with an impersonation token, for
+// routes that change credentials or sessions, or use admin powers
+func GinForbidImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, impersonated := GinGetActorID(c); impersonated {
+			c.JSON(http.StatusForbidden, gin.H{"error": "This action is not allowed while impersonating a user"})
 			c.Abort()
 			return
 		}
+		c.Next()
+	}
+}
 
-		tokenString := parts[1]
+// RequirePermission lets the request through only if the caller's role grants the permission
+func RequirePermission(checker entities.PermissionChecker, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, exists := GinGetUserRole(c)
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Role not found"})
+			c.Abort()
+			return
+		}
 
-		// Validate the access token using JWTService
-		claims, err := authSvc.ValidateToken(tokenString)
+		allowed, err := checker.HasPermission(c.Request.Context(), role, permission)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check permissions"})
 			c.Abort()
 			return
 		}
-
-		// Extract user ID ("sub" claim) from token
-		sub, ok := claims["sub"].(string)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token subject"})
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: insufficient permissions"})
 			c.Abort()
 			return
 		}
 
-		// Extract user role (may be empty)
-		role, _ := claims["role"].(string)
-
-		// Store user ID and role in Gin context
-		c.Set("userID", sub)
-		c.Set("userRole", role)
-
 		c.Next()
 	}
 }
@@ -186,3 +382,35 @@ func GinGetUserRole(c *gin.Context) (string, bool) {
 	role, ok := userRole.(string)
 	return role, ok
 }
+
+// GinGetSessionID gets the session ID of the access token from Gin context
+func GinGetSessionID(c *gin.Context) (string, bool) {
+	sessionID, exists := c.Get("sessionID")
+	if !exists {
+		return "", false
+	}
+	id, ok := sessionID.(string)
+	return id, ok && id != ""
+}
+
+// GinGetActorID gets the user acting through an impersonation token from Gin
+// context. It reports false when the user is acting for themselves.
+func GinGetActorID(c *gin.Context) (string, bool) {
+	actorID, exists := c.Get("actorID")
+	if !exists {
+		return "", false
+	}
+	id, ok := actorID.(string)
+	return id, ok && id != ""
+}
+
+// GinGetTokenScopes gets the scopes of a personal access token from Gin context.
+// It reports false when the request was authenticated with a login session.
+func GinGetTokenScopes(c *gin.Context) ([]string, bool) {
+	tokenScopes, exists := c.Get("tokenScopes")
+	if !exists {
+		return nil, false
+	}
+	scopes, ok := tokenScopes.([]string)
+	return scopes, ok
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePersonalAccessToken is what fakePATValidator resolves a token to
+type fakePersonalAccessToken struct {
+	userID string
+	scopes []string
+}
+
+type fakePATValidator struct {
+	tokens map[string]fakePersonalAccessToken
+}
+
+func (v *fakePATValidator) ValidatePersonalAccessToken(ctx context.Context, token string) (string, string, []string, error) {
+	pat, ok := v.tokens[token]
+	if !ok {
+		return "", "", nil, errors.New("invalid token")
+	}
+	return pat.userID, entities.RoleUser, pat.scopes, nil
+}
+
+type fakeSuspensionChecker struct {
+	suspended map[string]bool
+}
+
+func (s *fakeSuspensionChecker) IsSuspended(ctx context.Context, userID string) (bool, error) {
+	return s.suspended[userID], nil
+}
+
+type fakeSecurityEventRecorder struct {
+	mutex  sync.Mutex
+	events []entities.SecurityEvent
+}
+
+func (r *fakeSecurityEventRecorder) Record(event entities.SecurityEvent, client entities.ClientInfo) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.events = append(r.events, event)
+}
+
+// newAuthTestRouter guards routes the way the API router does and answers 200
+// when a request gets through
+func newAuthTestRouter(jwtService *JWTService, revocationStore TokenRevocationStore, patValidator PersonalAccessTokenValidator, suspensions SuspensionChecker, cookies *AuthCookies, events SecurityEventRecorder) *gin.Engine {
+	authMiddleware := GinAuthMiddleware(jwtService, revocationStore, patValidator, suspensions, cookies)
+	ok := func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	}
+
+	router := gin.New()
+	router.Use(GinAuditImpersonation(events))
+	router.POST("/blog", authMiddleware, GinRequireScope(entities.ScopeBlogWrite), ok)
+
+	profileRoutes := router.Group("/profile")
+	profileRoutes.Use(authMiddleware, GinRequireSession())
+	profileRoutes.GET("/me", ok)
+	profileRoutes.PUT("/password", GinForbidImpersonation(), ok)
+
+	adminRoutes := router.Group("/admin")
+	adminRoutes.Use(authMiddleware, GinRequireSession(), GinForbidImpersonation())
+	adminRoutes.GET("/users", ok)
+	return router
+}
+
+func TestGinAuthMiddleware(t *testing.T) {
+	jwtService := NewJWTService(newTestKeyManager(t, newFakeSigningKeyRepository(), AlgorithmEdDSA, "test-encryption-key"))
+	revocationStore := NewInMemoryRevocationStore()
+	patValidator := &fakePATValidator{tokens: map[string]fakePersonalAccessToken{
+		PersonalAccessTokenPrefix + "writer":    {userID: "user-1", scopes: []string{entities.ScopeBlogWrite}},
+		PersonalAccessTokenPrefix + "reader":    {userID: "user-1", scopes: []string{entities.ScopeCommentsWrite}},
+		PersonalAccessTokenPrefix + "suspended": {userID: "suspended-user", scopes: []string{entities.ScopeBlogWrite}},
+	}}
+	suspensions := &fakeSuspensionChecker{suspended: map[string]bool{"suspended-user": true, "suspended-admin": true}}
+	router := newAuthTestRouter(jwtService, revocationStore, patValidator, suspensions, newTestAuthCookies(t, true), &fakeSecurityEventRecorder{})
+
+	accessToken, refreshToken, err := jwtService.GenerateTokens("user-1", entities.RoleUser, "session-1")
+	require.NoError(t, err)
+
+	revokedToken, _, err := jwtService.GenerateTokens("user-1", entities.RoleUser, "session-2")
+	require.NoError(t, err)
+	jti, expiresAt, err := jwtService.TokenID(revokedToken)
+	require.NoError(t, err)
+	require.NoError(t, revocationStore.Revoke(context.Background(), jti, expiresAt))
+
+	mfaToken, err := jwtService.GenerateMFAToken("user-1")
+	require.NoError(t, err)
+	impersonationToken, err := jwtService.GenerateImpersonationToken("user-1", entities.RoleUser, "admin-1", 10*time.Minute)
+	require.NoError(t, err)
+	suspendedActorToken, err := jwtService.GenerateImpersonationToken("user-1", entities.RoleUser, "suspended-admin", 10*time.Minute)
+	require.NoError(t, err)
+	suspendedUserToken, _, err := jwtService.GenerateTokens("suspended-user", entities.RoleUser, "session-3")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		bearer         string
+		cookie         string
+		expectedStatus int
+	}{
+		{name: "should accept an access token", method: http.MethodGet, path: "/profile/me", bearer: accessToken, expectedStatus: http.StatusOK},
+		{name: "should accept an access token from the cookie", method: http.MethodGet, path: "/profile/me", cookie: accessToken, expectedStatus: http.StatusOK},
+		{name: "should refuse a request without a token", method: http.MethodGet, path: "/profile/me", expectedStatus: http.StatusUnauthorized},
+		{name: "should refuse a revoked access token", method: http.MethodGet, path: "/profile/me", bearer: revokedToken, expectedStatus: http.StatusUnauthorized},
+		{name: "should refuse a refresh token", method: http.MethodGet, path: "/profile/me", bearer: refreshToken, expectedStatus: http.StatusUnauthorized},
+		{name: "should refuse an MFA token", method: http.MethodGet, path: "/profile/me", bearer: mfaToken, expectedStatus: http.StatusUnauthorized},
+		{name: "should refuse a suspended user", method: http.MethodGet, path: "/profile/me", bearer: suspendedUserToken, expectedStatus: http.StatusForbidden},
+		{name: "should accept a personal access token with the scope", method: http.MethodPost, path: "/blog", bearer: PersonalAccessTokenPrefix + "writer", expectedStatus: http.StatusOK},
+		{name: "should refuse a personal access token missing the scope", method: http.MethodPost, path: "/blog", bearer: PersonalAccessTokenPrefix + "reader", expectedStatus: http.StatusForbidden},
+		{name: "should refuse an unknown personal access token", method: http.MethodPost, path: "/blog", bearer: PersonalAccessTokenPrefix + "unknown", expectedStatus: http.StatusUnauthorized},
+		{name: "should refuse a personal access token sent in a cookie", method: http.MethodPost, path: "/blog", cookie: PersonalAccessTokenPrefix + "writer", expectedStatus: http.StatusUnauthorized},
+		{name: "should refuse a personal access token of a suspended user", method: http.MethodPost, path: "/blog", bearer: PersonalAccessTokenPrefix + "suspended", expectedStatus: http.StatusForbidden},
+		{name: "should refuse a personal access token on the profile", method: http.MethodGet, path: "/profile/me", bearer: PersonalAccessTokenPrefix + "writer", expectedStatus: http.StatusForbidden},
+		{name: "should refuse a personal access token on admin routes", method: http.MethodGet, path: "/admin/users", bearer: PersonalAccessTokenPrefix + "writer", expectedStatus: http.StatusForbidden},
+		{name: "should let an impersonation token read the profile", method: http.MethodGet, path: "/profile/me", bearer: impersonationToken, expectedStatus: http.StatusOK},
+		{name: "should refuse an impersonation token on credential routes", method: http.MethodPut, path: "/profile/password", bearer: impersonationToken, expectedStatus: http.StatusForbidden},
+		{name: "should refuse an impersonation token on admin routes", method: http.MethodGet, path: "/admin/users", bearer: impersonationToken, expectedStatus: http.StatusForbidden},
+		{name: "should refuse an impersonation token of a suspended admin", method: http.MethodGet, path: "/profile/me", bearer: suspendedActorToken, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.bearer != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: AccessTokenCookie, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestGinAuditImpersonation(t *testing.T) {
+	jwtService := NewJWTService(newTestKeyManager(t, newFakeSigningKeyRepository(), AlgorithmEdDSA, "test-encryption-key"))
+
+	accessToken, _, err := jwtService.GenerateTokens("user-1", entities.RoleUser, "session-1")
+	require.NoError(t, err)
+	impersonationToken, err := jwtService.GenerateImpersonationToken("user-1", entities.RoleUser, "admin-1", 10*time.Minute)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name            string
+		method          string
+		path            string
+		token           string
+		expectedOutcome string // empty when nothing should be recorded
+		expectedDetails string
+	}{
+		{name: "should record a change made while impersonating", method: http.MethodPost, path: "/blog", token: impersonationToken, expectedOutcome: entities.OutcomeSuccess, expectedDetails: "POST /blog: 200"},
+		{name: "should record a refused change made while impersonating", method: http.MethodPut, path: "/profile/password", token: impersonationToken, expectedOutcome: entities.OutcomeFailure, expectedDetails: "PUT /profile/password: 403"},
+		{name: "should not record a read made while impersonating", method: http.MethodGet, path: "/profile/me", token: impersonationToken},
+		{name: "should not record a change the user made themselves", method: http.MethodPost, path: "/blog", token: accessToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := &fakeSecurityEventRecorder{}
+			router := newAuthTestRouter(jwtService, NewInMemoryRevocationStore(), nil, nil, newTestAuthCookies(t, false), events)
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			if tt.expectedOutcome == "" {
+				assert.Empty(t, events.events)
+				return
+			}
+			require.Len(t, events.events, 1)
+			event := events.events[0]
+			assert.Equal(t, entities.SecurityEventImpersonatedRequest, event.Type)
+			assert.Equal(t, tt.expectedOutcome, event.Outcome)
+			assert.Equal(t, "admin-1", event.ActorID)
+			assert.Equal(t, "user-1", event.TargetID)
+			assert.Equal(t, tt.expectedDetails, event.Details)
+		})
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// BcryptMaxPasswordBytes is the longest password bcrypt accepts, in bytes
+const BcryptMaxPasswordBytes = 72
+
 type BcryptService struct {
 	cost int	
 }
@@ -24,3 +27,9 @@ func (s *BcryptService) ComparePassword(hashedPassword, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
 
+
+// NeedsRehash reports whether a hash was made with a different cost
+func (s *BcryptService) NeedsRehash(hashedPassword string) bool {
+	cost, err := bcrypt.Cost([]byte(hashedPassword))
+	return err != nil || cost != s.cost
+}
@@ -7,6 +7,7 @@ import (
 	"net/smtp"
 	"os"
 	"strings"
+	"time"
 )
 
 type EmailService struct {
@@ -176,6 +177,195 @@ Your Application Team
 	return nil
 }
 
+// SendMagicLinkEmail sends a single-use sign-in link
+func (e *EmailService) SendMagicLinkEmail(email, fullName, token string) error {
+	// Get base URL from environment or use default
+	baseURL := os.Getenv("APP_BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+
+	// Create sign-in link
+	magicLink := fmt.Sprintf("%s/auth/magic-link/verify?token=%s", baseURL, token)
+
+	// Email subject
+	subject := "Your Sign-in Link"
+
+	// Email body
+	body := fmt.Sprintf(`
+Hello %s,
+
+Click the link below to sign in. Open it in the same browser you requested it from:
+
+%s
+
+This link will expire in 10 minutes and can only be used once.
+
+If you didn't request this link, please ignore this email.
+
+Best regards,
+Your Application Team
+`, fullName, magicLink)
+
+	// Try to send real email if SMTP is configured
+	if e.smtpHost != "" && e.smtpUsername != "" && e.smtpPassword != "" {
+		err := e.sendEmail(email, subject, body)
+		if err == nil {
+			fmt.Printf("✅ Magic link email sent successfully to: %s\n", email)
+			return nil
+		}
+		fmt.Printf("⚠️ Failed to send email via SMTP: %v\n", err)
+	}
+
+	// Fallback to console logging if SMTP is not configured
+	fmt.Printf("=== MAGIC LINK EMAIL (CONSOLE LOG) ===\n")
+	fmt.Printf("To: %s\n", email)
+	fmt.Printf("Subject: %s\n", subject)
+	fmt.Printf("Body:\n%s\n", body)
+	fmt.Printf("Magic Link: %s\n", magicLink)
+	fmt.Printf("===========================\n")
+	fmt.Printf("💡 To send real emails, configure SMTP settings in your .env file\n")
+
+	return nil
+}
+
+// SendEmailChangeConfirmation asks the new address to confirm an email change
+func (e *EmailService) SendEmailChangeConfirmation(email, fullName, token string) error {
+	// Get base URL from environment or use default
+	baseURL := os.Getenv("APP_BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+
+	// Create confirmation link
+	confirmLink := fmt.Sprintf("%s/auth/email-change/confirm?token=%s", baseURL, token)
+
+	// Email subject
+	subject := "Confirm Your New Email Address"
+
+	// Email body
+	body := fmt.Sprintf(`
+Hello %s,
+
+You asked to use this address for your account. Click the link below to confirm the change:
+
+%s
+
+This link will expire in 24 hours. Until you confirm, your account keeps its current email address.
+
+If you didn't request this change, please ignore this email.
+
+Best regards,
+Your Application Team
+`, fullName, confirmLink)
+
+	// Try to send real email if SMTP is configured
+	if e.smtpHost != "" && e.smtpUsername != "" && e.smtpPassword != "" {
+		err := e.sendEmail(email, subject, body)
+		if err == nil {
+			fmt.Printf("✅ Email change confirmation sent successfully to: %s\n", email)
+			return nil
+		}
+		fmt.Printf("⚠️ Failed to send email via SMTP: %v\n", err)
+	}
+
+	// Fallback to console logging if SMTP is not configured
+	fmt.Printf("=== EMAIL CHANGE CONFIRMATION (CONSOLE LOG) ===\n")
+	fmt.Printf("To: %s\n", email)
+	fmt.Printf("Subject: %s\n", subject)
+	fmt.Printf("Body:\n%s\n", body)
+	fmt.Printf("Confirmation Link: %s\n", confirmLink)
+	fmt.Printf("===========================\n")
+
+	return nil
+}
+
+// SendEmailChangeRequestedNotice tells the current address about a requested change and how to cancel it
+func (e *EmailService) SendEmailChangeRequestedNotice(email, fullName, newEmail, cancelToken string) error {
+	// Get base URL from environment or use default
+	baseURL := os.Getenv("APP_BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+
+	// Create cancellation link
+	cancelLink := fmt.Sprintf("%s/auth/email-change/cancel?token=%s", baseURL, cancelToken)
+
+	// Email subject
+	subject := "Email Change Requested"
+
+	// Email body
+	body := fmt.Sprintf(`
+Hello %s,
+
+Someone asked to change the email address of your account to %s. The change only happens once it is confirmed from that address.
+
+If this wasn't you, cancel the change with the link below and change your password:
+
+%s
+
+Best regards,
+Your Application Team
+`, fullName, newEmail, cancelLink)
+
+	// Try to send real email if SMTP is configured
+	if e.smtpHost != "" && e.smtpUsername != "" && e.smtpPassword != "" {
+		err := e.sendEmail(email, subject, body)
+		if err == nil {
+			fmt.Printf("✅ Email change notice sent successfully to: %s\n", email)
+			return nil
+		}
+		fmt.Printf("⚠️ Failed to send email via SMTP: %v\n", err)
+	}
+
+	// Fallback to console logging if SMTP is not configured
+	fmt.Printf("=== EMAIL CHANGE NOTICE (CONSOLE LOG) ===\n")
+	fmt.Printf("To: %s\n", email)
+	fmt.Printf("Subject: %s\n", subject)
+	fmt.Printf("Body:\n%s\n", body)
+	fmt.Printf("Cancel Link: %s\n", cancelLink)
+	fmt.Printf("===========================\n")
+
+	return nil
+}
+
+// SendEmailChangedNotification tells the old address that the account moved to a new email
+func (e *EmailService) SendEmailChangedNotification(email, fullName, newEmail string) error {
+	// Email subject
+	subject := "Email Address Changed"
+
+	// Email body
+	body := fmt.Sprintf(`
+Hello %s,
+
+The email address of your account has been changed to %s. This address will no longer receive messages about the account.
+
+If you didn't make this change, please contact support immediately.
+
+Best regards,
+Your Application Team
+`, fullName, newEmail)
+
+	// Try to send real email if SMTP is configured
+	if e.smtpHost != "" && e.smtpUsername != "" && e.smtpPassword != "" {
+		err := e.sendEmail(email, subject, body)
+		if err == nil {
+			fmt.Printf("✅ Email changed notification sent successfully to: %s\n", email)
+			return nil
+		}
+		fmt.Printf("⚠️ Failed to send email via SMTP: %v\n", err)
+	}
+
+	// Fallback to console logging if SMTP is not configured
+	fmt.Printf("=== EMAIL CHANGED NOTIFICATION (CONSOLE LOG) ===\n")
+	fmt.Printf("To: %s\n", email)
+	fmt.Printf("Subject: %s\n", subject)
+	fmt.Printf("Body:\n%s\n", body)
+	fmt.Printf("===========================\n")
+
+	return nil
+}
+
 // SendPasswordChangeNotification sends a notification when password is changed
 func (e *EmailService) SendPasswordChangeNotification(email, fullName string) error {
 	subject := "Password Changed Successfully"
@@ -211,6 +401,200 @@ Your Application Team
 	return nil
 }
 
+// SendAccountLockedEmail tells the owner that sign-in was locked after repeated failed attempts
+func (e *EmailService) SendAccountLockedEmail(email, fullName string, lockedUntil time.Time) error {
+	subject := "Sign-in Temporarily Locked"
+
+	body := fmt.Sprintf(`
+Hello %s,
+
+We noticed several failed attempts to sign in to your account, so sign-in has been locked until %s.
+
+If this was you, you can try again after that time or reset your password. If it wasn't, someone may be trying to guess your password; consider resetting it and enabling two-factor authentication.
+
+Best regards,
+Your Application Team
+`, fullName, lockedUntil.UTC().Format("2006-01-02 15:04 MST"))
+
+	// Try to send real email if SMTP is configured
+	if e.smtpHost != "" && e.smtpUsername != "" && e.smtpPassword != "" {
+		err := e.sendEmail(email, subject, body)
+		if err == nil {
+			fmt.Printf("✅ Account locked notification sent successfully to: %s\n", email)
+			return nil
+		}
+		fmt.Printf("⚠️ Failed to send email via SMTP: %v\n", err)
+	}
+
+	// Fallback to console logging
+	fmt.Printf("=== ACCOUNT LOCKED NOTIFICATION (CONSOLE LOG) ===\n")
+	fmt.Printf("To: %s\n", email)
+	fmt.Printf("Subject: %s\n", subject)
+	fmt.Printf("Body:\n%s\n", body)
+	fmt.Printf("====================================\n")
+
+	return nil
+}
+
+// SendAccountDeletionScheduledEmail tells the owner when their account will be deleted and how to keep it
+func (e *EmailService) SendAccountDeletionScheduledEmail(email, fullName string, deleteAfter time.Time) error {
+	subject := "Your Account Will Be Deleted"
+
+	body := fmt.Sprintf(`
+Hello %s,
+
+We received your request to delete your account. Your account and everything you posted will be permanently deleted on %s.
+
+If you change your mind, sign in before then and cancel the deletion from your profile. If you didn't request this, sign in, cancel the deletion and change your password right away.
+
+Best regards,
+Your Application Team
+`, fullName, deleteAfter.UTC().Format("2006-01-02 15:04 MST"))
+
+	// Try to send real email if SMTP is configured
+	if e.smtpHost != "" && e.smtpUsername != "" && e.smtpPassword != "" {
+		err := e.sendEmail(email, subject, body)
+		if err == nil {
+			fmt.Printf("✅ Account deletion notice sent successfully to: %s\n", email)
+			return nil
+		}
+		fmt.Printf("⚠️ Failed to send email via SMTP: %v\n", err)
+	}
+
+	// Fallback to console logging
+	fmt.Printf("=== ACCOUNT DELETION NOTICE (CONSOLE LOG) ===\n")
+	fmt.Printf("To: %s\n", email)
+	fmt.Printf("Subject: %s\n", subject)
+	fmt.Printf("Body:\n%s\n", body)
+	fmt.Printf("====================================\n")
+
+	return nil
+}
+
+// SendAccountSuspendedEmail tells a user their account was suspended, why and until when
+func (e *EmailService) SendAccountSuspendedEmail(email, fullName, reason string, until *time.Time) error {
+	subject := "Your Account Has Been Suspended"
+
+	duration := "until further notice"
+	if until != nil {
+		duration = "until " + until.UTC().Format("2006-01-02 15:04 MST")
+	}
+
+	body := fmt.Sprintf(`
+Hello %s,
+
+Your account has been suspended %s. While it is suspended you cannot sign in or use the API.
+
+Reason: %s
+
+If you believe this is a mistake, please reply to this email.
+
+Best regards,
+Your Application Team
+`, fullName, duration, reason)
+
+	// Try to send real email if SMTP is configured
+	if e.smtpHost != "" && e.smtpUsername != "" && e.smtpPassword != "" {
+		err := e.sendEmail(email, subject, body)
+		if err == nil {
+			fmt.Printf("✅ Suspension notice sent successfully to: %s\n", email)
+			return nil
+		}
+		fmt.Printf("⚠️ Failed to send email via SMTP: %v\n", err)
+	}
+
+	// Fallback to console logging
+	fmt.Printf("=== SUSPENSION NOTICE (CONSOLE LOG) ===\n")
+	fmt.Printf("To: %s\n", email)
+	fmt.Printf("Subject: %s\n", subject)
+	fmt.Printf("Body:\n%s\n", body)
+	fmt.Printf("====================================\n")
+
+	return nil
+}
+
+// SendAccountRestoredEmail tells a user their suspension was lifted
+func (e *EmailService) SendAccountRestoredEmail(email, fullName string) error {
+	subject := "Your Account Has Been Restored"
+
+	body := fmt.Sprintf(`
+Hello %s,
+
+The suspension of your account has been lifted. You can sign in again.
+
+Best regards,
+Your Application Team
+`, fullName)
+
+	// Try to send real email if SMTP is configured
+	if e.smtpHost != "" && e.smtpUsername != "" && e.smtpPassword != "" {
+		err := e.sendEmail(email, subject, body)
+		if err == nil {
+			fmt.Printf("✅ Account restored notice sent successfully to: %s\n", email)
+			return nil
+		}
+		fmt.Printf("⚠️ Failed to send email via SMTP: %v\n", err)
+	}
+
+	// Fallback to console logging
+	fmt.Printf("=== ACCOUNT RESTORED NOTICE (CONSOLE LOG) ===\n")
+	fmt.Printf("To: %s\n", email)
+	fmt.Printf("Subject: %s\n", subject)
+	fmt.Printf("Body:\n%s\n", body)
+	fmt.Printf("====================================\n")
+
+	return nil
+}
+
+// SendDataExportReadyEmail sends the link to download a finished data export
+func (e *EmailService) SendDataExportReadyEmail(email, fullName, token string, expiresAt time.Time) error {
+	// Get base URL from environment or use default
+	baseURL := os.Getenv("APP_BASE_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+
+	// Create download link
+	downloadLink := fmt.Sprintf("%s/profile/export/download?token=%s", baseURL, token)
+
+	subject := "Your Data Export Is Ready"
+
+	body := fmt.Sprintf(`
+Hello %s,
+
+The copy of your data you requested is ready. Download it here:
+
+%s
+
+This link will expire on %s. Anyone with the link can download your data, so don't share it.
+
+If you didn't request this export, change your password right away.
+
+Best regards,
+Your Application Team
+`, fullName, downloadLink, expiresAt.UTC().Format("2006-01-02 15:04 MST"))
+
+	// Try to send real email if SMTP is configured
+	if e.smtpHost != "" && e.smtpUsername != "" && e.smtpPassword != "" {
+		err := e.sendEmail(email, subject, body)
+		if err == nil {
+			fmt.Printf("✅ Data export email sent successfully to: %s\n", email)
+			return nil
+		}
+		fmt.Printf("⚠️ Failed to send email via SMTP: %v\n", err)
+	}
+
+	// Fallback to console logging
+	fmt.Printf("=== DATA EXPORT EMAIL (CONSOLE LOG) ===\n")
+	fmt.Printf("To: %s\n", email)
+	fmt.Printf("Subject: %s\n", subject)
+	fmt.Printf("Body:\n%s\n", body)
+	fmt.Printf("Download Link: %s\n", downloadLink)
+	fmt.Printf("====================================\n")
+
+	return nil
+}
+
 // sendEmail sends an email using SMTP
 func (e *EmailService) sendEmail(to, subject, body string) error {
 	// Email headers
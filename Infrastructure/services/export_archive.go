@@ -0,0 +1,54 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+// ExportArchive builds the zip archive of a personal data export in memory
+type ExportArchive struct {
+	buffer    bytes.Buffer
+	writer    *zip.Writer
+	createdAt time.Time
+}
+
+// NewExportArchive starts an empty archive
+func NewExportArchive(createdAt time.Time) *ExportArchive {
+	archive := &ExportArchive{createdAt: createdAt}
+	archive.writer = zip.NewWriter(&archive.buffer)
+	return archive
+}
+
+// AddJSON adds a file holding the indented JSON encoding of v
+func (a *ExportArchive) AddJSON(name string, v interface{}) error {
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return a.AddFile(name, content)
+}
+
+// AddFile adds a file to the archive
+func (a *ExportArchive) AddFile(name string, content []byte) error {
+	file, err := a.writer.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: a.createdAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(content)
+	return err
+}
+
+// Bytes finishes the archive and returns its contents
+func (a *ExportArchive) Bytes() ([]byte, error) {
+	if err := a.writer.Close(); err != nil {
+		return nil, err
+	}
+	return a.buffer.Bytes(), nil
+}
@@ -7,38 +7,55 @@ import (
     "fmt"
 )
 
+// JWTService signs and verifies tokens with the keys of a KeyManager. Tokens
+// signed with the old HS256 secret are not accepted: they carry neither a
+// "type" nor a session, so upgrading signs every user out once.
 type JWTService struct {
-    SecretKey string
+    keys *KeyManager
 }
 
-func NewJWTService(secretKey string) *JWTService {
+func NewJWTService(keys *KeyManager) *JWTService {
     return &JWTService{
-        SecretKey: secretKey,
+        keys: keys,
     }
 }
 
 type JWTServiceInterface interface {
-    GenerateTokens(userID string, userRole string) (string, string, error)
+    GenerateTokens(userID string, userRole string, sessionID string) (string, string, error)
     ValidateToken(tokenString string) (jwt.MapClaims, error) 
-    GenerateResetToken(userID string) (string, error)
-    ValidateResetToken(tokenString string) (jwt.MapClaims, error)
+    ValidateRefreshToken(tokenString string) (jwt.MapClaims, error)
+    GenerateMFAToken(userID string) (string, error)
+    GenerateImpersonationToken(userID string, userRole string, actorID string, ttl time.Duration) (string, error)
+    ValidateMFAToken(tokenString string) (jwt.MapClaims, error)
 }
 
+const (
+    AccessTokenType  = "access"
+    RefreshTokenType = "refresh"
+    MFATokenType     = "mfa"
+)
+
+// RefreshTokenTTL is how long a refresh token lives, the longest of any token
+const RefreshTokenTTL = 7 * 24 * time.Hour
+
 
 // GenerateTokens creates access and refresh tokens with essential claims.
-func (s *JWTService) GenerateTokens(userID string, userRole string) (string, string, error) {
+// sessionID ("sid") ties both tokens to the stored token family so refresh
+// tokens can be rotated and revoked together.
+func (s *JWTService) GenerateTokens(userID string, userRole string, sessionID string) (string, string, error) {
     now := time.Now()
 
     // Access Token (15 min expiry)
     accessClaims := jwt.MapClaims{
         "sub": userID,
         "role": userRole,
+        "sid": sessionID,
+        "type": AccessTokenType,
         "iat": now.Unix(),
         "exp": now.Add(15 * time.Minute).Unix(),
         "jti": uuid.NewString(),
     }
-    accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-    accessTokenString, err := accessToken.SignedString([]byte(s.SecretKey))
+    accessTokenString, err := s.sign(accessClaims)
     if err != nil {
         return "", "", err
     }
@@ -47,12 +64,13 @@ func (s *JWTService) GenerateTokens(userID string, userRole string) (string, str
     refreshClaims := jwt.MapClaims{
         "sub": userID,
         "role": userRole,
+        "sid": sessionID,
+        "type": RefreshTokenType,
         "iat": now.Unix(),                          // timestamp 
-        "exp": now.Add(7 * 24 * time.Hour).Unix(),
+        "exp": now.Add(RefreshTokenTTL).Unix(),
         "jti": uuid.NewString(),
     }
-    refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-    refreshTokenString, err := refreshToken.SignedString([]byte(s.SecretKey))
+    refreshTokenString, err := s.sign(refreshClaims)
     if err != nil {
         return "", "", err
     }
@@ -60,14 +78,38 @@ func (s *JWTService) GenerateTokens(userID string, userRole string) (string, str
     return accessTokenString, refreshTokenString, nil
 }
 
+// sign signs claims with the active key and names it in the "kid" header
+func (s *JWTService) sign(claims jwt.MapClaims) (string, error) {
+    kid, method, key, err := s.keys.SigningKey()
+    if err != nil {
+        return "", err
+    }
+
+    token := jwt.NewWithClaims(method, claims)
+    token.Header["kid"] = kid
+    return token.SignedString(key)
+}
+
+// verificationKey picks the public key named by the token's "kid" header
+func (s *JWTService) verificationKey(t *jwt.Token) (interface{}, error) {
+    kid, ok := t.Header["kid"].(string)
+    if !ok {
+        return nil, fmt.Errorf("token has no key ID")
+    }
+
+    method, key, err := s.keys.VerificationKey(kid)
+    if err != nil {
+        return nil, err
+    }
+    if t.Method.Alg() != method.Alg() {
+        return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+    }
+    return key, nil
+}
+
 // ValidateToken verifies the token signature and expiration.
 func (s *JWTService) ValidateToken(tokenString string) (jwt.MapClaims, error) {
-    token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-        if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-            return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-        }
-        return []byte(s.SecretKey), nil
-    })
+    token, err := jwt.Parse(tokenString, s.verificationKey)
     if err != nil {
         return nil, err
     }
@@ -77,52 +119,109 @@ func (s *JWTService) ValidateToken(tokenString string) (jwt.MapClaims, error) {
         return nil, fmt.Errorf("invalid token")
     }
 
+    // An "act" claim must name who is acting, or the token could pass as the user's own
+    if _, exists := claims["act"]; exists && ActorID(claims) == "" {
+        return nil, fmt.Errorf("invalid actor claim")
+    }
+
+    return claims, nil
+}
+
+// ActorID returns the user acting on behalf of the token subject, from the
+// "act" claim of an impersonation token, or an empty string
+func ActorID(claims jwt.MapClaims) string {
+    actor, ok := claims["act"].(map[string]interface{})
+    if !ok {
+        return ""
+    }
+    sub, _ := actor["sub"].(string)
+    return sub
+}
+
+// ValidateRefreshToken verifies a refresh token and makes sure it was issued as one
+func (s *JWTService) ValidateRefreshToken(tokenString string) (jwt.MapClaims, error) {
+    claims, err := s.ValidateToken(tokenString)
+    if err != nil {
+        return nil, err
+    }
+
+    if tokenType, ok := claims["type"].(string); !ok || tokenType != RefreshTokenType {
+        return nil, fmt.Errorf("invalid token type")
+    }
+
+    if sid, ok := claims["sid"].(string); !ok || sid == "" {
+        return nil, fmt.Errorf("refresh token has no session")
+    }
+
+    if ActorID(claims) != "" {
+        return nil, fmt.Errorf("impersonation tokens cannot be refreshed")
+    }
+
     return claims, nil
 }
 
-// GenerateResetToken creates a reset token with 15-minute expiry
-func (s *JWTService) GenerateResetToken(userID string) (string, error) {
+// TokenID reads the "jti" and expiry of a token this service issued, without
+// re-verifying it. Use it only on tokens that were generated or validated already.
+func (s *JWTService) TokenID(tokenString string) (string, time.Time, error) {
+    claims := jwt.MapClaims{}
+    if _, _, err := jwt.NewParser().ParseUnverified(tokenString, claims); err != nil {
+        return "", time.Time{}, err
+    }
+
+    jti, _ := claims["jti"].(string)
+    exp, err := claims.GetExpirationTime()
+    if err != nil || exp == nil {
+        return "", time.Time{}, fmt.Errorf("token has no expiry")
+    }
+
+    return jti, exp.Time, nil
+}
+
+// GenerateMFAToken creates the short-lived challenge token a user trades for
+// real tokens once the second factor is verified (5-minute expiry)
+func (s *JWTService) GenerateMFAToken(userID string) (string, error) {
     now := time.Now()
 
-    resetClaims := jwt.MapClaims{
+    mfaClaims := jwt.MapClaims{
         "sub": userID,
-        "type": "reset",
+        "type": MFATokenType,
         "iat": now.Unix(),
-        "exp": now.Add(15 * time.Minute).Unix(),
+        "exp": now.Add(5 * time.Minute).Unix(),
         "jti": uuid.NewString(),
     }
-    
-    resetToken := jwt.NewWithClaims(jwt.SigningMethodHS256, resetClaims)
-    resetTokenString, err := resetToken.SignedString([]byte(s.SecretKey))
-    if err != nil {
-        return "", err
+
+    return s.sign(mfaClaims)
+}
+
+// GenerateImpersonationToken creates an access token for userID that actorID
+// uses on their behalf. The actor is named in the "act" claim (RFC 8693); the
+// token belongs to no session and comes without a refresh token.
+func (s *JWTService) GenerateImpersonationToken(userID string, userRole string, actorID string, ttl time.Duration) (string, error) {
+    now := time.Now()
+
+    impersonationClaims := jwt.MapClaims{
+        "sub": userID,
+        "role": userRole,
+        "act": map[string]interface{}{"sub": actorID},
+        "type": AccessTokenType,
+        "iat": now.Unix(),
+        "exp": now.Add(ttl).Unix(),
+        "jti": uuid.NewString(),
     }
 
-    return resetTokenString, nil
+    return s.sign(impersonationClaims)
 }
 
-// ValidateResetToken verifies the reset token signature and expiration
-func (s *JWTService) ValidateResetToken(tokenString string) (jwt.MapClaims, error) {
-    token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-        if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-            return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
-        }
-        return []byte(s.SecretKey), nil
-    })
+// ValidateMFAToken verifies an MFA challenge token
+func (s *JWTService) ValidateMFAToken(tokenString string) (jwt.MapClaims, error) {
+    claims, err := s.ValidateToken(tokenString)
     if err != nil {
         return nil, err
     }
 
-    claims, ok := token.Claims.(jwt.MapClaims)
-    if !ok || !token.Valid {
-        return nil, fmt.Errorf("invalid reset token")
-    }
-
-    // Check if it's a reset token
-    if tokenType, ok := claims["type"].(string); !ok || tokenType != "reset" {
+    if tokenType, ok := claims["type"].(string); !ok || tokenType != MFATokenType {
         return nil, fmt.Errorf("invalid token type")
     }
 
     return claims, nil
 }
-    
\ No newline at end of file
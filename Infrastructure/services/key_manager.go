@@ -0,0 +1,417 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// minKeyRotationInterval keeps rotation from running in a tight loop
+const minKeyRotationInterval = time.Minute
+
+// unknownKeyReloadInterval limits how often a token with an unknown "kid",
+// e.g. one signed with a key another instance just rotated to, reloads the keys
+const unknownKeyReloadInterval = 10 * time.Second
+
+// JSONWebKey is the public half of a signing key, as published in the JWKS
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`   // RSA modulus
+	E   string `json:"e,omitempty"`   // RSA exponent
+	Crv string `json:"crv,omitempty"` // OKP or EC curve
+	X   string `json:"x,omitempty"`   // OKP public key or EC x coordinate
+	Y   string `json:"y,omitempty"`   // EC y coordinate
+}
+
+// JSONWebKeySet is the document served at /.well-known/jwks.json
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// loadedKey is a parsed SigningKey
+type loadedKey struct {
+	kid        string
+	method     jwt.SigningMethod
+	privateKey crypto.Signer
+	publicKey  crypto.PublicKey
+	createdAt  time.Time
+	retired    bool
+}
+
+// KeyManager keeps the JWT signing keys: one active key signs new tokens, and
+// retired keys keep verifying tokens for a grace period after rotation.
+// Keys are persisted so every API instance signs and verifies with the same set;
+// private keys are encrypted before they are stored.
+type KeyManager struct {
+	repo             entities.SigningKeyRepository
+	algorithm        string
+	rotationInterval time.Duration
+	gracePeriod      time.Duration
+	box              *secretBox
+
+	mutex  sync.RWMutex
+	active *loadedKey
+	keys   map[string]*loadedKey
+	newest string // kid of the newest stored key, even one that could not be decrypted
+
+	reloadMutex       sync.Mutex
+	unknownReloadedAt time.Time
+}
+
+// NewKeyManager creates a key manager. The grace period must outlive the
+// longest-lived token (the 7-day refresh token) or rotation would sign users
+// out, so a shorter one is refused.
+// The encryption key can be any string; changing it makes every stored key
+// unusable, so new ones are generated and every user is signed out.
+func NewKeyManager(repo entities.SigningKeyRepository, algorithm string, rotationInterval, gracePeriod time.Duration, encryptionKey string) (*KeyManager, error) {
+	if algorithm != AlgorithmRS256 && algorithm != AlgorithmEdDSA {
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", algorithm)
+	}
+	if rotationInterval < minKeyRotationInterval {
+		return nil, fmt.Errorf("key rotation interval must be at least %v", minKeyRotationInterval)
+	}
+	if gracePeriod < RefreshTokenTTL {
+		return nil, fmt.Errorf("key grace period must be at least the refresh token lifetime (%v)", RefreshTokenTTL)
+	}
+	if encryptionKey == "" {
+		return nil, fmt.Errorf("signing key encryption key is required")
+	}
+
+	box, err := newSecretBox(encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyManager{
+		repo:             repo,
+		algorithm:        algorithm,
+		rotationInterval: rotationInterval,
+		gracePeriod:      gracePeriod,
+		box:              box,
+		keys:             make(map[string]*loadedKey),
+	}, nil
+}
+
+// Load reads the usable keys from the database, rotating first if there is
+// no active key or the active one is due for rotation
+func (m *KeyManager) Load(ctx context.Context) error {
+	if err := m.reload(ctx); err != nil {
+		return err
+	}
+
+	m.mutex.RLock()
+	due := m.active == nil || time.Since(m.active.createdAt) >= m.rotationInterval
+	m.mutex.RUnlock()
+
+	if due {
+		return m.rotate(ctx)
+	}
+	return nil
+}
+
+// Rotate creates a new active key and retires the previous ones
+func (m *KeyManager) Rotate(ctx context.Context) error {
+	if err := m.reload(ctx); err != nil {
+		return err
+	}
+	return m.rotate(ctx)
+}
+
+// rotate replaces the newest key seen by the last reload. When instances rotate
+// at the same time only one new key is stored; the others load it instead.
+func (m *KeyManager) rotate(ctx context.Context) error {
+	m.mutex.RLock()
+	previous := m.newest
+	m.mutex.RUnlock()
+
+	key, err := generateSigningKey(m.algorithm)
+	if err != nil {
+		return fmt.Errorf("failed to generate signing key: %v", err)
+	}
+	key.Replaces = previous
+	key.PrivateKey, err = m.box.seal([]byte(key.PrivateKey))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt signing key: %v", err)
+	}
+
+	if err := m.repo.Create(ctx, key); err != nil {
+		if errors.Is(err, entities.ErrSigningKeyReplaced) {
+			return m.reload(ctx)
+		}
+		return fmt.Errorf("failed to store signing key: %v", err)
+	}
+
+	now := time.Now()
+	stored, err := m.repo.FindUsable(ctx, now)
+	if err != nil {
+		return fmt.Errorf("failed to load signing keys: %v", err)
+	}
+	for _, k := range stored {
+		if k.ID == key.ID || k.RetiredAt != nil {
+			continue
+		}
+		if err := m.repo.Retire(ctx, k.ID, now, now.Add(m.gracePeriod)); err != nil {
+			// Another instance may have retired it already
+			fmt.Printf("Warning: Failed to retire signing key %s: %v\n", k.ID, err)
+		}
+	}
+
+	if err := m.repo.DeleteExpired(ctx, now); err != nil {
+		fmt.Printf("Warning: Failed to delete expired signing keys: %v\n", err)
+	}
+
+	return m.reload(ctx)
+}
+
+// StartRotation starts a background routine that picks up keys rotated by other
+// instances and rotates the active key when it is due
+func (m *KeyManager) StartRotation() {
+	interval := m.rotationInterval / 4
+	if interval > time.Hour {
+		interval = time.Hour
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := m.Load(context.Background()); err != nil {
+				fmt.Printf("Warning: Failed to rotate signing keys: %v\n", err)
+			}
+		}
+	}()
+}
+
+// SigningKey returns the active key used to sign new tokens
+func (m *KeyManager) SigningKey() (string, jwt.SigningMethod, crypto.Signer, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if m.active == nil {
+		return "", nil, nil, fmt.Errorf("no active signing key")
+	}
+	return m.active.kid, m.active.method, m.active.privateKey, nil
+}
+
+// VerificationKey returns the public key and algorithm for a "kid". An unknown
+// "kid" reloads the keys first, at most every unknownKeyReloadInterval, so
+// tokens signed by an instance that has just rotated verify everywhere.
+func (m *KeyManager) VerificationKey(kid string) (jwt.SigningMethod, crypto.PublicKey, error) {
+	key, ok := m.key(kid)
+	if !ok {
+		m.reloadForUnknownKey()
+		key, ok = m.key(kid)
+	}
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown signing key: %s", kid)
+	}
+	return key.method, key.publicKey, nil
+}
+
+func (m *KeyManager) key(kid string) (*loadedKey, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	key, ok := m.keys[kid]
+	return key, ok
+}
+
+// reloadForUnknownKey reloads the keys unless that was done recently. Callers
+// arriving during a reload wait for it.
+func (m *KeyManager) reloadForUnknownKey() {
+	m.reloadMutex.Lock()
+	defer m.reloadMutex.Unlock()
+
+	if time.Since(m.unknownReloadedAt) < unknownKeyReloadInterval {
+		return
+	}
+	m.unknownReloadedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.reload(ctx); err != nil {
+		fmt.Printf("Warning: Failed to reload signing keys: %v\n", err)
+	}
+}
+
+// JWKS returns the public keys of every key that can still verify tokens
+func (m *KeyManager) JWKS() JSONWebKeySet {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	for _, key := range m.keys {
+		jwk := JSONWebKey{Kid: key.kid, Use: "sig", Alg: key.method.Alg()}
+		switch pub := key.publicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// reload replaces the in-memory key set with the usable keys from the database
+func (m *KeyManager) reload(ctx context.Context) error {
+	stored, err := m.repo.FindUsable(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to load signing keys: %v", err)
+	}
+
+	keys := make(map[string]*loadedKey, len(stored))
+	var active *loadedKey
+	newest := ""
+	if len(stored) > 0 {
+		newest = stored[0].ID
+	}
+	for i := range stored {
+		key, err := m.decryptSigningKey(ctx, &stored[i])
+		if err != nil {
+			fmt.Printf("Warning: Skipping signing key %s: %v\n", stored[i].ID, err)
+			continue
+		}
+		keys[key.kid] = key
+
+		// Keys are sorted newest first; the newest unretired key signs
+		if active == nil && !key.retired {
+			active = key
+		}
+	}
+
+	m.mutex.Lock()
+	m.keys = keys
+	m.active = active
+	m.newest = newest
+	m.mutex.Unlock()
+
+	return nil
+}
+
+// decryptSigningKey parses a stored key pair. Keys stored in plain text before
+// encryption was introduced are encrypted in place.
+func (m *KeyManager) decryptSigningKey(ctx context.Context, stored *entities.SigningKey) (*loadedKey, error) {
+	if strings.HasPrefix(stored.PrivateKey, "-----BEGIN") {
+		encrypted, err := m.box.seal([]byte(stored.PrivateKey))
+		if err == nil {
+			err = m.repo.UpdatePrivateKey(ctx, stored.ID, stored.PrivateKey, encrypted)
+		}
+		if err != nil {
+			fmt.Printf("Warning: Failed to encrypt signing key %s: %v\n", stored.ID, err)
+		}
+		return parseSigningKey(stored, stored.PrivateKey)
+	}
+
+	privatePEM, err := m.box.open(stored.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return parseSigningKey(stored, string(privatePEM))
+}
+
+// generateSigningKey creates a new key pair for the given algorithm
+func generateSigningKey(algorithm string) (*entities.SigningKey, error) {
+	var privateKey crypto.Signer
+	switch algorithm {
+	case AlgorithmRS256:
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, err
+		}
+		privateKey = key
+	case AlgorithmEdDSA:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		privateKey = key
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm: %s", algorithm)
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(privateKey.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.SigningKey{
+		ID:         uuid.NewString(),
+		Algorithm:  algorithm,
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})),
+		PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})),
+		CreatedAt:  time.Now(),
+	}, nil
+}
+
+// parseSigningKey decodes a key pair, given its private key as PEM
+func parseSigningKey(key *entities.SigningKey, privatePEM string) (*loadedKey, error) {
+	block, _ := pem.Decode([]byte(privatePEM))
+	if block == nil {
+		return nil, fmt.Errorf("invalid private key PEM")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := &loadedKey{
+		kid:       key.ID,
+		createdAt: key.CreatedAt,
+		retired:   key.RetiredAt != nil,
+	}
+
+	switch private := parsed.(type) {
+	case *rsa.PrivateKey:
+		loaded.method = jwt.SigningMethodRS256
+		loaded.privateKey = private
+		loaded.publicKey = &private.PublicKey
+	case ed25519.PrivateKey:
+		loaded.method = jwt.SigningMethodEdDSA
+		loaded.privateKey = private
+		loaded.publicKey = private.Public()
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+
+	if loaded.method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("key algorithm %s does not match stored %s", loaded.method.Alg(), key.Algorithm)
+	}
+
+	return loaded, nil
+}
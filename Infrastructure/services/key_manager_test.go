@@ -0,0 +1,368 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSigningKeyRepository keeps signing keys in memory. Like the unique index
+// on "replaces", it refuses a second key replacing the same key.
+type fakeSigningKeyRepository struct {
+	mutex sync.Mutex
+	keys  map[string]entities.SigningKey
+	finds int
+}
+
+func newFakeSigningKeyRepository() *fakeSigningKeyRepository {
+	return &fakeSigningKeyRepository{keys: map[string]entities.SigningKey{}}
+}
+
+func (r *fakeSigningKeyRepository) Create(ctx context.Context, key *entities.SigningKey) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, stored := range r.keys {
+		if stored.Replaces == key.Replaces {
+			return entities.ErrSigningKeyReplaced
+		}
+	}
+	r.keys[key.ID] = *key
+	return nil
+}
+
+func (r *fakeSigningKeyRepository) FindUsable(ctx context.Context, now time.Time) ([]entities.SigningKey, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.finds++
+	usable := []entities.SigningKey{}
+	for _, key := range r.keys {
+		if key.ExpiresAt == nil || key.ExpiresAt.After(now) {
+			usable = append(usable, key)
+		}
+	}
+	sort.Slice(usable, func(i, j int) bool {
+		return usable[i].CreatedAt.After(usable[j].CreatedAt)
+	})
+	return usable, nil
+}
+
+func (r *fakeSigningKeyRepository) Retire(ctx context.Context, id string, retiredAt, expiresAt time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key, ok := r.keys[id]
+	if !ok || key.RetiredAt != nil {
+		return errors.New("signing key not found")
+	}
+	key.RetiredAt = &retiredAt
+	key.ExpiresAt = &expiresAt
+	r.keys[id] = key
+	return nil
+}
+
+func (r *fakeSigningKeyRepository) DeleteExpired(ctx context.Context, now time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for id, key := range r.keys {
+		if key.ExpiresAt != nil && !key.ExpiresAt.After(now) {
+			delete(r.keys, id)
+		}
+	}
+	return nil
+}
+
+func (r *fakeSigningKeyRepository) UpdatePrivateKey(ctx context.Context, id, oldPrivateKey, newPrivateKey string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key, ok := r.keys[id]
+	if !ok || key.PrivateKey != oldPrivateKey {
+		return errors.New("signing key not found")
+	}
+	key.PrivateKey = newPrivateKey
+	r.keys[id] = key
+	return nil
+}
+
+func newTestKeyManager(t *testing.T, repo entities.SigningKeyRepository, algorithm, encryptionKey string) *KeyManager {
+	manager, err := NewKeyManager(repo, algorithm, 24*time.Hour, RefreshTokenTTL, encryptionKey)
+	require.NoError(t, err)
+	require.NoError(t, manager.Load(context.Background()))
+	return manager
+}
+
+func TestNewKeyManager(t *testing.T) {
+	tests := []struct {
+		name             string
+		algorithm        string
+		rotationInterval time.Duration
+		gracePeriod      time.Duration
+		encryptionKey    string
+	}{
+		{"should reject an unknown algorithm", "HS256", 24 * time.Hour, RefreshTokenTTL, "key"},
+		{"should reject a zero rotation interval", AlgorithmEdDSA, 0, RefreshTokenTTL, "key"},
+		{"should reject a negative rotation interval", AlgorithmEdDSA, -time.Hour, RefreshTokenTTL, "key"},
+		{"should reject a rotation interval below a minute", AlgorithmEdDSA, time.Second, RefreshTokenTTL, "key"},
+		{"should reject a grace period shorter than refresh tokens live", AlgorithmEdDSA, 24 * time.Hour, RefreshTokenTTL - time.Hour, "key"},
+		{"should require an encryption key", AlgorithmEdDSA, 24 * time.Hour, RefreshTokenTTL, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewKeyManager(newFakeSigningKeyRepository(), tt.algorithm, tt.rotationInterval, tt.gracePeriod, tt.encryptionKey)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestKeyManager_Load(t *testing.T) {
+	t.Run("should create an encrypted active key when there is none", func(t *testing.T) {
+		repo := newFakeSigningKeyRepository()
+		manager := newTestKeyManager(t, repo, AlgorithmEdDSA, "key")
+
+		kid, method, _, err := manager.SigningKey()
+		require.NoError(t, err)
+		assert.Equal(t, jwt.SigningMethodEdDSA, method)
+
+		stored := repo.keys[kid]
+		assert.False(t, strings.HasPrefix(stored.PrivateKey, "-----BEGIN"))
+		assert.True(t, strings.HasPrefix(stored.PublicKey, "-----BEGIN PUBLIC KEY"))
+	})
+
+	t.Run("should reuse the active key of another instance", func(t *testing.T) {
+		repo := newFakeSigningKeyRepository()
+		first := newTestKeyManager(t, repo, AlgorithmEdDSA, "key")
+		second := newTestKeyManager(t, repo, AlgorithmEdDSA, "key")
+
+		firstKid, _, _, err := first.SigningKey()
+		require.NoError(t, err)
+		secondKid, _, _, err := second.SigningKey()
+		require.NoError(t, err)
+		assert.Equal(t, firstKid, secondKid)
+		assert.Len(t, repo.keys, 1)
+	})
+
+	t.Run("should encrypt a key stored in plain text", func(t *testing.T) {
+		repo := newFakeSigningKeyRepository()
+		legacy, err := generateSigningKey(AlgorithmEdDSA)
+		require.NoError(t, err)
+		require.NoError(t, repo.Create(context.Background(), legacy))
+
+		manager := newTestKeyManager(t, repo, AlgorithmEdDSA, "key")
+
+		kid, _, _, err := manager.SigningKey()
+		require.NoError(t, err)
+		assert.Equal(t, legacy.ID, kid)
+		assert.False(t, strings.HasPrefix(repo.keys[kid].PrivateKey, "-----BEGIN"))
+	})
+
+	t.Run("should replace keys it cannot decrypt", func(t *testing.T) {
+		repo := newFakeSigningKeyRepository()
+		first := newTestKeyManager(t, repo, AlgorithmEdDSA, "key")
+		oldKid, _, _, err := first.SigningKey()
+		require.NoError(t, err)
+
+		second := newTestKeyManager(t, repo, AlgorithmEdDSA, "another-key")
+		newKid, _, _, err := second.SigningKey()
+		require.NoError(t, err)
+		assert.NotEqual(t, oldKid, newKid)
+	})
+}
+
+func TestKeyManager_Rotate(t *testing.T) {
+	repo := newFakeSigningKeyRepository()
+	manager := newTestKeyManager(t, repo, AlgorithmEdDSA, "key")
+	jwtService := NewJWTService(manager)
+
+	oldKid, _, _, err := manager.SigningKey()
+	require.NoError(t, err)
+	accessToken, _, err := jwtService.GenerateTokens("user-1", "user", "session-1")
+	require.NoError(t, err)
+
+	require.NoError(t, manager.Rotate(context.Background()))
+
+	newKid, _, _, err := manager.SigningKey()
+	require.NoError(t, err)
+
+	t.Run("should sign with the new key", func(t *testing.T) {
+		assert.NotEqual(t, oldKid, newKid)
+
+		token, _, err := jwtService.GenerateTokens("user-1", "user", "session-1")
+		require.NoError(t, err)
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+		require.NoError(t, err)
+		assert.Equal(t, newKid, parsed.Header["kid"])
+	})
+
+	t.Run("should keep verifying tokens of the retired key", func(t *testing.T) {
+		claims, err := jwtService.ValidateToken(accessToken)
+		require.NoError(t, err)
+		assert.Equal(t, "user-1", claims["sub"])
+	})
+
+	t.Run("should retire the old key for the grace period", func(t *testing.T) {
+		retired := repo.keys[oldKid]
+		require.NotNil(t, retired.RetiredAt)
+		require.NotNil(t, retired.ExpiresAt)
+		assert.WithinDuration(t, time.Now().Add(RefreshTokenTTL), *retired.ExpiresAt, time.Minute)
+	})
+
+	t.Run("should publish both keys", func(t *testing.T) {
+		kids := []string{}
+		for _, key := range manager.JWKS().Keys {
+			kids = append(kids, key.Kid)
+		}
+		assert.ElementsMatch(t, []string{oldKid, newKid}, kids)
+	})
+
+	t.Run("should drop the retired key once it expires", func(t *testing.T) {
+		expired := repo.keys[oldKid]
+		past := time.Now().Add(-time.Minute)
+		expired.ExpiresAt = &past
+		repo.keys[oldKid] = expired
+
+		require.NoError(t, manager.Load(context.Background()))
+
+		_, err := jwtService.ValidateToken(accessToken)
+		assert.Error(t, err)
+		require.Len(t, manager.JWKS().Keys, 1)
+		assert.Equal(t, newKid, manager.JWKS().Keys[0].Kid)
+	})
+}
+
+func TestKeyManager_JWKS(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		kty       string
+		crv       string
+	}{
+		{AlgorithmRS256, "RSA", ""},
+		{AlgorithmEdDSA, "OKP", "Ed25519"},
+	}
+
+	for _, tt := range tests {
+		t.Run("should publish "+tt.algorithm+" keys", func(t *testing.T) {
+			manager := newTestKeyManager(t, newFakeSigningKeyRepository(), tt.algorithm, "key")
+			kid, _, _, err := manager.SigningKey()
+			require.NoError(t, err)
+
+			set := manager.JWKS()
+			require.Len(t, set.Keys, 1)
+			key := set.Keys[0]
+			assert.Equal(t, kid, key.Kid)
+			assert.Equal(t, tt.kty, key.Kty)
+			assert.Equal(t, tt.algorithm, key.Alg)
+			assert.Equal(t, "sig", key.Use)
+			assert.Equal(t, tt.crv, key.Crv)
+			if tt.kty == "RSA" {
+				assert.NotEmpty(t, key.N)
+				assert.Equal(t, "AQAB", key.E)
+			} else {
+				assert.NotEmpty(t, key.X)
+			}
+		})
+	}
+}
+
+func TestJWTService_LegacyTokens(t *testing.T) {
+	manager := newTestKeyManager(t, newFakeSigningKeyRepository(), AlgorithmEdDSA, "key")
+
+	t.Run("should refuse tokens signed with the old HS256 secret", func(t *testing.T) {
+		legacy := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"sub":  "user-1",
+			"role": "user",
+			"exp":  time.Now().Add(time.Hour).Unix(),
+			"jti":  "legacy",
+		})
+		legacyToken, err := legacy.SignedString([]byte("legacy-secret"))
+		require.NoError(t, err)
+
+		_, err = NewJWTService(manager).ValidateToken(legacyToken)
+		assert.Error(t, err)
+	})
+}
+
+func TestKeyManager_MultipleInstances(t *testing.T) {
+	t.Run("should agree on one key when instances start together", func(t *testing.T) {
+		repo := newFakeSigningKeyRepository()
+		managers := make([]*KeyManager, 5)
+		for i := range managers {
+			manager, err := NewKeyManager(repo, AlgorithmEdDSA, 24*time.Hour, RefreshTokenTTL, "key")
+			require.NoError(t, err)
+			managers[i] = manager
+		}
+
+		var wg sync.WaitGroup
+		for _, manager := range managers {
+			wg.Add(1)
+			go func(manager *KeyManager) {
+				defer wg.Done()
+				assert.NoError(t, manager.Load(context.Background()))
+			}(manager)
+		}
+		wg.Wait()
+
+		require.Len(t, repo.keys, 1)
+		for _, manager := range managers {
+			kid, _, _, err := manager.SigningKey()
+			require.NoError(t, err)
+			_, ok := repo.keys[kid]
+			assert.True(t, ok)
+		}
+	})
+
+	t.Run("should store one new key when instances rotate from the same key", func(t *testing.T) {
+		repo := newFakeSigningKeyRepository()
+		first := newTestKeyManager(t, repo, AlgorithmEdDSA, "key")
+		second := newTestKeyManager(t, repo, AlgorithmEdDSA, "key")
+
+		// Both decided to rotate after seeing the same newest key
+		require.NoError(t, first.rotate(context.Background()))
+		require.NoError(t, second.rotate(context.Background()))
+
+		assert.Len(t, repo.keys, 2)
+		firstKid, _, _, err := first.SigningKey()
+		require.NoError(t, err)
+		secondKid, _, _, err := second.SigningKey()
+		require.NoError(t, err)
+		assert.Equal(t, firstKid, secondKid)
+	})
+
+	t.Run("should verify tokens signed with a key another instance rotated to", func(t *testing.T) {
+		repo := newFakeSigningKeyRepository()
+		first := newTestKeyManager(t, repo, AlgorithmEdDSA, "key")
+		second := newTestKeyManager(t, repo, AlgorithmEdDSA, "key")
+
+		require.NoError(t, first.Rotate(context.Background()))
+		accessToken, _, err := NewJWTService(first).GenerateTokens("user-1", "user", "session-1")
+		require.NoError(t, err)
+
+		_, err = NewJWTService(second).ValidateToken(accessToken)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should reload for unknown keys at most once an interval", func(t *testing.T) {
+		repo := newFakeSigningKeyRepository()
+		manager := newTestKeyManager(t, repo, AlgorithmEdDSA, "key")
+		finds := repo.finds
+
+		_, _, err := manager.VerificationKey("unknown-1")
+		assert.Error(t, err)
+		_, _, err = manager.VerificationKey("unknown-2")
+		assert.Error(t, err)
+		assert.Equal(t, finds+1, repo.finds)
+	})
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// LoginAttemptStore counts failed logins per key (an account or an IP address)
+// and records temporary locks on those keys
+type LoginAttemptStore interface {
+	// RecordFailure adds a failure and returns how many were recorded in the
+	// window that started with the first failure
+	RecordFailure(ctx context.Context, key string, window time.Duration) (int, error)
+	// Lock blocks logins for the key until the given time
+	Lock(ctx context.Context, key string, until time.Time) error
+	// LockedUntil returns when the key's lock ends, or the zero time if it is not locked
+	LockedUntil(ctx context.Context, key string) (time.Time, error)
+	// Reset forgets the key's failures and lock
+	Reset(ctx context.Context, key string) error
+}
+
+type loginAttempts struct {
+	failures    int
+	windowEnds  time.Time
+	lockedUntil time.Time
+}
+
+// InMemoryLoginAttemptStore is a LoginAttemptStore for single-instance deployments
+type InMemoryLoginAttemptStore struct {
+	attempts map[string]*loginAttempts
+	mutex    sync.Mutex
+}
+
+// NewInMemoryLoginAttemptStore creates a new in-memory login attempt store
+func NewInMemoryLoginAttemptStore() *InMemoryLoginAttemptStore {
+	return &InMemoryLoginAttemptStore{
+		attempts: make(map[string]*loginAttempts),
+	}
+}
+
+// RecordFailure adds a failure for the key
+func (s *InMemoryLoginAttemptStore) RecordFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	entry, exists := s.attempts[key]
+	if !exists {
+		entry = &loginAttempts{}
+		s.attempts[key] = entry
+	}
+	if !entry.windowEnds.After(now) {
+		entry.failures = 0
+		entry.windowEnds = now.Add(window)
+	}
+	entry.failures++
+	return entry.failures, nil
+}
+
+// Lock blocks logins for the key until the given time
+func (s *InMemoryLoginAttemptStore) Lock(ctx context.Context, key string, until time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry, exists := s.attempts[key]
+	if !exists {
+		entry = &loginAttempts{}
+		s.attempts[key] = entry
+	}
+	entry.lockedUntil = until
+	return nil
+}
+
+// LockedUntil returns when the key's lock ends
+func (s *InMemoryLoginAttemptStore) LockedUntil(ctx context.Context, key string) (time.Time, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry, exists := s.attempts[key]
+	if !exists || !entry.lockedUntil.After(time.Now()) {
+		return time.Time{}, nil
+	}
+	return entry.lockedUntil, nil
+}
+
+// Reset forgets the key's failures and lock
+func (s *InMemoryLoginAttemptStore) Reset(ctx context.Context, key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.attempts, key)
+	return nil
+}
+
+// Cleanup removes keys whose window and lock have both ended
+func (s *InMemoryLoginAttemptStore) Cleanup() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	for key, entry := range s.attempts {
+		if !entry.windowEnds.After(now) && !entry.lockedUntil.After(now) {
+			delete(s.attempts, key)
+		}
+	}
+}
+
+// StartCleanup starts a background cleanup routine
+func (s *InMemoryLoginAttemptStore) StartCleanup() {
+	go func() {
+		ticker := time.NewTicker(5 * time.Minute)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			s.Cleanup()
+		}
+	}()
+}
+
+// RedisLoginAttemptStore is a LoginAttemptStore shared by every API instance
+type RedisLoginAttemptStore struct {
+	client *redis.Client
+}
+
+// NewRedisLoginAttemptStore creates a login attempt store backed by Redis
+func NewRedisLoginAttemptStore(client *redis.Client) *RedisLoginAttemptStore {
+	return &RedisLoginAttemptStore{client: client}
+}
+
+func loginFailuresKey(key string) string {
+	return "login_failures:" + key
+}
+
+func loginLockKey(key string) string {
+	return "login_lock:" + key
+}
+
+// RecordFailure adds a failure for the key; Redis expires the counter with its window
+func (s *RedisLoginAttemptStore) RecordFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	failuresKey := loginFailuresKey(key)
+	count, err := s.client.Incr(ctx, failuresKey).Result()
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		if err := s.client.Expire(ctx, failuresKey, window).Err(); err != nil {
+			return 0, err
+		}
+	}
+	return int(count), nil
+}
+
+// Lock blocks logins for the key until the given time
+func (s *RedisLoginAttemptStore) Lock(ctx context.Context, key string, until time.Time) error {
+	ttl := time.Until(until)
+	if ttl <= 0 {
+		return nil
+	}
+	return s.client.Set(ctx, loginLockKey(key), until.Unix(), ttl).Err()
+}
+
+// LockedUntil returns when the key's lock ends
+func (s *RedisLoginAttemptStore) LockedUntil(ctx context.Context, key string) (time.Time, error) {
+	value, err := s.client.Get(ctx, loginLockKey(key)).Result()
+	if err == redis.Nil {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	unix, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(unix, 0), nil
+}
+
+// Reset forgets the key's failures and lock
+func (s *RedisLoginAttemptStore) Reset(ctx context.Context, key string) error {
+	return s.client.Del(ctx, loginFailuresKey(key), loginLockKey(key)).Err()
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password hashing algorithms
+const (
+	PasswordHashBcrypt   = "bcrypt"
+	PasswordHashArgon2id = "argon2id"
+)
+
+// PasswordHasher hashes and checks user passwords. Hashes are self-describing:
+// the algorithm and its parameters are stored in the hash itself.
+type PasswordHasher interface {
+	HashPassword(password string) (string, error)
+	ComparePassword(hashedPassword, password string) error
+	// NeedsRehash reports whether a hash was made with another algorithm or
+	// outdated parameters and should be replaced at the next successful login
+	NeedsRehash(hashedPassword string) bool
+}
+
+// PasswordHasherConfig selects the algorithm used for new hashes and its parameters
+type PasswordHasherConfig struct {
+	Algorithm  string
+	BcryptCost int
+	Argon2id   Argon2idParams
+}
+
+// MultiPasswordHasher hashes new passwords with the configured algorithm and
+// checks hashes made by any supported one, so switching algorithms does not
+// lock out existing users
+type MultiPasswordHasher struct {
+	preferred string
+	bcrypt    *BcryptService
+	argon2id  *Argon2idService
+}
+
+// NewPasswordHasher creates the password hasher for a configuration
+func NewPasswordHasher(config PasswordHasherConfig) (*MultiPasswordHasher, error) {
+	if config.Algorithm != PasswordHashBcrypt && config.Algorithm != PasswordHashArgon2id {
+		return nil, fmt.Errorf("unsupported password hash algorithm: %s", config.Algorithm)
+	}
+
+	if config.BcryptCost < bcrypt.MinCost || config.BcryptCost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	argon2idService, err := NewArgon2idService(config.Argon2id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MultiPasswordHasher{
+		preferred: config.Algorithm,
+		bcrypt:    NewBcryptService(config.BcryptCost),
+		argon2id:  argon2idService,
+	}, nil
+}
+
+// HashPassword hashes a password with the configured algorithm
+func (h *MultiPasswordHasher) HashPassword(password string) (string, error) {
+	if h.preferred == PasswordHashArgon2id {
+		return h.argon2id.HashPassword(password)
+	}
+	return h.bcrypt.HashPassword(password)
+}
+
+// ComparePassword checks a password against a hash made by any supported algorithm
+func (h *MultiPasswordHasher) ComparePassword(hashedPassword, password string) error {
+	switch passwordHashAlgorithm(hashedPassword) {
+	case PasswordHashArgon2id:
+		return h.argon2id.ComparePassword(hashedPassword, password)
+	case PasswordHashBcrypt:
+		return h.bcrypt.ComparePassword(hashedPassword, password)
+	default:
+		return errors.New("unknown password hash format")
+	}
+}
+
+// NeedsRehash reports whether a hash does not match the configured algorithm and parameters
+func (h *MultiPasswordHasher) NeedsRehash(hashedPassword string) bool {
+	if passwordHashAlgorithm(hashedPassword) != h.preferred {
+		return true
+	}
+	if h.preferred == PasswordHashArgon2id {
+		return h.argon2id.NeedsRehash(hashedPassword)
+	}
+	return h.bcrypt.NeedsRehash(hashedPassword)
+}
+
+// passwordHashAlgorithm identifies the algorithm from a hash's prefix
+func passwordHashAlgorithm(hashedPassword string) string {
+	switch {
+	case strings.HasPrefix(hashedPassword, "$argon2id$"):
+		return PasswordHashArgon2id
+	case strings.HasPrefix(hashedPassword, "$2a$"), strings.HasPrefix(hashedPassword, "$2b$"), strings.HasPrefix(hashedPassword, "$2y$"):
+		return PasswordHashBcrypt
+	default:
+		return ""
+	}
+}
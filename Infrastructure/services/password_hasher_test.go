@@ -0,0 +1,190 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2idParams keep the tests fast; production uses DefaultArgon2idParams
+var testArgon2idParams = Argon2idParams{
+	Memory:      64,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func newTestPasswordHasher(t *testing.T, algorithm string) *MultiPasswordHasher {
+	hasher, err := NewPasswordHasher(PasswordHasherConfig{
+		Algorithm:  algorithm,
+		BcryptCost: bcrypt.MinCost,
+		Argon2id:   testArgon2idParams,
+	})
+	require.NoError(t, err)
+	return hasher
+}
+
+func TestArgon2idService_HashPassword(t *testing.T) {
+	service, err := NewArgon2idService(testArgon2idParams)
+	require.NoError(t, err)
+
+	t.Run("should encode the parameters in PHC format", func(t *testing.T) {
+		hash, err := service.HashPassword("Correct-Horse-1")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$"))
+
+		params, salt, key, err := decodeArgon2idHash(hash)
+		assert.NoError(t, err)
+		assert.Equal(t, testArgon2idParams, params)
+		assert.Len(t, salt, 16)
+		assert.Len(t, key, 32)
+	})
+
+	t.Run("should salt every hash", func(t *testing.T) {
+		first, err := service.HashPassword("Correct-Horse-1")
+		require.NoError(t, err)
+		second, err := service.HashPassword("Correct-Horse-1")
+		require.NoError(t, err)
+		assert.NotEqual(t, first, second)
+	})
+
+	t.Run("should match only the hashed password", func(t *testing.T) {
+		hash, err := service.HashPassword("Correct-Horse-1")
+		require.NoError(t, err)
+
+		assert.NoError(t, service.ComparePassword(hash, "Correct-Horse-1"))
+		assert.ErrorIs(t, service.ComparePassword(hash, "Correct-Horse-2"), ErrPasswordMismatch)
+	})
+}
+
+func TestArgon2idService_DecodeHash(t *testing.T) {
+	tests := []struct {
+		name string
+		hash string
+	}{
+		{"should reject another algorithm", "$argon2i$v=19$m=64,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"},
+		{"should reject another version", "$argon2id$v=16$m=64,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"},
+		{"should reject missing parameters", "$argon2id$v=19$m=64$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"},
+		{"should reject an invalid salt", "$argon2id$v=19$m=64,t=1,p=1$!!!$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U"},
+		{"should reject an invalid key", "$argon2id$v=19$m=64,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$!!!"},
+		{"should reject too few fields", "$argon2id$v=19$m=64,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := decodeArgon2idHash(tt.hash)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNewArgon2idService(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(params *Argon2idParams)
+	}{
+		{"should reject zero iterations", func(params *Argon2idParams) { params.Iterations = 0 }},
+		{"should reject zero parallelism", func(params *Argon2idParams) { params.Parallelism = 0 }},
+		{"should reject too little memory per thread", func(params *Argon2idParams) { params.Parallelism = 16 }},
+		{"should reject a short salt", func(params *Argon2idParams) { params.SaltLength = 4 }},
+		{"should reject a short key", func(params *Argon2idParams) { params.KeyLength = 8 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := testArgon2idParams
+			tt.change(&params)
+
+			_, err := NewArgon2idService(params)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMultiPasswordHasher_NeedsRehash(t *testing.T) {
+	argon2idHasher := newTestPasswordHasher(t, PasswordHashArgon2id)
+	bcryptHasher := newTestPasswordHasher(t, PasswordHashBcrypt)
+
+	argon2idHash, err := argon2idHasher.HashPassword("Correct-Horse-1")
+	require.NoError(t, err)
+	bcryptHash, err := bcryptHasher.HashPassword("Correct-Horse-1")
+	require.NoError(t, err)
+
+	stronger := testArgon2idParams
+	stronger.Iterations = 2
+	strongerHasher, err := NewPasswordHasher(PasswordHasherConfig{
+		Algorithm:  PasswordHashArgon2id,
+		BcryptCost: bcrypt.MinCost,
+		Argon2id:   stronger,
+	})
+	require.NoError(t, err)
+
+	costlierBcrypt, err := NewPasswordHasher(PasswordHasherConfig{
+		Algorithm:  PasswordHashBcrypt,
+		BcryptCost: bcrypt.MinCost + 1,
+		Argon2id:   testArgon2idParams,
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		hasher *MultiPasswordHasher
+		hash   string
+		rehash bool
+	}{
+		{"should keep a current argon2id hash", argon2idHasher, argon2idHash, false},
+		{"should rehash bcrypt when argon2id is configured", argon2idHasher, bcryptHash, true},
+		{"should rehash argon2id made with older parameters", strongerHasher, argon2idHash, true},
+		{"should keep a current bcrypt hash", bcryptHasher, bcryptHash, false},
+		{"should rehash argon2id when bcrypt is configured", bcryptHasher, argon2idHash, true},
+		{"should rehash bcrypt made with another cost", costlierBcrypt, bcryptHash, true},
+		{"should rehash an unknown format", argon2idHasher, "plaintext", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.rehash, tt.hasher.NeedsRehash(tt.hash))
+		})
+	}
+}
+
+func TestMultiPasswordHasher_ComparePassword(t *testing.T) {
+	hasher := newTestPasswordHasher(t, PasswordHashArgon2id)
+	bcryptHash, err := newTestPasswordHasher(t, PasswordHashBcrypt).HashPassword("Correct-Horse-1")
+	require.NoError(t, err)
+
+	t.Run("should check hashes made with either algorithm", func(t *testing.T) {
+		argon2idHash, err := hasher.HashPassword("Correct-Horse-1")
+		require.NoError(t, err)
+
+		assert.NoError(t, hasher.ComparePassword(argon2idHash, "Correct-Horse-1"))
+		assert.NoError(t, hasher.ComparePassword(bcryptHash, "Correct-Horse-1"))
+		assert.Error(t, hasher.ComparePassword(bcryptHash, "Correct-Horse-2"))
+	})
+
+	t.Run("should reject an unknown hash format", func(t *testing.T) {
+		assert.Error(t, hasher.ComparePassword("plaintext", "plaintext"))
+	})
+}
+
+func TestNewPasswordHasher(t *testing.T) {
+	tests := []struct {
+		name   string
+		config PasswordHasherConfig
+	}{
+		{"should reject an unknown algorithm", PasswordHasherConfig{Algorithm: "md5", BcryptCost: bcrypt.MinCost, Argon2id: testArgon2idParams}},
+		{"should reject a bcrypt cost that is too low", PasswordHasherConfig{Algorithm: PasswordHashBcrypt, BcryptCost: bcrypt.MinCost - 1, Argon2id: testArgon2idParams}},
+		{"should reject a bcrypt cost that is too high", PasswordHasherConfig{Algorithm: PasswordHashBcrypt, BcryptCost: bcrypt.MaxCost + 1, Argon2id: testArgon2idParams}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPasswordHasher(tt.config)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Password policy violation codes
+const (
+	PasswordTooShort         = "too_short"
+	PasswordTooLong          = "too_long"
+	PasswordMissingLowercase = "missing_lowercase"
+	PasswordMissingUppercase = "missing_uppercase"
+	PasswordMissingDigit     = "missing_digit"
+	PasswordMissingSymbol    = "missing_symbol"
+	PasswordBreached         = "breached"
+	PasswordPersonalInfo     = "contains_personal_info"
+)
+
+// minPersonalInfoLength keeps short names and username fragments from rejecting
+// unrelated passwords
+const minPersonalInfoLength = 3
+
+// PasswordViolation is one rule a password breaks
+type PasswordViolation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError lists every rule a password breaks
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.Message
+	}
+	return "password does not meet the requirements: " + strings.Join(messages, "; ")
+}
+
+// PasswordPolicyConfig configures the password rules. Lengths count characters,
+// not bytes, and any script counts towards the letter classes. MaxBytes also
+// caps the length in bytes, for hashers with a byte limit; zero means no cap.
+type PasswordPolicyConfig struct {
+	MinLength        int
+	MaxLength        int
+	MaxBytes         int
+	RequireLowercase bool
+	RequireUppercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+}
+
+// PasswordContext is what is known about the user a password is for. Passwords
+// containing any of it are rejected.
+type PasswordContext struct {
+	Email    string
+	Username string
+	FullName string
+}
+
+// PasswordPolicy checks new passwords. It never logs the passwords it checks.
+type PasswordPolicy struct {
+	config   PasswordPolicyConfig
+	breached map[string]bool
+}
+
+// NewPasswordPolicy creates a password policy without a breached-password list
+func NewPasswordPolicy(config PasswordPolicyConfig) (*PasswordPolicy, error) {
+	if config.MinLength < 1 {
+		return nil, errors.New("minimum password length must be at least 1")
+	}
+	if config.MaxLength < config.MinLength {
+		return nil, errors.New("maximum password length must not be below the minimum")
+	}
+	if config.MaxBytes < 0 || (config.MaxBytes > 0 && config.MaxBytes < config.MinLength) {
+		return nil, errors.New("maximum password size in bytes must not be below the minimum length")
+	}
+	return &PasswordPolicy{config: config, breached: map[string]bool{}}, nil
+}
+
+// LoadBreachedPasswords reads a breached-password list with one password per
+// line. Entries are matched case-insensitively.
+func (p *PasswordPolicy) LoadBreachedPasswords(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	breached := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" {
+			breached[strings.ToLower(line)] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read breached password list: %v", err)
+	}
+
+	p.breached = breached
+	return nil
+}
+
+// Check returns a *PasswordPolicyError listing every rule the password breaks,
+// or nil if it is acceptable
+func (p *PasswordPolicy) Check(password string, user PasswordContext) error {
+	var violations []PasswordViolation
+	add := func(code, message string) {
+		violations = append(violations, PasswordViolation{Code: code, Message: message})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < p.config.MinLength {
+		add(PasswordTooShort, fmt.Sprintf("password must be at least %d characters", p.config.MinLength))
+	}
+	if length > p.config.MaxLength {
+		add(PasswordTooLong, fmt.Sprintf("password must be at most %d characters", p.config.MaxLength))
+	} else if p.config.MaxBytes > 0 && len(password) > p.config.MaxBytes {
+		// Characters outside ASCII take several bytes each
+		add(PasswordTooLong, fmt.Sprintf("password must be at most %d bytes; accented and non-Latin characters count as more than one", p.config.MaxBytes))
+	}
+
+	var hasLower, hasUpper, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsLetter(r):
+			// Letters of scripts without case count as both
+			hasLower = true
+			hasUpper = true
+		default:
+			hasSymbol = true
+		}
+	}
+	if p.config.RequireLowercase && !hasLower {
+		add(PasswordMissingLowercase, "password must contain a lowercase letter")
+	}
+	if p.config.RequireUppercase && !hasUpper {
+		add(PasswordMissingUppercase, "password must contain an uppercase letter")
+	}
+	if p.config.RequireDigit && !hasDigit {
+		add(PasswordMissingDigit, "password must contain a number")
+	}
+	if p.config.RequireSymbol && !hasSymbol {
+		add(PasswordMissingSymbol, "password must contain a symbol or space")
+	}
+
+	if p.breached[strings.ToLower(password)] {
+		add(PasswordBreached, "password appears in a list of breached passwords")
+	}
+
+	if containsPersonalInfo(password, user) {
+		add(PasswordPersonalInfo, "password must not contain your email, username or name")
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+func containsPersonalInfo(password string, user PasswordContext) bool {
+	lowered := strings.ToLower(password)
+
+	parts := []string{user.Username}
+	if email := strings.ToLower(user.Email); email != "" {
+		parts = append(parts, email, strings.Split(email, "@")[0])
+	}
+	parts = append(parts, strings.Fields(user.FullName)...)
+
+	for _, part := range parts {
+		part = strings.ToLower(part)
+		if utf8.RuneCountInString(part) >= minPersonalInfoLength && strings.Contains(lowered, part) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPasswordPolicyConfig is the default policy of the server
+var testPasswordPolicyConfig = PasswordPolicyConfig{
+	MinLength:        8,
+	MaxLength:        64,
+	RequireLowercase: true,
+	RequireUppercase: true,
+	RequireDigit:     true,
+	RequireSymbol:    true,
+}
+
+// violationCodes returns the codes of the rules err reports as broken
+func violationCodes(t *testing.T, err error) []string {
+	if err == nil {
+		return nil
+	}
+
+	var policyErr *PasswordPolicyError
+	require.True(t, errors.As(err, &policyErr))
+	codes := make([]string, len(policyErr.Violations))
+	for i, violation := range policyErr.Violations {
+		codes[i] = violation.Code
+	}
+	return codes
+}
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	policy, err := NewPasswordPolicy(testPasswordPolicyConfig)
+	require.NoError(t, err)
+
+	user := PasswordContext{Email: "jane.doe@example.com", Username: "janed", FullName: "Jane Doe"}
+
+	tests := []struct {
+		name     string
+		password string
+		want     []string
+	}{
+		{"should accept a password following every rule", "Correct-Horse-1", nil},
+		{"should count symbols and spaces alike", "Correct Horse 1", nil},
+		{"should count letters of caseless scripts as both cases", "パスワード-1234", nil},
+		{"should reject a short password", "Ab-1", []string{PasswordTooShort}},
+		{"should count characters rather than bytes", "Äbc-1234", nil},
+		{"should reject a long password", "Aa-1" + strings.Repeat("x", 61), []string{PasswordTooLong}},
+		{"should require a lowercase letter", "CORRECT-HORSE-1", []string{PasswordMissingLowercase}},
+		{"should require an uppercase letter", "correct-horse-1", []string{PasswordMissingUppercase}},
+		{"should require a number", "Correct-Horse-X", []string{PasswordMissingDigit}},
+		{"should require a symbol", "CorrectHorse1", []string{PasswordMissingSymbol}},
+		{"should reject the username", "Janed-Horse-1", []string{PasswordPersonalInfo}},
+		{"should reject the email's local part", "Jane.Doe-Horse-1", []string{PasswordPersonalInfo}},
+		{"should reject part of the name", "Horse-Doe-1-X", []string{PasswordPersonalInfo}},
+		{"should list every broken rule", "abc", []string{PasswordTooShort, PasswordMissingUppercase, PasswordMissingDigit, PasswordMissingSymbol}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, violationCodes(t, policy.Check(tt.password, user)))
+		})
+	}
+}
+
+func TestPasswordPolicy_CheckPersonalInfo(t *testing.T) {
+	policy, err := NewPasswordPolicy(testPasswordPolicyConfig)
+	require.NoError(t, err)
+
+	t.Run("should ignore parts shorter than three characters", func(t *testing.T) {
+		user := PasswordContext{Username: "jo", FullName: "Al Li"}
+		assert.NoError(t, policy.Check("Jo-Al-Li-123x", user))
+	})
+
+	t.Run("should not check anything without a user", func(t *testing.T) {
+		assert.NoError(t, policy.Check("Correct-Horse-1", PasswordContext{}))
+	})
+}
+
+func TestPasswordPolicy_CheckKeepsSecrets(t *testing.T) {
+	policy, err := NewPasswordPolicy(testPasswordPolicyConfig)
+	require.NoError(t, err)
+
+	t.Run("should not repeat the password in the error", func(t *testing.T) {
+		err := policy.Check("hunter2", PasswordContext{})
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "hunter2")
+	})
+}
+
+func TestPasswordPolicy_CheckMaxBytes(t *testing.T) {
+	config := testPasswordPolicyConfig
+	config.MaxLength = 100
+	config.MaxBytes = BcryptMaxPasswordBytes
+	policy, err := NewPasswordPolicy(config)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		password string
+		want     []string
+	}{
+		{"should accept 72 ASCII bytes", "Aa-1" + strings.Repeat("x", 68), nil},
+		{"should reject 73 ASCII bytes", "Aa-1" + strings.Repeat("x", 69), []string{PasswordTooLong}},
+		{"should reject fewer characters that take over 72 bytes", "Aa-1" + strings.Repeat("ä", 40), []string{PasswordTooLong}},
+		{"should report a password over both limits once", "Aa-1" + strings.Repeat("ä", 100), []string{PasswordTooLong}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, violationCodes(t, policy.Check(tt.password, PasswordContext{})))
+		})
+	}
+}
+
+func TestNewPasswordPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		config PasswordPolicyConfig
+	}{
+		{"should reject a minimum below one", PasswordPolicyConfig{MinLength: 0, MaxLength: 64}},
+		{"should reject a maximum below the minimum", PasswordPolicyConfig{MinLength: 12, MaxLength: 8}},
+		{"should reject a byte cap below the minimum", PasswordPolicyConfig{MinLength: 80, MaxLength: 100, MaxBytes: BcryptMaxPasswordBytes}},
+		{"should reject a negative byte cap", PasswordPolicyConfig{MinLength: 8, MaxLength: 64, MaxBytes: -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewPasswordPolicy(tt.config)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPasswordPolicy_LoadBreachedPasswords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(path, []byte("Password-123\n\n  Summer-2024!  \n"), 0o600))
+
+	policy, err := NewPasswordPolicy(testPasswordPolicyConfig)
+	require.NoError(t, err)
+	require.NoError(t, policy.LoadBreachedPasswords(path))
+
+	tests := []struct {
+		name     string
+		password string
+		want     []string
+	}{
+		{"should reject a listed password", "Password-123", []string{PasswordBreached}},
+		{"should match the list case-insensitively", "PASSWORD-123", []string{PasswordMissingLowercase, PasswordBreached}},
+		{"should trim the list's entries", "Summer-2024!", []string{PasswordBreached}},
+		{"should accept an unlisted password", "Correct-Horse-1", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, violationCodes(t, policy.Check(tt.password, PasswordContext{})))
+		})
+	}
+
+	t.Run("should fail for a missing file", func(t *testing.T) {
+		assert.Error(t, policy.LoadBreachedPasswords(filepath.Join(t.TempDir(), "missing.txt")))
+	})
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+// secretBox encrypts secrets for storage with AES-256-GCM. The result is the
+// random nonce followed by the ciphertext, base64 encoded.
+type secretBox struct {
+	aead cipher.AEAD
+}
+
+// newSecretBox creates a secret box. The key can be any string; it is hashed to
+// a 256-bit AES key.
+func newSecretBox(key string) (*secretBox, error) {
+	hashed := sha256.Sum256([]byte(key))
+	block, err := aes.NewCipher(hashed[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &secretBox{aead: aead}, nil
+}
+
+// seal encrypts a secret
+func (b *secretBox) seal(plain []byte) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := b.aead.Seal(nonce, nonce, plain, nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open decrypts a secret made by seal
+func (b *secretBox) open(encrypted string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < b.aead.NonceSize() {
+		return nil, errors.New("invalid encrypted secret")
+	}
+	nonce, ciphertext := data[:b.aead.NonceSize()], data[b.aead.NonceSize():]
+	plain, err := b.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errors.New("failed to decrypt secret")
+	}
+	return plain, nil
+}
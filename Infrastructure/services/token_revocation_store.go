@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// TokenRevocationStore keeps the IDs ("jti") of access tokens that were revoked
+// before they expired. Entries only need to live until the token's own expiry.
+type TokenRevocationStore interface {
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// InMemoryRevocationStore is a TokenRevocationStore for single-instance deployments
+type InMemoryRevocationStore struct {
+	revoked map[string]time.Time
+	mutex   sync.RWMutex
+}
+
+// NewInMemoryRevocationStore creates a new in-memory revocation store
+func NewInMemoryRevocationStore() *InMemoryRevocationStore {
+	return &InMemoryRevocationStore{
+		revoked: make(map[string]time.Time),
+	}
+}
+
+// Revoke marks a token ID as revoked until expiresAt
+func (s *InMemoryRevocationStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	if jti == "" || !expiresAt.After(time.Now()) {
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.revoked[jti] = expiresAt
+	return nil
+}
+
+// IsRevoked checks if a token ID has been revoked
+func (s *InMemoryRevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	expiresAt, exists := s.revoked[jti]
+	return exists && expiresAt.After(time.Now()), nil
+}
+
+// Cleanup removes entries whose tokens have expired anyway
+func (s *InMemoryRevocationStore) Cleanup() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	for jti, expiresAt := range s.revoked {
+		if !expiresAt.After(now) {
+			delete(s.revoked, jti)
+		}
+	}
+}
+
+// StartCleanup starts a background cleanup routine
+func (s *InMemoryRevocationStore) StartCleanup() {
+	go func() {
+		ticker := time.NewTicker(5 * time.Minute)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			s.Cleanup()
+		}
+	}()
+}
+
+// RedisRevocationStore is a TokenRevocationStore shared by every API instance
+type RedisRevocationStore struct {
+	client *redis.Client
+}
+
+// NewRedisRevocationStore creates a revocation store backed by Redis
+func NewRedisRevocationStore(client *redis.Client) *RedisRevocationStore {
+	return &RedisRevocationStore{client: client}
+}
+
+func revokedTokenKey(jti string) string {
+	return "revoked_jti:" + jti
+}
+
+// Revoke marks a token ID as revoked; Redis expires the key with the token
+func (s *RedisRevocationStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if jti == "" || ttl <= 0 {
+		return nil
+	}
+	return s.client.Set(ctx, revokedTokenKey(jti), 1, ttl).Err()
+}
+
+// IsRevoked checks if a token ID has been revoked
+func (s *RedisRevocationStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	count, err := s.client.Exists(ctx, revokedTokenKey(jti)).Result()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfc6238Secret is the SHA-1 key of the RFC 6238 test vectors, "12345678901234567890"
+var rfc6238Secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func newTestTOTPService(t *testing.T) *TOTPService {
+	service, err := NewTOTPService("Blog", "test-encryption-key")
+	require.NoError(t, err)
+	return service
+}
+
+func TestTOTPService_VerifyRFC6238Vectors(t *testing.T) {
+	service := newTestTOTPService(t)
+
+	// RFC 6238 appendix B lists 8-digit codes; 6-digit codes are their last six digits
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			now := time.Unix(tt.unix, 0)
+
+			step, ok := service.Verify(rfc6238Secret, tt.code, now)
+			assert.True(t, ok)
+			assert.Equal(t, tt.unix/totpPeriod, step)
+		})
+	}
+}
+
+func TestTOTPService_Verify(t *testing.T) {
+	service := newTestTOTPService(t)
+	now := time.Unix(1111111111, 0)
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		at     time.Time
+		valid  bool
+	}{
+		{"should accept the current code", rfc6238Secret, "050471", now, true},
+		{"should accept a lowercase secret", strings.ToLower(rfc6238Secret), "050471", now, true},
+		{"should accept the code of the previous step", rfc6238Secret, "050471", now.Add(totpPeriod * time.Second), true},
+		{"should accept the code of the next step", rfc6238Secret, "050471", now.Add(-totpPeriod * time.Second), true},
+		{"should reject a code two steps old", rfc6238Secret, "050471", now.Add(2 * totpPeriod * time.Second), false},
+		{"should reject a wrong code", rfc6238Secret, "123456", now, false},
+		{"should reject a code of the wrong length", rfc6238Secret, "50471", now, false},
+		{"should reject an invalid secret", "not base32!", "050471", now, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok := service.Verify(tt.secret, tt.code, tt.at)
+			assert.Equal(t, tt.valid, ok)
+		})
+	}
+}
+
+func TestTOTPService_EncryptSecret(t *testing.T) {
+	service := newTestTOTPService(t)
+
+	t.Run("should decrypt what it encrypted", func(t *testing.T) {
+		encrypted, err := service.EncryptSecret(rfc6238Secret)
+		require.NoError(t, err)
+		assert.NotContains(t, encrypted, rfc6238Secret)
+
+		decrypted, err := service.DecryptSecret(encrypted)
+		assert.NoError(t, err)
+		assert.Equal(t, rfc6238Secret, decrypted)
+	})
+
+	t.Run("should not decrypt with another key", func(t *testing.T) {
+		encrypted, err := service.EncryptSecret(rfc6238Secret)
+		require.NoError(t, err)
+
+		other, err := NewTOTPService("Blog", "another-key")
+		require.NoError(t, err)
+		_, err = other.DecryptSecret(encrypted)
+		assert.Error(t, err)
+	})
+}
+
+func TestTOTPService_RecoveryCodes(t *testing.T) {
+	service := newTestTOTPService(t)
+
+	t.Run("should generate distinct codes", func(t *testing.T) {
+		codes, err := service.GenerateRecoveryCodes(10)
+		require.NoError(t, err)
+		assert.Len(t, codes, 10)
+
+		seen := map[string]bool{}
+		for _, code := range codes {
+			assert.Regexp(t, `^[0-9a-f]{5}-[0-9a-f]{5}$`, code)
+			assert.False(t, seen[code])
+			seen[code] = true
+		}
+	})
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"abcde-12345", "abcde12345"},
+		{"ABCDE-12345", "abcde12345"},
+		{" abcde 12345 ", "abcde12345"},
+		{"abcde12345", "abcde12345"},
+	}
+	for _, tt := range tests {
+		t.Run("should normalize "+tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.want, NormalizeRecoveryCode(tt.input))
+		})
+	}
+}
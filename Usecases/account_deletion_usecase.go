@@ -0,0 +1,272 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+	"g6_starter_project/Infrastructure/mongodb/repositories"
+	"g6_starter_project/Infrastructure/services"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// accountPurgeBatchSize limits how many accounts one purge run deletes
+const accountPurgeBatchSize = 100
+
+// AccountDeletionUsecase lets users delete their own account. A deletion waits
+// out a grace period, during which the user can sign in and cancel it, before
+// the purge removes the account and everything the user owned.
+type AccountDeletionUsecase struct {
+	userRepo             entities.UserRepository
+	blogRepo             repositories.IBlogRepository
+	interactionRepo      repositories.IBlogInteractionRepository
+	commentRepo          repositories.ICommentRepository
+	chatRepo             entities.ChatRepository
+	dataExportRepo       entities.DataExportRepository
+	tokenUsecase         *TokenUsecase
+	personalAccessTokens *PersonalAccessTokenUsecase
+	oneTimeTokens        *OneTimeTokenUsecase
+	mfaUsecase           *MFAUsecase
+	passwordHasher       services.PasswordHasher
+	emailService         *services.EmailService
+	gracePeriod          time.Duration
+	securityEvents       *SecurityEventUsecase
+}
+
+// NewAccountDeletionUsecase initializes the account deletion usecase
+func NewAccountDeletionUsecase(userRepo entities.UserRepository, blogRepo repositories.IBlogRepository, interactionRepo repositories.IBlogInteractionRepository, commentRepo repositories.ICommentRepository, chatRepo entities.ChatRepository, dataExportRepo entities.DataExportRepository, tokenUsecase *TokenUsecase, personalAccessTokens *PersonalAccessTokenUsecase, oneTimeTokens *OneTimeTokenUsecase, mfaUsecase *MFAUsecase, passwordHasher services.PasswordHasher, emailService *services.EmailService, gracePeriod time.Duration, securityEvents *SecurityEventUsecase) *AccountDeletionUsecase {
+	return &AccountDeletionUsecase{
+		userRepo:             userRepo,
+		blogRepo:             blogRepo,
+		interactionRepo:      interactionRepo,
+		commentRepo:          commentRepo,
+		chatRepo:             chatRepo,
+		dataExportRepo:       dataExportRepo,
+		tokenUsecase:         tokenUsecase,
+		personalAccessTokens: personalAccessTokens,
+		oneTimeTokens:        oneTimeTokens,
+		mfaUsecase:           mfaUsecase,
+		passwordHasher:       passwordHasher,
+		emailService:         emailService,
+		gracePeriod:          gracePeriod,
+		securityEvents:       securityEvents,
+	}
+}
+
+// RequestDeletion schedules the account for deletion once the grace period
+// ends. The user confirms with their password, or with a current 2FA code when
+// 2FA is enabled. Every session and personal access token is revoked at once.
+func (u *AccountDeletionUsecase) RequestDeletion(userID, password, code string, client entities.ClientInfo) (time.Time, error) {
+	user, err := u.userRepo.GetUserByID(userID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("user not found: %v", err)
+	}
+
+	if user.DeleteAfter != nil {
+		return time.Time{}, errors.New("account deletion is already scheduled")
+	}
+
+	if err := u.confirm(user, password, code, client); err != nil {
+		u.recordDeletion(entities.SecurityEventDeletionRequest, userID, entities.OutcomeFailure, err.Error(), client)
+		return time.Time{}, err
+	}
+
+	deleteAfter := time.Now().Add(u.gracePeriod)
+	if err := u.userRepo.ScheduleDeletion(userID, &deleteAfter); err != nil {
+		return time.Time{}, fmt.Errorf("failed to schedule account deletion: %v", err)
+	}
+	u.recordDeletion(entities.SecurityEventDeletionRequest, userID, entities.OutcomeSuccess, "delete after "+deleteAfter.UTC().Format(time.RFC3339), client)
+
+	if err := u.tokenUsecase.RevokeAllSessions(userID); err != nil {
+		fmt.Printf("Warning: Failed to revoke sessions after deletion request: %v\n", err)
+	}
+	if err := u.personalAccessTokens.RevokeAll(userID); err != nil {
+		fmt.Printf("Warning: Failed to revoke personal access tokens after deletion request: %v\n", err)
+	}
+
+	if err := u.emailService.SendAccountDeletionScheduledEmail(user.Email, user.FullName, deleteAfter); err != nil {
+		fmt.Printf("Warning: Failed to send account deletion notice: %v\n", err)
+	}
+
+	return deleteAfter, nil
+}
+
+// CancelDeletion keeps an account whose deletion is still in its grace period
+func (u *AccountDeletionUsecase) CancelDeletion(userID string, client entities.ClientInfo) error {
+	user, err := u.userRepo.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("user not found: %v", err)
+	}
+
+	if user.DeleteAfter == nil {
+		return errors.New("no account deletion is scheduled")
+	}
+
+	if err := u.userRepo.ScheduleDeletion(userID, nil); err != nil {
+		return fmt.Errorf("failed to cancel account deletion: %v", err)
+	}
+	u.recordDeletion(entities.SecurityEventDeletionCancel, userID, entities.OutcomeSuccess, "", client)
+	return nil
+}
+
+// StartPurge deletes accounts whose grace period has ended, every interval
+func (u *AccountDeletionUsecase) StartPurge(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if _, err := u.PurgeDue(); err != nil {
+				fmt.Printf("Warning: Failed to purge deleted accounts: %v\n", err)
+			}
+		}
+	}()
+}
+
+// PurgeDue deletes the accounts whose grace period has ended and returns how
+// many were deleted. An account that fails is retried on the next run.
+func (u *AccountDeletionUsecase) PurgeDue() (int, error) {
+	now := time.Now()
+	users, err := u.userRepo.GetUsersDueForDeletion(now, accountPurgeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for i := range users {
+		if err := u.purge(&users[i], now); err != nil {
+			fmt.Printf("Warning: Failed to delete account %s: %v\n", users[i].ID.Hex(), err)
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// purge removes everything the user owned, then the user. Every step can run
+// again, so a purge that fails halfway finishes on a later run.
+func (u *AccountDeletionUsecase) purge(user *entities.User, now time.Time) error {
+	ctx := context.Background()
+	userID := user.ID.Hex()
+
+	// The user may have cancelled since the batch was loaded
+	current, err := u.userRepo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if current.DeleteAfter == nil || current.DeleteAfter.After(now) {
+		return nil
+	}
+
+	// The user's posts go along with every comment and reaction on them
+	postIDs, err := u.blogRepo.FindIDsByAuthor(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to find posts: %v", err)
+	}
+	if err := u.commentRepo.DeleteByBlogs(ctx, postIDs); err != nil {
+		return fmt.Errorf("failed to delete comments on posts: %v", err)
+	}
+	if err := u.interactionRepo.DeleteByBlogs(ctx, postIDs); err != nil {
+		return fmt.Errorf("failed to delete reactions to posts: %v", err)
+	}
+	if err := u.blogRepo.DeleteByAuthor(ctx, user.ID); err != nil {
+		return fmt.Errorf("failed to delete posts: %v", err)
+	}
+
+	// Comments and reactions on other users' posts change their counters
+	commentedIDs, err := u.commentRepo.FindBlogIDsByAuthor(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to find comments: %v", err)
+	}
+	if err := u.commentRepo.DeleteByAuthor(ctx, user.ID); err != nil {
+		return fmt.Errorf("failed to delete comments: %v", err)
+	}
+	u.recountComments(ctx, commentedIDs)
+
+	reactedIDs, err := u.interactionRepo.FindBlogIDsByUser(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to find reactions: %v", err)
+	}
+	if err := u.interactionRepo.DeleteByUser(ctx, user.ID); err != nil {
+		return fmt.Errorf("failed to delete reactions: %v", err)
+	}
+	u.recountReactions(ctx, reactedIDs)
+
+	if err := u.chatRepo.DeleteChatsByUserID(userID); err != nil {
+		return fmt.Errorf("failed to delete chats: %v", err)
+	}
+	if err := u.dataExportRepo.DeleteByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete data exports: %v", err)
+	}
+	if err := u.tokenUsecase.RevokeAllSessions(userID); err != nil {
+		return err
+	}
+	if err := u.personalAccessTokens.RevokeAll(userID); err != nil {
+		return err
+	}
+	if err := u.oneTimeTokens.Revoke(userID); err != nil {
+		return fmt.Errorf("failed to delete one-time tokens: %v", err)
+	}
+
+	if err := u.userRepo.DeleteUser(userID); err != nil {
+		return fmt.Errorf("failed to delete user: %v", err)
+	}
+
+	// The audit log keeps the user's events, which only name the user by ID
+	u.recordDeletion(entities.SecurityEventAccountDeleted, userID, entities.OutcomeSuccess, "", entities.ClientInfo{})
+	return nil
+}
+
+// recountComments recomputes the comment count of posts that lost comments
+func (u *AccountDeletionUsecase) recountComments(ctx context.Context, postIDs []primitive.ObjectID) {
+	for _, postID := range postIDs {
+		count, err := u.commentRepo.CountByBlog(ctx, postID)
+		if err == nil {
+			err = u.blogRepo.SetCommentCount(ctx, postID, count)
+		}
+		if err != nil {
+			fmt.Printf("Warning: Failed to recount comments of post %s: %v\n", postID.Hex(), err)
+		}
+	}
+}
+
+// recountReactions recomputes the likes and dislikes of posts that lost reactions
+func (u *AccountDeletionUsecase) recountReactions(ctx context.Context, postIDs []primitive.ObjectID) {
+	for _, postID := range postIDs {
+		likes, dislikes, _, err := u.interactionRepo.GetPopularityCounts(ctx, postID)
+		if err == nil {
+			err = u.blogRepo.UpdateCounts(ctx, postID, likes, dislikes)
+		}
+		if err != nil {
+			fmt.Printf("Warning: Failed to recount reactions of post %s: %v\n", postID.Hex(), err)
+		}
+	}
+}
+
+// confirm checks the password, or the 2FA code when one is given
+func (u *AccountDeletionUsecase) confirm(user *entities.User, password, code string, client entities.ClientInfo) error {
+	if code != "" {
+		return u.mfaUsecase.Verify(user, code, client)
+	}
+
+	if password == "" {
+		return errors.New("password or verification code is required")
+	}
+	if err := u.passwordHasher.ComparePassword(user.Password, password); err != nil {
+		return errors.New("password is incorrect")
+	}
+	return nil
+}
+
+func (u *AccountDeletionUsecase) recordDeletion(eventType, userID, outcome, details string, client entities.ClientInfo) {
+	u.securityEvents.Record(entities.SecurityEvent{
+		Type:     eventType,
+		Outcome:  outcome,
+		ActorID:  userID,
+		TargetID: userID,
+		Details:  details,
+	}, client)
+}
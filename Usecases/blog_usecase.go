@@ -16,7 +16,7 @@ type IBlogUsecase interface {
 	// CRUD usecases
 	CreatePost(ctx context.Context, blog *entities.Blog, authorID primitive.ObjectID) (*entities.Blog, error)
 	GetPostByID(ctx context.Context, postID string, requestingUserID *primitive.ObjectID) (*entities.Blog, error)
-	UpdatePost(ctx context.Context, postID string, updateData *entities.Blog, requestingUserID primitive.ObjectID) (*entities.Blog, error)
+	UpdatePost(ctx context.Context, postID string, updateData *entities.Blog, requestingUserID primitive.ObjectID, requestingUserRole string) (*entities.Blog, error)
 	DeletePost(ctx context.Context, postID string, requestingUserID primitive.ObjectID, requestingUserRole string) error
 	// filter & Search usecases
 	ListPosts(ctx context.Context, tag, authorName, title, sortBy string, startDate, endDate *time.Time, page, limit int64, minPopularity, maxPopularity *int64) ([]entities.Blog, int64, error)
@@ -25,22 +25,34 @@ type IBlogUsecase interface {
 	DislikePost(ctx context.Context, postID string, userID primitive.ObjectID) error
 }
 
+// HiddenAuthorLister names the users whose posts are left out of post listings,
+// such as suspended users
+type HiddenAuthorLister interface {
+	HiddenAuthorIDs(ctx context.Context) ([]primitive.ObjectID, error)
+}
+
 type blogUsecase struct {
 	blogRepo        repositories.IBlogRepository
 	interactionRepo repositories.IBlogInteractionRepository
 	userRepo        entities.UserRepository
+	permissions     entities.PermissionChecker
+	hiddenAuthors   HiddenAuthorLister
 }
 
 // NewBlogUsecase creates a new blog usecase instance
 func NewBlogUsecase(
 	blogRepo repositories.IBlogRepository,
 	interactionRepo repositories.IBlogInteractionRepository,
-	userRepo entities.UserRepository) IBlogUsecase {
+	userRepo entities.UserRepository,
+	permissions entities.PermissionChecker,
+	hiddenAuthors HiddenAuthorLister) IBlogUsecase {
 
 	return &blogUsecase{
 		blogRepo:        blogRepo,
 		interactionRepo: interactionRepo,
 		userRepo:        userRepo,
+		permissions:     permissions,
+		hiddenAuthors:   hiddenAuthors,
 	}
 }
 
@@ -86,8 +98,8 @@ func (uc *blogUsecase) GetPostByID(ctx context.Context, postID string, userID *p
 	return post, nil
 }
 
-// UpdatePost updates blog content, tags, and timestamps if user is the author
-func (uc *blogUsecase) UpdatePost(ctx context.Context, postID string, updateData *entities.Blog, requestingUserID primitive.ObjectID) (*entities.Blog, error) {
+// UpdatePost updates blog content, tags, and timestamps if user is the author or may edit any post
+func (uc *blogUsecase) UpdatePost(ctx context.Context, postID string, updateData *entities.Blog, requestingUserID primitive.ObjectID, requestingUserRole string) (*entities.Blog, error) {
 	objectID, err := primitive.ObjectIDFromHex(postID)
 	if err != nil {
 		return nil, errors.New("invalid post ID format")
@@ -99,7 +111,13 @@ func (uc *blogUsecase) UpdatePost(ctx context.Context, postID string, updateData
 	}
 
 	if originalPost.AuthorID != requestingUserID {
-		return nil, errors.New("forbidden: you are not the author of this post")
+		canUpdateAny, err := uc.permissions.HasPermission(ctx, requestingUserRole, entities.PermissionPostsUpdateAny)
+		if err != nil {
+			return nil, err
+		}
+		if !canUpdateAny {
+			return nil, errors.New("forbidden: you are not the author of this post")
+		}
 	}
 
 	originalPost.Title = updateData.Title
@@ -114,7 +132,7 @@ func (uc *blogUsecase) UpdatePost(ctx context.Context, postID string, updateData
 	return originalPost, nil
 }
 
-// DeletePost deletes a blog post if the requester is the author or an admin
+// DeletePost deletes a blog post if the requester is the author or may delete any post
 func (uc *blogUsecase) DeletePost(ctx context.Context, postID string, requestingUserID primitive.ObjectID, requestingUserRole string) error {
 	objectID, err := primitive.ObjectIDFromHex(postID)
 	if err != nil {
@@ -126,11 +144,14 @@ func (uc *blogUsecase) DeletePost(ctx context.Context, postID string, requesting
 		return errors.New("post not found")
 	}
 
-	isOwner := postToDelete.AuthorID == requestingUserID
-	isAdmin := requestingUserRole == "admin"
-
-	if !isOwner && !isAdmin {
-		return errors.New("forbidden: you are not authorized to delete this post")
+	if postToDelete.AuthorID != requestingUserID {
+		canDeleteAny, err := uc.permissions.HasPermission(ctx, requestingUserRole, entities.PermissionPostsDeleteAny)
+		if err != nil {
+			return err
+		}
+		if !canDeleteAny {
+			return errors.New("forbidden: you are not authorized to delete this post")
+		}
 	}
 
 	return uc.blogRepo.Delete(ctx, objectID)
@@ -167,17 +188,23 @@ func (uc *blogUsecase) ListPosts(
 		tags = strings.Split(tag, ",")
 	}
 
+	hiddenAuthorIDs, err := uc.hiddenAuthors.HiddenAuthorIDs(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	options := repositories.SearchFilterOptions{
-		AuthorID:      authorID,
-		Tags:          tags,
-		Title:         title,
-		Page:          page,
-		Limit:         limit,
-		StartDate:     startDate,
-		EndDate:       endDate,
-		SortBy:        sortBy,
-		MinPopularity: minPopularity,
-		MaxPopularity: maxPopularity,
+		AuthorID:         authorID,
+		ExcludeAuthorIDs: hiddenAuthorIDs,
+		Tags:             tags,
+		Title:            title,
+		Page:             page,
+		Limit:            limit,
+		StartDate:        startDate,
+		EndDate:          endDate,
+		SortBy:           sortBy,
+		MinPopularity:    minPopularity,
+		MaxPopularity:    maxPopularity,
 	}
 
 	return uc.blogRepo.Find(ctx, options)
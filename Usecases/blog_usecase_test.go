@@ -0,0 +1,131 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+
+	"g6_starter_project/Domain/entities"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Custom roles: a reviewer may delete any post, a manager may manage roles,
+// and neither may do anything else
+const (
+	testRoleReviewer = "reviewer"
+	testRoleManager  = "manager"
+)
+
+// newTestPermissions returns the built-in roles plus the custom test roles
+func newTestPermissions(t *testing.T) *RoleUsecase {
+	roleRepo := newFakeRoleRepository()
+	roles := NewRoleUsecase(roleRepo, nil)
+	require.NoError(t, roles.EnsureDefaultRoles(context.Background()))
+	require.NoError(t, roleRepo.Upsert(context.Background(), &entities.Role{
+		Name:        testRoleReviewer,
+		Permissions: []string{entities.PermissionPostsDeleteAny},
+	}))
+	require.NoError(t, roleRepo.Upsert(context.Background(), &entities.Role{
+		Name:        testRoleManager,
+		Permissions: []string{entities.PermissionUsersManageRoles},
+	}))
+	return roles
+}
+
+func TestBlogUsecase_UpdatePost(t *testing.T) {
+	authorID := primitive.NewObjectID()
+	otherID := primitive.NewObjectID()
+
+	tests := []struct {
+		name      string
+		userID    primitive.ObjectID
+		role      string
+		wantError string
+	}{
+		{"should let the author edit", authorID, entities.RoleUser, ""},
+		{"should let an admin edit any post", otherID, entities.RoleAdmin, ""},
+		{"should let an editor edit any post", otherID, entities.RoleEditor, ""},
+		{"should forbid another user", otherID, entities.RoleUser, "forbidden: you are not the author of this post"},
+		{"should forbid a moderator", otherID, entities.RoleModerator, "forbidden: you are not the author of this post"},
+		{"should forbid a role that may only delete", otherID, testRoleReviewer, "forbidden: you are not the author of this post"},
+		{"should forbid an unknown role", otherID, "ghost", "forbidden: you are not the author of this post"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			post := &entities.Blog{ID: primitive.NewObjectID(), AuthorID: authorID, Title: "Before", Content: "Before"}
+			blogs := newFakeBlogRepository(post)
+			usecase := NewBlogUsecase(blogs, nil, nil, newTestPermissions(t), nil)
+
+			update := &entities.Blog{Title: "After", Content: "After", Tags: []string{"go"}}
+			updated, err := usecase.UpdatePost(context.Background(), post.ID.Hex(), update, tt.userID, tt.role)
+
+			stored, findErr := blogs.FindByID(context.Background(), post.ID)
+			require.NoError(t, findErr)
+			if tt.wantError != "" {
+				assert.EqualError(t, err, tt.wantError)
+				assert.Nil(t, updated)
+				assert.Equal(t, "Before", stored.Title)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "After", updated.Title)
+			assert.Equal(t, "After", stored.Title)
+			assert.Equal(t, authorID, stored.AuthorID)
+		})
+	}
+
+	t.Run("should report a missing post", func(t *testing.T) {
+		usecase := NewBlogUsecase(newFakeBlogRepository(), nil, nil, newTestPermissions(t), nil)
+
+		_, err := usecase.UpdatePost(context.Background(), primitive.NewObjectID().Hex(), &entities.Blog{}, authorID, entities.RoleAdmin)
+		assert.EqualError(t, err, "post not found")
+	})
+}
+
+func TestBlogUsecase_DeletePost(t *testing.T) {
+	authorID := primitive.NewObjectID()
+	otherID := primitive.NewObjectID()
+
+	tests := []struct {
+		name      string
+		userID    primitive.ObjectID
+		role      string
+		wantError string
+	}{
+		{"should let the author delete", authorID, entities.RoleUser, ""},
+		{"should let an admin delete any post", otherID, entities.RoleAdmin, ""},
+		{"should let a moderator delete any post", otherID, entities.RoleModerator, ""},
+		{"should let a custom role with the permission delete", otherID, testRoleReviewer, ""},
+		{"should forbid another user", otherID, entities.RoleUser, "forbidden: you are not authorized to delete this post"},
+		{"should forbid an editor", otherID, entities.RoleEditor, "forbidden: you are not authorized to delete this post"},
+		{"should forbid an unknown role", otherID, "ghost", "forbidden: you are not authorized to delete this post"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			post := &entities.Blog{ID: primitive.NewObjectID(), AuthorID: authorID}
+			blogs := newFakeBlogRepository(post)
+			usecase := NewBlogUsecase(blogs, nil, nil, newTestPermissions(t), nil)
+
+			err := usecase.DeletePost(context.Background(), post.ID.Hex(), tt.userID, tt.role)
+
+			if tt.wantError != "" {
+				assert.EqualError(t, err, tt.wantError)
+				assert.True(t, blogs.has(post.ID))
+				return
+			}
+			require.NoError(t, err)
+			assert.False(t, blogs.has(post.ID))
+		})
+	}
+
+	t.Run("should reject an invalid post ID", func(t *testing.T) {
+		usecase := NewBlogUsecase(newFakeBlogRepository(), nil, nil, newTestPermissions(t), nil)
+
+		err := usecase.DeletePost(context.Background(), "not-an-id", authorID, entities.RoleAdmin)
+		assert.EqualError(t, err, "invalid post ID format")
+	})
+}
@@ -14,6 +14,7 @@ import (
 // This lists all the functions our usecase must have.
 type ICommentUsecase interface {
 	CreateComment(ctx context.Context, blogIDStr string, authorID primitive.ObjectID, content string) (*entities.Comment, error)
+	DeleteComment(ctx context.Context, blogIDStr, commentIDStr string, requestingUserID primitive.ObjectID, requestingUserRole string) error
 }
 
 // 2. DEFINE THE STRUCT (The "Employee" that does the work)
@@ -22,15 +23,17 @@ type ICommentUsecase interface {
 type CommentUsecase struct {
 	commentRepo repositories.ICommentRepository
 	blogRepo    repositories.IBlogRepository
+	permissions entities.PermissionChecker
 }
 
 // 3. DEFINE THE CONSTRUCTOR (The "Hiring" function)
 // This is how we will create a new CommentUsecase from main.go.
 // It takes the dependencies (the repositories) and returns a new usecase.
-func NewCommentUsecase(commentRepo repositories.ICommentRepository, blogRepo repositories.IBlogRepository) ICommentUsecase {
+func NewCommentUsecase(commentRepo repositories.ICommentRepository, blogRepo repositories.IBlogRepository, permissions entities.PermissionChecker) ICommentUsecase {
 	return &CommentUsecase{
 		commentRepo: commentRepo,
 		blogRepo:    blogRepo,
+		permissions: permissions,
 	}
 }
 
@@ -59,3 +62,38 @@ func (uc *CommentUsecase) CreateComment(ctx context.Context, blogIDStr string, a
 
 	return createdComment, nil
 }
+
+// DeleteComment deletes a comment if the requester wrote it or may delete any comment
+func (uc *CommentUsecase) DeleteComment(ctx context.Context, blogIDStr, commentIDStr string, requestingUserID primitive.ObjectID, requestingUserRole string) error {
+	blogID, err := primitive.ObjectIDFromHex(blogIDStr)
+	if err != nil {
+		return errors.New("invalid blog ID format")
+	}
+	commentID, err := primitive.ObjectIDFromHex(commentIDStr)
+	if err != nil {
+		return errors.New("invalid comment ID format")
+	}
+
+	comment, err := uc.commentRepo.FindByID(ctx, commentID)
+	if err != nil || comment.BlogID != blogID {
+		return errors.New("comment not found")
+	}
+
+	if comment.AuthorID != requestingUserID {
+		canDeleteAny, err := uc.permissions.HasPermission(ctx, requestingUserRole, entities.PermissionCommentsDeleteAny)
+		if err != nil {
+			return err
+		}
+		if !canDeleteAny {
+			return errors.New("forbidden: you are not authorized to delete this comment")
+		}
+	}
+
+	if err := uc.commentRepo.Delete(ctx, commentID); err != nil {
+		return err
+	}
+
+	go uc.blogRepo.DecrementCommentCount(context.Background(), blogID)
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+
+	"g6_starter_project/Domain/entities"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCommentUsecase_DeleteComment(t *testing.T) {
+	authorID := primitive.NewObjectID()
+	otherID := primitive.NewObjectID()
+
+	tests := []struct {
+		name      string
+		userID    primitive.ObjectID
+		role      string
+		wantError string
+	}{
+		{"should let the author delete", authorID, entities.RoleUser, ""},
+		{"should let an admin delete any comment", otherID, entities.RoleAdmin, ""},
+		{"should let a moderator delete any comment", otherID, entities.RoleModerator, ""},
+		{"should forbid another user", otherID, entities.RoleUser, "forbidden: you are not authorized to delete this comment"},
+		{"should forbid an editor", otherID, entities.RoleEditor, "forbidden: you are not authorized to delete this comment"},
+		{"should forbid a role that may only delete posts", otherID, testRoleReviewer, "forbidden: you are not authorized to delete this comment"},
+		{"should forbid an unknown role", otherID, "ghost", "forbidden: you are not authorized to delete this comment"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			post := &entities.Blog{ID: primitive.NewObjectID(), CommentCount: 1}
+			comment := &entities.Comment{ID: primitive.NewObjectID(), BlogID: post.ID, AuthorID: authorID}
+			comments := newFakeCommentRepository(comment)
+			usecase := NewCommentUsecase(comments, newFakeBlogRepository(post), newTestPermissions(t))
+
+			err := usecase.DeleteComment(context.Background(), post.ID.Hex(), comment.ID.Hex(), tt.userID, tt.role)
+
+			if tt.wantError != "" {
+				assert.EqualError(t, err, tt.wantError)
+				assert.True(t, comments.has(comment.ID))
+				return
+			}
+			require.NoError(t, err)
+			assert.False(t, comments.has(comment.ID))
+		})
+	}
+
+	t.Run("should not delete a comment through another post", func(t *testing.T) {
+		comment := &entities.Comment{ID: primitive.NewObjectID(), BlogID: primitive.NewObjectID(), AuthorID: authorID}
+		comments := newFakeCommentRepository(comment)
+		usecase := NewCommentUsecase(comments, newFakeBlogRepository(), newTestPermissions(t))
+
+		err := usecase.DeleteComment(context.Background(), primitive.NewObjectID().Hex(), comment.ID.Hex(), authorID, entities.RoleAdmin)
+		assert.EqualError(t, err, "comment not found")
+		assert.True(t, comments.has(comment.ID))
+	})
+}
@@ -0,0 +1,299 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+	"g6_starter_project/Infrastructure/mongodb/repositories"
+	"g6_starter_project/Infrastructure/services"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// dataExportBuildTimeout is how long a pending export blocks new requests
+	// before it is given up, e.g. after a restart interrupted the build
+	dataExportBuildTimeout = time.Hour
+	// dataExportLinkTTL is how long the emailed download link works
+	dataExportLinkTTL = 48 * time.Hour
+	// maxDataExportSize keeps the archive within MongoDB's 16MB document limit
+	maxDataExportSize = 15 << 20
+)
+
+// DataExportUsecase builds archives of everything stored about a user, for
+// users who want a copy of their data. The archive is built in the background
+// and the user is emailed a link to download it.
+type DataExportUsecase struct {
+	exportRepo      entities.DataExportRepository
+	userRepo        entities.UserRepository
+	blogRepo        repositories.IBlogRepository
+	interactionRepo repositories.IBlogInteractionRepository
+	commentRepo     repositories.ICommentRepository
+	chatRepo        entities.ChatRepository
+	emailService    *services.EmailService
+	rateLimiter     *services.RateLimiter
+	tokenHasher     *services.TokenHasher
+	securityEvents  *SecurityEventUsecase
+}
+
+// NewDataExportUsecase initializes the data export usecase
+func NewDataExportUsecase(exportRepo entities.DataExportRepository, userRepo entities.UserRepository, blogRepo repositories.IBlogRepository, interactionRepo repositories.IBlogInteractionRepository, commentRepo repositories.ICommentRepository, chatRepo entities.ChatRepository, emailService *services.EmailService, rateLimiter *services.RateLimiter, tokenHasher *services.TokenHasher, securityEvents *SecurityEventUsecase) *DataExportUsecase {
+	return &DataExportUsecase{
+		exportRepo:      exportRepo,
+		userRepo:        userRepo,
+		blogRepo:        blogRepo,
+		interactionRepo: interactionRepo,
+		commentRepo:     commentRepo,
+		chatRepo:        chatRepo,
+		emailService:    emailService,
+		rateLimiter:     rateLimiter,
+		tokenHasher:     tokenHasher,
+		securityEvents:  securityEvents,
+	}
+}
+
+// RequestExport starts building an export of the user's data. Only one export
+// is built at a time, and a user can request a few exports a day.
+func (u *DataExportUsecase) RequestExport(userID string, client entities.ClientInfo) (*entities.DataExport, error) {
+	ctx := context.Background()
+	now := time.Now()
+
+	if _, err := u.userRepo.GetUserByID(userID); err != nil {
+		return nil, fmt.Errorf("user not found: %v", err)
+	}
+
+	pending, err := u.exportRepo.HasPending(ctx, userID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check pending exports: %v", err)
+	}
+	if pending {
+		return nil, errors.New("an export is already being prepared")
+	}
+
+	// rate limit 3 requests per user a day
+	if !u.rateLimiter.IsAllowed("data_export:"+userID, 3, 24*time.Hour) {
+		return nil, errors.New("too many export requests. please try again later")
+	}
+
+	export := &entities.DataExport{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		Status:    entities.DataExportPending,
+		CreatedAt: now,
+		ExpiresAt: now.Add(dataExportBuildTimeout),
+	}
+	if err := u.exportRepo.Create(ctx, export); err != nil {
+		return nil, fmt.Errorf("failed to create export: %v", err)
+	}
+
+	u.securityEvents.Record(entities.SecurityEvent{
+		Type:     entities.SecurityEventDataExport,
+		Outcome:  entities.OutcomeSuccess,
+		ActorID:  userID,
+		TargetID: userID,
+	}, client)
+
+	go u.build(export)
+
+	return export, nil
+}
+
+// Download returns the ready export a download token belongs to
+func (u *DataExportUsecase) Download(token string) (*entities.DataExport, error) {
+	if token == "" {
+		return nil, errors.New("download token is required")
+	}
+
+	export, err := u.exportRepo.FindByTokenHash(context.Background(), u.tokenHasher.Hash(token), time.Now())
+	if err != nil {
+		return nil, errors.New("invalid or expired download link")
+	}
+	return export, nil
+}
+
+// build collects the user's data into an archive and emails the download link.
+// A failed build is kept, marked failed, until it expires.
+func (u *DataExportUsecase) build(export *entities.DataExport) {
+	ctx := context.Background()
+
+	user, archive, err := u.collect(ctx, export)
+	if err == nil && len(archive) > maxDataExportSize {
+		err = errors.New("export is too large")
+	}
+	if err != nil {
+		fmt.Printf("Warning: Failed to build data export %s: %v\n", export.ID, err)
+		if err := u.exportRepo.Fail(ctx, export.ID, err.Error(), time.Now()); err != nil {
+			fmt.Printf("Warning: Failed to mark data export %s as failed: %v\n", export.ID, err)
+		}
+		return
+	}
+
+	token, err := services.RandomURLToken()
+	if err != nil {
+		fmt.Printf("Warning: Failed to generate data export token: %v\n", err)
+		u.exportRepo.Fail(ctx, export.ID, "failed to generate download token", time.Now())
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(dataExportLinkTTL)
+	if err := u.exportRepo.Complete(ctx, export.ID, u.tokenHasher.Hash(token), archive, now, expiresAt); err != nil {
+		fmt.Printf("Warning: Failed to store data export %s: %v\n", export.ID, err)
+		return
+	}
+
+	if err := u.emailService.SendDataExportReadyEmail(user.Email, user.FullName, token, expiresAt); err != nil {
+		fmt.Printf("Warning: Failed to send data export email: %v\n", err)
+	}
+}
+
+// collect loads everything stored about the user and writes it to a zip
+// archive, as one JSON file per kind of data and a readable Markdown summary
+func (u *DataExportUsecase) collect(ctx context.Context, export *entities.DataExport) (*entities.User, []byte, error) {
+	user, err := u.userRepo.GetUserByID(export.UserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("user not found: %v", err)
+	}
+	user.Password = ""
+
+	posts, err := u.blogRepo.FindByAuthor(ctx, user.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load posts: %v", err)
+	}
+	comments, err := u.commentRepo.FindByAuthor(ctx, user.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load comments: %v", err)
+	}
+	reactions, err := u.interactionRepo.FindByUser(ctx, user.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load reactions: %v", err)
+	}
+	chats, err := u.chatRepo.GetChatsByUserID(export.UserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load AI chats: %v", err)
+	}
+	events, err := u.securityEvents.AllForUser(export.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	archive := services.NewExportArchive(export.CreatedAt)
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", user},
+		{"posts.json", posts},
+		{"comments.json", comments},
+		{"reactions.json", reactions},
+		{"ai_chats.json", chats},
+		{"security_events.json", events},
+	}
+	for _, file := range files {
+		if err := archive.AddJSON(file.name, file.data); err != nil {
+			return nil, nil, fmt.Errorf("failed to write %s: %v", file.name, err)
+		}
+	}
+
+	summary := renderDataExport(export.CreatedAt, user, posts, comments, reactions, chats, events)
+	if err := archive.AddFile("export.md", []byte(summary)); err != nil {
+		return nil, nil, fmt.Errorf("failed to write export.md: %v", err)
+	}
+
+	content, err := archive.Bytes()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to write archive: %v", err)
+	}
+	return user, content, nil
+}
+
+// renderDataExport writes the Markdown summary of an export
+func renderDataExport(createdAt time.Time, user *entities.User, posts []entities.Blog, comments []entities.Comment, reactions []entities.BlogInteraction, chats []entities.Chat, events []entities.SecurityEvent) string {
+	var md strings.Builder
+
+	fmt.Fprintf(&md, "# Data export for %s\n\n", user.Username)
+	fmt.Fprintf(&md, "Created %s. The JSON files in this archive hold the same data in full.\n\n", formatExportTime(createdAt))
+
+	md.WriteString("## Profile\n\n")
+	fmt.Fprintf(&md, "- **Full name:** %s\n", user.FullName)
+	fmt.Fprintf(&md, "- **Username:** %s\n", user.Username)
+	fmt.Fprintf(&md, "- **Email:** %s\n", user.Email)
+	fmt.Fprintf(&md, "- **Role:** %s\n", user.Role)
+	if user.Bio != nil {
+		fmt.Fprintf(&md, "- **Bio:** %s\n", *user.Bio)
+	}
+	if user.ContactInfo != nil && user.ContactInfo.Phone != nil {
+		fmt.Fprintf(&md, "- **Phone:** %s\n", *user.ContactInfo.Phone)
+	}
+	if user.ContactInfo != nil && user.ContactInfo.Address != nil {
+		fmt.Fprintf(&md, "- **Address:** %s\n", *user.ContactInfo.Address)
+	}
+	for _, identity := range user.Identities {
+		fmt.Fprintf(&md, "- **Linked %s account:** %s\n", identity.Provider, identity.Email)
+	}
+	fmt.Fprintf(&md, "- **Joined:** %s\n\n", formatExportTime(user.CreatedAt))
+
+	fmt.Fprintf(&md, "## Posts (%d)\n\n", len(posts))
+	for _, post := range posts {
+		fmt.Fprintf(&md, "### %s\n\n", post.Title)
+		fmt.Fprintf(&md, "_Published %s", formatExportTime(post.CreatedAt))
+		if len(post.Tags) > 0 {
+			fmt.Fprintf(&md, " · Tags: %s", strings.Join(post.Tags, ", "))
+		}
+		md.WriteString("_\n\n")
+		fmt.Fprintf(&md, "%s\n\n", post.Content)
+	}
+
+	fmt.Fprintf(&md, "## Comments (%d)\n\n", len(comments))
+	for _, comment := range comments {
+		fmt.Fprintf(&md, "- %s on post `%s`: %s\n", formatExportTime(comment.CreatedAt), comment.BlogID.Hex(), comment.Content)
+	}
+	if len(comments) > 0 {
+		md.WriteString("\n")
+	}
+
+	fmt.Fprintf(&md, "## Reactions (%d)\n\n", len(reactions))
+	for _, reaction := range reactions {
+		kind := "viewed"
+		if reaction.Reaction != nil {
+			kind = *reaction.Reaction
+		}
+		fmt.Fprintf(&md, "- Post `%s`: %s\n", reaction.BlogID.Hex(), kind)
+	}
+	if len(reactions) > 0 {
+		md.WriteString("\n")
+	}
+
+	fmt.Fprintf(&md, "## AI chats (%d)\n\n", len(chats))
+	for _, chat := range chats {
+		fmt.Fprintf(&md, "### %s\n\n", formatExportTime(chat.CreatedAt))
+		fmt.Fprintf(&md, "**You:** %s\n\n", chat.Request)
+		fmt.Fprintf(&md, "**Assistant:** %s\n\n", chat.Response)
+	}
+
+	fmt.Fprintf(&md, "## Security events (%d)\n\n", len(events))
+	if len(events) > 0 {
+		md.WriteString("| Time | Event | Outcome | IP address | Details |\n")
+		md.WriteString("| --- | --- | --- | --- | --- |\n")
+		for _, event := range events {
+			fmt.Fprintf(&md, "| %s | %s | %s | %s | %s |\n",
+				formatExportTime(event.CreatedAt), event.Type, event.Outcome, event.IPAddress, markdownTableCell(event.Details))
+		}
+	}
+
+	return md.String()
+}
+
+func formatExportTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04 MST")
+}
+
+// markdownTableCell keeps a value from breaking out of its table cell
+func markdownTableCell(value string) string {
+	value = strings.ReplaceAll(value, "|", "\\|")
+	return strings.Join(strings.Fields(value), " ")
+}
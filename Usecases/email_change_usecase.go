@@ -0,0 +1,169 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+	"g6_starter_project/Infrastructure/services"
+	"g6_starter_project/Infrastructure/utils"
+)
+
+// emailChangeTTL is how long a requested email change waits for confirmation
+const emailChangeTTL = 24 * time.Hour
+
+// EmailChangeUsecase moves an account to a new email address. The new address
+// must confirm the change, and the old one is told and can cancel it.
+type EmailChangeUsecase struct {
+	userRepo       entities.UserRepository
+	emailService   *services.EmailService
+	rateLimiter    *services.RateLimiter
+	tokenUsecase   *TokenUsecase
+	oneTimeTokens  *OneTimeTokenUsecase
+	passwordHasher services.PasswordHasher
+}
+
+// NewEmailChangeUsecase initializes the email change usecase
+func NewEmailChangeUsecase(userRepo entities.UserRepository, emailService *services.EmailService, rateLimiter *services.RateLimiter, tokenUsecase *TokenUsecase, oneTimeTokens *OneTimeTokenUsecase, passwordHasher services.PasswordHasher) *EmailChangeUsecase {
+	return &EmailChangeUsecase{
+		userRepo:       userRepo,
+		emailService:   emailService,
+		rateLimiter:    rateLimiter,
+		tokenUsecase:   tokenUsecase,
+		oneTimeTokens:  oneTimeTokens,
+		passwordHasher: passwordHasher,
+	}
+}
+
+// RequestChange starts a change to newEmail after checking the user's password.
+// A new request replaces any pending one.
+func (u *EmailChangeUsecase) RequestChange(userID, password, newEmail string) (*entities.PendingEmailChange, error) {
+	newEmail = strings.ToLower(strings.TrimSpace(newEmail))
+	if !utils.IsValidEmail(newEmail) {
+		return nil, errors.New("invalid email format")
+	}
+
+	user, err := u.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %v", err)
+	}
+
+	if err := u.passwordHasher.ComparePassword(user.Password, password); err != nil {
+		return nil, errors.New("password is incorrect")
+	}
+
+	if newEmail == strings.ToLower(user.Email) {
+		return nil, errors.New("new email is the same as the current email")
+	}
+
+	// rate limit 3 requests per user an hour
+	if !u.rateLimiter.IsAllowed("email_change:"+userID, 3, time.Hour) {
+		return nil, errors.New("too many email change requests. please wait before trying again")
+	}
+
+	if _, err := u.userRepo.GetUserByEmail(newEmail); err == nil {
+		return nil, errors.New("email already exists")
+	}
+
+	// Issuing new tokens invalidates the links of any earlier request
+	confirmToken, err := u.oneTimeTokens.Issue(entities.TokenPurposeChangeEmail, userID, newEmail, "", emailChangeTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate confirmation token: %v", err)
+	}
+	cancelToken, err := u.oneTimeTokens.Issue(entities.TokenPurposeCancelEmailChange, userID, user.Email, "", emailChangeTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate cancellation token: %v", err)
+	}
+
+	now := time.Now()
+	pending := &entities.PendingEmailChange{
+		NewEmail:    newEmail,
+		ExpiresAt:   now.Add(emailChangeTTL),
+		RequestedAt: now,
+	}
+	if err := u.userRepo.SetPendingEmail(userID, pending); err != nil {
+		return nil, fmt.Errorf("failed to store email change: %v", err)
+	}
+
+	if err := u.emailService.SendEmailChangeConfirmation(newEmail, user.FullName, confirmToken); err != nil {
+		return nil, fmt.Errorf("failed to send confirmation email: %v", err)
+	}
+	if err := u.emailService.SendEmailChangeRequestedNotice(user.Email, user.FullName, newEmail, cancelToken); err != nil {
+		fmt.Printf("Warning: Failed to send email change notice: %v\n", err)
+	}
+
+	return pending, nil
+}
+
+// ConfirmChange switches the account to the pending address. Every session is
+// signed out, since the account's login changed.
+func (u *EmailChangeUsecase) ConfirmChange(token string) error {
+	confirmation, err := u.oneTimeTokens.Consume(entities.TokenPurposeChangeEmail, token, "")
+	if err != nil {
+		return errors.New("invalid or expired confirmation token")
+	}
+
+	user, err := u.userRepo.GetUserByID(confirmation.UserID)
+	if err != nil {
+		return errors.New("invalid or expired confirmation token")
+	}
+	if user.PendingEmail == nil || user.PendingEmail.NewEmail != confirmation.Email {
+		return errors.New("invalid or expired confirmation token")
+	}
+
+	// The unique email index makes this fail if the address was taken meanwhile
+	if err := u.userRepo.UpdateEmail(user.ID.Hex(), confirmation.Email); err != nil {
+		return err
+	}
+
+	// Links already sent to the old address must not work any more, whether
+	// they cancel the change, reset the password, sign in or verify the account
+	if err := u.oneTimeTokens.Revoke(user.ID.Hex(),
+		entities.TokenPurposeCancelEmailChange,
+		entities.TokenPurposeResetPassword,
+		entities.TokenPurposeMagicLink,
+		entities.TokenPurposeVerifyEmail,
+	); err != nil {
+		fmt.Printf("Warning: Failed to revoke one-time tokens after email change: %v\n", err)
+	}
+
+	if err := u.tokenUsecase.RevokeAllSessions(user.ID.Hex()); err != nil {
+		fmt.Printf("Warning: Failed to revoke sessions after email change: %v\n", err)
+	}
+
+	if err := u.emailService.SendEmailChangedNotification(user.Email, user.FullName, confirmation.Email); err != nil {
+		fmt.Printf("Warning: Failed to send email changed notification: %v\n", err)
+	}
+
+	return nil
+}
+
+// CancelChange drops a pending change using the link sent to the old address
+func (u *EmailChangeUsecase) CancelChange(token string) error {
+	cancellation, err := u.oneTimeTokens.Consume(entities.TokenPurposeCancelEmailChange, token, "")
+	if err != nil {
+		return errors.New("invalid cancellation token")
+	}
+	return u.cancel(cancellation.UserID)
+}
+
+// CancelOwnChange drops the signed-in user's pending change
+func (u *EmailChangeUsecase) CancelOwnChange(userID string) error {
+	user, err := u.userRepo.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("user not found: %v", err)
+	}
+	if user.PendingEmail == nil {
+		return errors.New("no pending email change")
+	}
+	return u.cancel(userID)
+}
+
+func (u *EmailChangeUsecase) cancel(userID string) error {
+	if err := u.oneTimeTokens.Revoke(userID, entities.TokenPurposeChangeEmail, entities.TokenPurposeCancelEmailChange); err != nil {
+		return fmt.Errorf("failed to cancel email change: %v", err)
+	}
+	return u.userRepo.SetPendingEmail(userID, nil)
+}
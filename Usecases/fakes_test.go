@@ -0,0 +1,518 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+	"g6_starter_project/Infrastructure/mongodb/repositories"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// The fakes below keep what the usecases under test need in memory. Each embeds
+// its repository interface, so calling a method a test does not expect panics.
+
+type fakeUserRepository struct {
+	entities.UserRepository
+	mutex sync.Mutex
+	users map[string]*entities.User
+}
+
+func newFakeUserRepository(users ...*entities.User) *fakeUserRepository {
+	repo := &fakeUserRepository{users: map[string]*entities.User{}}
+	for _, user := range users {
+		repo.users[user.ID.Hex()] = user
+	}
+	return repo
+}
+
+func (r *fakeUserRepository) GetUserByID(id string) (*entities.User, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *fakeUserRepository) GetUserByEmail(email string) (*entities.User, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+func (r *fakeUserRepository) GetUserByUsername(username string) (*entities.User, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, user := range r.users {
+		if user.Username == username {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+func (r *fakeUserRepository) GetUserByIdentity(provider, subject string) (*entities.User, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, user := range r.users {
+		for _, identity := range user.Identities {
+			if identity.Provider == provider && identity.Subject == subject {
+				copied := *user
+				return &copied, nil
+			}
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+func (r *fakeUserRepository) CreateUser(user *entities.User) (*entities.User, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	user.ID = primitive.NewObjectID()
+	copied := *user
+	r.users[user.ID.Hex()] = &copied
+	return user, nil
+}
+
+func (r *fakeUserRepository) AddIdentity(userID string, identity entities.ExternalIdentity) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return errors.New("user not found")
+	}
+	user.Identities = append(user.Identities, identity)
+	return nil
+}
+
+func (r *fakeUserRepository) UpdatePassword(userID, hash string, history []string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return errors.New("user not found")
+	}
+	user.Password = hash
+	user.PasswordHistory = history
+	return nil
+}
+
+func (r *fakeUserRepository) UpdateVerificationStatus(userID string, isVerified bool) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return errors.New("user not found")
+	}
+	user.IsVerified = isVerified
+	return nil
+}
+
+func (r *fakeUserRepository) SetRole(userID, role string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return errors.New("user not found")
+	}
+	user.Role = role
+	return nil
+}
+
+func (r *fakeUserRepository) CountUsersByRole(role string) (int64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var count int64
+	for _, user := range r.users {
+		if user.Role == role {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeUserRepository) UpdateMFA(userID string, mfa *entities.MFASettings) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.users[userID].MFA = mfa
+	return nil
+}
+
+func (r *fakeUserRepository) UseMFAStep(userID string, step int64) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	mfa := r.users[userID].MFA
+	if mfa == nil || mfa.LastUsedStep >= step {
+		return false, nil
+	}
+	mfa.LastUsedStep = step
+	return true, nil
+}
+
+func (r *fakeUserRepository) UseMFARecoveryCode(userID string, codeHash string) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	mfa := r.users[userID].MFA
+	if mfa == nil {
+		return false, nil
+	}
+	for i, hash := range mfa.RecoveryCodes {
+		if hash == codeHash {
+			mfa.RecoveryCodes = append(mfa.RecoveryCodes[:i], mfa.RecoveryCodes[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type fakeSecurityEventRepository struct {
+	entities.SecurityEventRepository
+	mutex  sync.Mutex
+	events []entities.SecurityEvent
+}
+
+func (r *fakeSecurityEventRepository) Create(ctx context.Context, event *entities.SecurityEvent) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.events = append(r.events, *event)
+	return nil
+}
+
+// count returns how many events have the type, outcome and details
+func (r *fakeSecurityEventRepository) count(eventType, outcome, details string) int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	count := 0
+	for _, event := range r.events {
+		if event.Type == eventType && event.Outcome == outcome && event.Details == details {
+			count++
+		}
+	}
+	return count
+}
+
+type fakeRoleRepository struct {
+	entities.RoleRepository
+	mutex sync.Mutex
+	roles map[string]entities.Role
+}
+
+func newFakeRoleRepository() *fakeRoleRepository {
+	return &fakeRoleRepository{roles: map[string]entities.Role{}}
+}
+
+func (r *fakeRoleRepository) GetAll(ctx context.Context) ([]entities.Role, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	roles := make([]entities.Role, 0, len(r.roles))
+	for _, role := range r.roles {
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+func (r *fakeRoleRepository) Upsert(ctx context.Context, role *entities.Role) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.roles[role.Name] = *role
+	return nil
+}
+
+func (r *fakeRoleRepository) CreateIfMissing(ctx context.Context, role *entities.Role) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.roles[role.Name]; !ok {
+		r.roles[role.Name] = *role
+	}
+	return nil
+}
+
+type fakeBlogRepository struct {
+	repositories.IBlogRepository
+	mutex sync.Mutex
+	posts map[primitive.ObjectID]*entities.Blog
+}
+
+func newFakeBlogRepository(posts ...*entities.Blog) *fakeBlogRepository {
+	repo := &fakeBlogRepository{posts: map[primitive.ObjectID]*entities.Blog{}}
+	for _, post := range posts {
+		repo.posts[post.ID] = post
+	}
+	return repo
+}
+
+func (r *fakeBlogRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*entities.Blog, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	post, ok := r.posts[id]
+	if !ok {
+		return nil, errors.New("blog not found")
+	}
+	copied := *post
+	return &copied, nil
+}
+
+func (r *fakeBlogRepository) Update(ctx context.Context, blog *entities.Blog) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	copied := *blog
+	r.posts[blog.ID] = &copied
+	return nil
+}
+
+func (r *fakeBlogRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.posts, id)
+	return nil
+}
+
+func (r *fakeBlogRepository) DecrementCommentCount(ctx context.Context, blogID primitive.ObjectID) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if post, ok := r.posts[blogID]; ok {
+		post.CommentCount--
+	}
+	return nil
+}
+
+// has reports whether the post is still stored
+func (r *fakeBlogRepository) has(id primitive.ObjectID) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	_, ok := r.posts[id]
+	return ok
+}
+
+type fakeCommentRepository struct {
+	repositories.ICommentRepository
+	mutex    sync.Mutex
+	comments map[primitive.ObjectID]*entities.Comment
+}
+
+func newFakeCommentRepository(comments ...*entities.Comment) *fakeCommentRepository {
+	repo := &fakeCommentRepository{comments: map[primitive.ObjectID]*entities.Comment{}}
+	for _, comment := range comments {
+		repo.comments[comment.ID] = comment
+	}
+	return repo
+}
+
+func (r *fakeCommentRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*entities.Comment, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	comment, ok := r.comments[id]
+	if !ok {
+		return nil, errors.New("comment not found")
+	}
+	copied := *comment
+	return &copied, nil
+}
+
+func (r *fakeCommentRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.comments, id)
+	return nil
+}
+
+// has reports whether the comment is still stored
+func (r *fakeCommentRepository) has(id primitive.ObjectID) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	_, ok := r.comments[id]
+	return ok
+}
+
+type fakeTokenRepository struct {
+	entities.TokenRepository
+	mutex  sync.Mutex
+	tokens map[string]entities.Token
+}
+
+func newFakeTokenRepository() *fakeTokenRepository {
+	return &fakeTokenRepository{tokens: map[string]entities.Token{}}
+}
+
+func (r *fakeTokenRepository) Create(ctx context.Context, token *entities.Token) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.tokens[token.ID] = *token
+	return nil
+}
+
+func (r *fakeTokenRepository) FindByID(ctx context.Context, id string) (*entities.Token, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	token, ok := r.tokens[id]
+	if !ok {
+		return nil, errors.New("token not found")
+	}
+	return &token, nil
+}
+
+func (r *fakeTokenRepository) Rotate(ctx context.Context, id, currentRefreshTokenHash string, rotated *entities.Token) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	token, ok := r.tokens[id]
+	if !ok || token.RefreshTokenHash != currentRefreshTokenHash {
+		return false, nil
+	}
+	r.tokens[id] = *rotated
+	return true, nil
+}
+
+func (r *fakeTokenRepository) DeleteByID(ctx context.Context, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.tokens, id)
+	return nil
+}
+
+// has reports whether the token family is still stored
+func (r *fakeTokenRepository) has(id string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	_, ok := r.tokens[id]
+	return ok
+}
+
+type fakeSigningKeyRepository struct {
+	entities.SigningKeyRepository
+	mutex sync.Mutex
+	keys  []entities.SigningKey
+}
+
+func (r *fakeSigningKeyRepository) Create(ctx context.Context, key *entities.SigningKey) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.keys = append(r.keys, *key)
+	return nil
+}
+
+func (r *fakeSigningKeyRepository) FindUsable(ctx context.Context, now time.Time) ([]entities.SigningKey, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	usable := append([]entities.SigningKey{}, r.keys...)
+	sort.Slice(usable, func(i, j int) bool {
+		return usable[i].CreatedAt.After(usable[j].CreatedAt)
+	})
+	return usable, nil
+}
+
+func (r *fakeSigningKeyRepository) DeleteExpired(ctx context.Context, now time.Time) error {
+	return nil
+}
+
+type fakeOIDCStateRepository struct {
+	entities.OIDCStateRepository
+	mutex  sync.Mutex
+	states map[string]entities.OIDCState
+}
+
+func newFakeOIDCStateRepository() *fakeOIDCStateRepository {
+	return &fakeOIDCStateRepository{states: map[string]entities.OIDCState{}}
+}
+
+func (r *fakeOIDCStateRepository) Create(ctx context.Context, state *entities.OIDCState) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.states[state.State] = *state
+	return nil
+}
+
+func (r *fakeOIDCStateRepository) Consume(ctx context.Context, state string, now time.Time) (*entities.OIDCState, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	pending, ok := r.states[state]
+	if !ok || !pending.ExpiresAt.After(now) {
+		return nil, errors.New("state not found")
+	}
+	delete(r.states, state)
+	return &pending, nil
+}
+
+type fakeOneTimeTokenRepository struct {
+	entities.OneTimeTokenRepository
+	mutex   sync.Mutex
+	revoked map[string][]string
+}
+
+func newFakeOneTimeTokenRepository() *fakeOneTimeTokenRepository {
+	return &fakeOneTimeTokenRepository{revoked: map[string][]string{}}
+}
+
+func (r *fakeOneTimeTokenRepository) DeleteByUser(ctx context.Context, userID string, purposes ...string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.revoked[userID] = append(r.revoked[userID], purposes...)
+	return nil
+}
+
+// revokedPurposes returns the purposes of the user's tokens that were revoked
+func (r *fakeOneTimeTokenRepository) revokedPurposes(userID string) []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]string{}, r.revoked[userID]...)
+}
+
+// fakeMFAPolicyRepository has no policies, so no role requires MFA
+type fakeMFAPolicyRepository struct {
+	entities.MFAPolicyRepository
+}
+
+func (r *fakeMFAPolicyRepository) GetByRole(ctx context.Context, role string) (*entities.MFAPolicy, error) {
+	return nil, nil
+}
@@ -0,0 +1,126 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+	"g6_starter_project/Infrastructure/services"
+	"g6_starter_project/Infrastructure/utils"
+)
+
+// MagicLinkTTL is how long an emailed sign-in link stays valid
+const MagicLinkTTL = 10 * time.Minute
+
+// MagicLinkUsecase signs users in with single-use links sent to their email
+type MagicLinkUsecase struct {
+	userRepo       entities.UserRepository
+	emailService   *services.EmailService
+	rateLimiter    *services.RateLimiter
+	tokenUsecase   *TokenUsecase
+	mfaUsecase     *MFAUsecase
+	oneTimeTokens  *OneTimeTokenUsecase
+	securityEvents *SecurityEventUsecase
+}
+
+// NewMagicLinkUsecase initializes the magic link usecase
+func NewMagicLinkUsecase(userRepo entities.UserRepository, emailService *services.EmailService, rateLimiter *services.RateLimiter, tokenUsecase *TokenUsecase, mfaUsecase *MFAUsecase, oneTimeTokens *OneTimeTokenUsecase, securityEvents *SecurityEventUsecase) *MagicLinkUsecase {
+	return &MagicLinkUsecase{
+		userRepo:       userRepo,
+		emailService:   emailService,
+		rateLimiter:    rateLimiter,
+		tokenUsecase:   tokenUsecase,
+		mfaUsecase:     mfaUsecase,
+		oneTimeTokens:  oneTimeTokens,
+		securityEvents: securityEvents,
+	}
+}
+
+// RequestLink emails a sign-in link and returns the nonce the requesting browser
+// must present when the link is followed. A nonce is returned whether or not the
+// account exists, so the response does not reveal which emails are registered.
+func (u *MagicLinkUsecase) RequestLink(email, ipAddress string) (string, error) {
+	if !utils.IsValidEmail(email) {
+		return "", errors.New("invalid email format")
+	}
+	email = strings.ToLower(email)
+
+	// rate limit 3 links per email and 10 per IP address an hour
+	if !u.rateLimiter.IsAllowed("magic_link:"+email, 3, time.Hour) ||
+		(ipAddress != "" && !u.rateLimiter.IsAllowed("magic_link_ip:"+ipAddress, 10, time.Hour)) {
+		return "", errors.New("too many sign-in link requests. please wait before trying again")
+	}
+
+	nonce, err := services.RandomURLToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %v", err)
+	}
+
+	user, err := u.userRepo.GetUserByEmail(email)
+	if err != nil || !user.IsVerified {
+		// Unverified accounts sign in with their password once verified
+		return nonce, nil
+	}
+
+	// The link only works together with the nonce, so it is bound to this browser
+	token, err := u.oneTimeTokens.Issue(entities.TokenPurposeMagicLink, user.ID.Hex(), user.Email, nonce, MagicLinkTTL)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate sign-in link: %v", err)
+	}
+
+	if err := u.emailService.SendMagicLinkEmail(user.Email, user.FullName, token); err != nil {
+		return "", fmt.Errorf("failed to send sign-in link: %v", err)
+	}
+
+	return nonce, nil
+}
+
+// CompleteLogin signs in with a link token and the nonce from the browser that
+// requested it. Like Login, it returns an MFA challenge instead of tokens when a
+// second factor is needed.
+func (u *MagicLinkUsecase) CompleteLogin(token, nonce string, client entities.ClientInfo) (*entities.User, *entities.Token, *MFAChallenge, error) {
+	// An unbound link does not exist, so a missing nonce must not match one
+	if nonce == "" {
+		return nil, nil, nil, errors.New("invalid or expired sign-in link")
+	}
+
+	link, err := u.oneTimeTokens.Consume(entities.TokenPurposeMagicLink, token, nonce)
+	if err != nil {
+		u.securityEvents.Record(entities.SecurityEvent{
+			Type:    entities.SecurityEventLogin,
+			Outcome: entities.OutcomeFailure,
+			Details: "invalid sign-in link",
+		}, client)
+		return nil, nil, nil, errors.New("invalid or expired sign-in link")
+	}
+
+	user, err := u.userRepo.GetUserByID(link.UserID)
+	if err != nil {
+		return nil, nil, nil, errors.New("invalid or expired sign-in link")
+	}
+
+	challenge, err := u.mfaUsecase.Challenge(user)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if challenge != nil {
+		return nil, nil, challenge, nil
+	}
+
+	tokens, err := u.tokenUsecase.GenerateTokens(user.ID.Hex(), user.Role, client)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	u.securityEvents.Record(entities.SecurityEvent{
+		Type:     entities.SecurityEventLogin,
+		Outcome:  entities.OutcomeSuccess,
+		ActorID:  user.ID.Hex(),
+		TargetID: user.ID.Hex(),
+		Details:  "magic link",
+	}, client)
+
+	user.Password = ""
+	return user, tokens, nil, nil
+}
@@ -0,0 +1,480 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+	"g6_starter_project/Infrastructure/services"
+)
+
+const recoveryCodeCount = 10
+
+// Two-factor throttling. Wrong codes are counted per user across every place a
+// code is checked; at mfaLockoutFailures the user's code checks are locked and
+// the login challenge being guessed is revoked.
+const (
+	mfaFailureWindow   = 15 * time.Minute
+	mfaLockoutFailures = 5
+	mfaLockoutDuration = 15 * time.Minute
+)
+
+const (
+	errInvalidMFACode     = "invalid verification code"
+	errTooManyMFAAttempts = "too many failed verification attempts. please try again later"
+)
+
+// MFAEnrollment is what a user needs to add the account to an authenticator app
+type MFAEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// MFAChallenge is returned by Login instead of tokens when a second factor is needed
+type MFAChallenge struct {
+	Token              string `json:"mfa_token"`
+	EnrollmentRequired bool   `json:"enrollment_required"`
+}
+
+// MFAUsecase handles TOTP enrollment, the second login step and per-role MFA policies
+type MFAUsecase struct {
+	userRepo       entities.UserRepository
+	policyRepo     entities.MFAPolicyRepository
+	roleUsecase    *RoleUsecase
+	tokenUsecase   *TokenUsecase
+	jwtService     *services.JWTService
+	totpService    *services.TOTPService
+	tokenHasher    *services.TokenHasher
+	attemptStore   services.LoginAttemptStore
+	securityEvents *SecurityEventUsecase
+}
+
+// NewMFAUsecase initializes the MFA usecase
+func NewMFAUsecase(userRepo entities.UserRepository, policyRepo entities.MFAPolicyRepository, roleUsecase *RoleUsecase, tokenUsecase *TokenUsecase, jwtService *services.JWTService, totpService *services.TOTPService, tokenHasher *services.TokenHasher, attemptStore services.LoginAttemptStore, securityEvents *SecurityEventUsecase) *MFAUsecase {
+	return &MFAUsecase{
+		userRepo:       userRepo,
+		policyRepo:     policyRepo,
+		roleUsecase:    roleUsecase,
+		tokenUsecase:   tokenUsecase,
+		jwtService:     jwtService,
+		totpService:    totpService,
+		tokenHasher:    tokenHasher,
+		attemptStore:   attemptStore,
+		securityEvents: securityEvents,
+	}
+}
+
+// Challenge decides whether a user who passed the password check needs a second
+// factor. It returns nil when tokens can be issued straight away.
+func (u *MFAUsecase) Challenge(user *entities.User) (*MFAChallenge, error) {
+	enrollmentRequired := false
+	if !user.MFAEnabled() {
+		required, err := u.isRequired(user.Role)
+		if err != nil {
+			return nil, err
+		}
+		if !required {
+			return nil, nil
+		}
+		enrollmentRequired = true
+	}
+
+	mfaToken, err := u.jwtService.GenerateMFAToken(user.ID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate MFA token: %v", err)
+	}
+
+	return &MFAChallenge{Token: mfaToken, EnrollmentRequired: enrollmentRequired}, nil
+}
+
+// CompleteLogin trades an MFA challenge token and a TOTP or recovery code for tokens
+func (u *MFAUsecase) CompleteLogin(mfaToken, code string, client entities.ClientInfo) (*entities.User, *entities.Token, error) {
+	user, err := u.userFromChallenge(mfaToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !user.MFAEnabled() {
+		return nil, nil, errors.New("two-factor enrollment required")
+	}
+
+	err = u.guardCode(user, mfaToken, entities.SecurityEventLogin, client, func() error {
+		return u.verifyCode(user, code)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return u.finishLogin(mfaToken, user, client)
+}
+
+// BeginLoginEnrollment starts enrollment for a user whose role requires MFA but
+// who has not set it up yet, authenticated by the MFA challenge token
+func (u *MFAUsecase) BeginLoginEnrollment(mfaToken string) (*MFAEnrollment, error) {
+	user, err := u.userFromChallenge(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	return u.beginEnrollment(user)
+}
+
+// ConfirmLoginEnrollment confirms an enrollment started during login and signs the user in
+func (u *MFAUsecase) ConfirmLoginEnrollment(mfaToken, code string, client entities.ClientInfo) (*entities.User, *entities.Token, []string, error) {
+	user, err := u.userFromChallenge(mfaToken)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var recoveryCodes []string
+	err = u.guardCode(user, mfaToken, entities.SecurityEventLogin, client, func() error {
+		recoveryCodes, err = u.confirmEnrollment(user, code)
+		return err
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	user, token, err := u.finishLogin(mfaToken, user, client)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return user, token, recoveryCodes, nil
+}
+
+// BeginEnrollment creates a new TOTP secret for a signed-in user. It is not
+// used for login until confirmed with a code from the authenticator app.
+func (u *MFAUsecase) BeginEnrollment(userID string) (*MFAEnrollment, error) {
+	user, err := u.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %v", err)
+	}
+	return u.beginEnrollment(user)
+}
+
+// ConfirmEnrollment enables MFA once the user proves the app generates valid
+// codes, and returns the recovery codes (shown only this once)
+func (u *MFAUsecase) ConfirmEnrollment(userID, code string, client entities.ClientInfo) ([]string, error) {
+	user, err := u.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %v", err)
+	}
+
+	var recoveryCodes []string
+	err = u.guardCode(user, "", entities.SecurityEventMFAVerification, client, func() error {
+		recoveryCodes, err = u.confirmEnrollment(user, code)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return recoveryCodes, nil
+}
+
+// Disable turns MFA off after checking a current code. Users whose role requires
+// MFA cannot disable it.
+func (u *MFAUsecase) Disable(userID, code string, client entities.ClientInfo) error {
+	user, err := u.userRepo.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("user not found: %v", err)
+	}
+
+	if !user.MFAEnabled() {
+		return errors.New("two-factor authentication is not enabled")
+	}
+
+	required, err := u.isRequired(user.Role)
+	if err != nil {
+		return err
+	}
+	if required {
+		return errors.New("two-factor authentication is required for your role")
+	}
+
+	err = u.guardCode(user, "", entities.SecurityEventMFAVerification, client, func() error {
+		return u.verifyCode(user, code)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := u.userRepo.UpdateMFA(userID, nil); err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %v", err)
+	}
+	return nil
+}
+
+// Verify checks a current TOTP or recovery code of a user with MFA enabled, to
+// confirm a sensitive action
+func (u *MFAUsecase) Verify(user *entities.User, code string, client entities.ClientInfo) error {
+	if !user.MFAEnabled() {
+		return errors.New("two-factor authentication is not enabled")
+	}
+	return u.guardCode(user, "", entities.SecurityEventMFAVerification, client, func() error {
+		return u.verifyCode(user, code)
+	})
+}
+
+// SetPolicy requires (or stops requiring) MFA for every user with a role
+func (u *MFAUsecase) SetPolicy(role string, required bool, adminID string) (*entities.MFAPolicy, error) {
+	exists, err := u.roleUsecase.RoleExists(context.Background(), role)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New("invalid role")
+	}
+
+	policy := &entities.MFAPolicy{
+		Role:      role,
+		Required:  required,
+		UpdatedBy: adminID,
+		UpdatedAt: time.Now(),
+	}
+	if err := u.policyRepo.Upsert(context.Background(), policy); err != nil {
+		return nil, fmt.Errorf("failed to save MFA policy: %v", err)
+	}
+	return policy, nil
+}
+
+// GetPolicies returns every configured MFA policy
+func (u *MFAUsecase) GetPolicies() ([]entities.MFAPolicy, error) {
+	return u.policyRepo.GetAll(context.Background())
+}
+
+// isRequired reports whether the policy for a role requires MFA
+func (u *MFAUsecase) isRequired(role string) (bool, error) {
+	policy, err := u.policyRepo.GetByRole(context.Background(), role)
+	if err != nil {
+		return false, fmt.Errorf("failed to load MFA policy: %v", err)
+	}
+	return policy != nil && policy.Required, nil
+}
+
+func (u *MFAUsecase) beginEnrollment(user *entities.User) (*MFAEnrollment, error) {
+	if user.MFAEnabled() {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := u.totpService.GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate secret: %v", err)
+	}
+	encrypted, err := u.totpService.EncryptSecret(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt secret: %v", err)
+	}
+
+	// Starting again replaces any earlier unconfirmed secret
+	if err := u.userRepo.UpdateMFA(user.ID.Hex(), &entities.MFASettings{Secret: encrypted}); err != nil {
+		return nil, fmt.Errorf("failed to save enrollment: %v", err)
+	}
+
+	return &MFAEnrollment{
+		Secret:          secret,
+		ProvisioningURI: u.totpService.ProvisioningURI(secret, user.Email),
+	}, nil
+}
+
+func (u *MFAUsecase) confirmEnrollment(user *entities.User, code string) ([]string, error) {
+	if user.MFAEnabled() {
+		return nil, errors.New("two-factor authentication is already enabled")
+	}
+	if user.MFA == nil || user.MFA.Secret == "" {
+		return nil, errors.New("no pending two-factor enrollment")
+	}
+
+	secret, err := u.totpService.DecryptSecret(user.MFA.Secret)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := u.totpService.Verify(secret, code, time.Now())
+	if !ok {
+		return nil, errors.New(errInvalidMFACode)
+	}
+
+	recoveryCodes, err := u.totpService.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate recovery codes: %v", err)
+	}
+	hashes := make([]string, len(recoveryCodes))
+	for i, recoveryCode := range recoveryCodes {
+		hashes[i] = u.tokenHasher.Hash(services.NormalizeRecoveryCode(recoveryCode))
+	}
+
+	now := time.Now()
+	settings := &entities.MFASettings{
+		Enabled:       true,
+		Secret:        user.MFA.Secret,
+		RecoveryCodes: hashes,
+		LastUsedStep:  step,
+		EnabledAt:     &now,
+	}
+	if err := u.userRepo.UpdateMFA(user.ID.Hex(), settings); err != nil {
+		return nil, fmt.Errorf("failed to enable two-factor authentication: %v", err)
+	}
+	user.MFA = settings
+
+	return recoveryCodes, nil
+}
+
+// verifyCode accepts a 6-digit TOTP code or an unused recovery code. Each code
+// works only once.
+func (u *MFAUsecase) verifyCode(user *entities.User, code string) error {
+	if isTOTPCode(code) {
+		secret, err := u.totpService.DecryptSecret(user.MFA.Secret)
+		if err != nil {
+			return err
+		}
+		step, ok := u.totpService.Verify(secret, code, time.Now())
+		if !ok {
+			return errors.New(errInvalidMFACode)
+		}
+		fresh, err := u.userRepo.UseMFAStep(user.ID.Hex(), step)
+		if err != nil {
+			return fmt.Errorf("failed to verify code: %v", err)
+		}
+		if !fresh {
+			return errors.New(errInvalidMFACode)
+		}
+		return nil
+	}
+
+	used, err := u.userRepo.UseMFARecoveryCode(user.ID.Hex(), u.tokenHasher.Hash(services.NormalizeRecoveryCode(code)))
+	if err != nil {
+		return fmt.Errorf("failed to verify code: %v", err)
+	}
+	if !used {
+		return errors.New(errInvalidMFACode)
+	}
+	return nil
+}
+
+// guardCode runs a code check for a user, refusing it while their code checks
+// are locked. A wrong code is recorded as a failed eventType event and counted;
+// the failure that reaches mfaLockoutFailures locks code checks for
+// mfaLockoutDuration and revokes the MFA challenge token, if one is given.
+func (u *MFAUsecase) guardCode(user *entities.User, mfaToken, eventType string, client entities.ClientInfo, check func() error) error {
+	ctx := context.Background()
+	key := "mfa:" + user.ID.Hex()
+
+	// Store errors let the check through so an outage does not lock everyone out
+	lockedUntil, err := u.attemptStore.LockedUntil(ctx, key)
+	if err != nil {
+		fmt.Printf("Warning: Failed to check two-factor lock: %v\n", err)
+	} else if !lockedUntil.IsZero() {
+		u.recordFailure(eventType, user, "two-factor locked out", client)
+		return errors.New(errTooManyMFAAttempts)
+	}
+
+	err = check()
+	if err == nil {
+		if err := u.attemptStore.Reset(ctx, key); err != nil {
+			fmt.Printf("Warning: Failed to reset failed two-factor attempts: %v\n", err)
+		}
+		return nil
+	}
+	if err.Error() != errInvalidMFACode {
+		return err
+	}
+
+	u.recordFailure(eventType, user, "wrong two-factor code", client)
+
+	failures, storeErr := u.attemptStore.RecordFailure(ctx, key, mfaFailureWindow)
+	if storeErr != nil {
+		fmt.Printf("Warning: Failed to record failed two-factor attempt: %v\n", storeErr)
+		return err
+	}
+	if failures >= mfaLockoutFailures {
+		if err := u.attemptStore.Lock(ctx, key, time.Now().Add(mfaLockoutDuration)); err != nil {
+			fmt.Printf("Warning: Failed to lock two-factor verification: %v\n", err)
+		}
+		u.revokeChallenge(mfaToken)
+		return errors.New(errTooManyMFAAttempts)
+	}
+	return err
+}
+
+// recordFailure adds a failed code check to the audit log
+func (u *MFAUsecase) recordFailure(eventType string, user *entities.User, details string, client entities.ClientInfo) {
+	u.securityEvents.Record(entities.SecurityEvent{
+		Type:     eventType,
+		Outcome:  entities.OutcomeFailure,
+		ActorID:  user.ID.Hex(),
+		TargetID: user.ID.Hex(),
+		Details:  details,
+	}, client)
+}
+
+// userFromChallenge validates an MFA challenge token and loads its user
+func (u *MFAUsecase) userFromChallenge(mfaToken string) (*entities.User, error) {
+	claims, err := u.jwtService.ValidateMFAToken(mfaToken)
+	if err != nil {
+		return nil, errors.New("invalid or expired MFA token")
+	}
+
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return nil, errors.New("invalid or expired MFA token")
+	}
+	revoked, err := u.tokenUsecase.revocationStore.IsRevoked(context.Background(), jti)
+	if err != nil || revoked {
+		return nil, errors.New("invalid or expired MFA token")
+	}
+
+	userID, _ := claims["sub"].(string)
+	user, err := u.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, errors.New("invalid or expired MFA token")
+	}
+	return user, nil
+}
+
+// finishLogin issues tokens and burns the MFA challenge token so it cannot be used again
+func (u *MFAUsecase) finishLogin(mfaToken string, user *entities.User, client entities.ClientInfo) (*entities.User, *entities.Token, error) {
+	u.revokeChallenge(mfaToken)
+
+	token, err := u.tokenUsecase.GenerateTokens(user.ID.Hex(), user.Role, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	u.recordLogin(entities.OutcomeSuccess, user, "two-factor", client)
+
+	user.Password = ""
+	return user, token, nil
+}
+
+// revokeChallenge puts an MFA challenge token on the revocation list
+func (u *MFAUsecase) revokeChallenge(mfaToken string) {
+	if mfaToken == "" {
+		return
+	}
+	if jti, expiresAt, err := u.jwtService.TokenID(mfaToken); err == nil {
+		if err := u.tokenUsecase.revocationStore.Revoke(context.Background(), jti, expiresAt); err != nil {
+			fmt.Printf("Warning: Failed to revoke MFA token: %v\n", err)
+		}
+	}
+}
+
+func (u *MFAUsecase) recordLogin(outcome string, user *entities.User, details string, client entities.ClientInfo) {
+	u.securityEvents.Record(entities.SecurityEvent{
+		Type:     entities.SecurityEventLogin,
+		Outcome:  outcome,
+		ActorID:  user.ID.Hex(),
+		TargetID: user.ID.Hex(),
+		Details:  details,
+	}, client)
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != 6 {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,176 @@
+package usecases
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+	"g6_starter_project/Infrastructure/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const testTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+type mfaTestSuite struct {
+	mfa      *MFAUsecase
+	users    *fakeUserRepository
+	events   *fakeSecurityEventRepository
+	user     *entities.User
+	recovery []string
+}
+
+func setupMFATestSuite(t *testing.T) *mfaTestSuite {
+	totpService, err := services.NewTOTPService("Blog", "test-encryption-key")
+	require.NoError(t, err)
+	tokenHasher := services.NewTokenHasher("test-hash-key")
+
+	encrypted, err := totpService.EncryptSecret(testTOTPSecret)
+	require.NoError(t, err)
+
+	recovery := []string{"abcde-12345", "fghij-67890"}
+	hashes := make([]string, len(recovery))
+	for i, code := range recovery {
+		hashes[i] = tokenHasher.Hash(services.NormalizeRecoveryCode(code))
+	}
+
+	user := &entities.User{
+		ID:   primitive.NewObjectID(),
+		Role: entities.RoleUser,
+		MFA: &entities.MFASettings{
+			Enabled:       true,
+			Secret:        encrypted,
+			RecoveryCodes: hashes,
+		},
+	}
+
+	users := newFakeUserRepository(user)
+	events := &fakeSecurityEventRepository{}
+	tokenUsecase := NewTokenUsecase(nil, nil, users, nil, services.NewInMemoryRevocationStore(), tokenHasher)
+	mfa := NewMFAUsecase(users, nil, nil, tokenUsecase, nil, totpService, tokenHasher, services.NewInMemoryLoginAttemptStore(), NewSecurityEventUsecase(events))
+
+	return &mfaTestSuite{
+		mfa:      mfa,
+		users:    users,
+		events:   events,
+		user:     user,
+		recovery: recovery,
+	}
+}
+
+// currentTOTPCode computes the code an authenticator app shows for the test secret now
+func currentTOTPCode(t *testing.T) string {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(testTOTPSecret)
+	require.NoError(t, err)
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(time.Now().Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", value%1000000)
+}
+
+func TestMFAUsecase_VerifyTOTP(t *testing.T) {
+	t.Run("should accept a code once", func(t *testing.T) {
+		ts := setupMFATestSuite(t)
+		code := currentTOTPCode(t)
+
+		assert.NoError(t, ts.mfa.Verify(ts.user, code, entities.ClientInfo{}))
+
+		err := ts.mfa.Verify(ts.user, code, entities.ClientInfo{})
+		require.Error(t, err)
+		assert.Equal(t, errInvalidMFACode, err.Error())
+	})
+
+	t.Run("should refuse users without two-factor authentication", func(t *testing.T) {
+		ts := setupMFATestSuite(t)
+		ts.user.MFA = nil
+
+		err := ts.mfa.Verify(ts.user, currentTOTPCode(t), entities.ClientInfo{})
+		assert.Error(t, err)
+	})
+}
+
+func TestMFAUsecase_VerifyRecoveryCode(t *testing.T) {
+	// Every input is a way of typing the first recovery code, abcde-12345
+	tests := []struct {
+		name string
+		code string
+	}{
+		{"should accept a code as issued", "abcde-12345"},
+		{"should accept a code in uppercase", "ABCDE-12345"},
+		{"should accept a code without the dash", "abcde12345"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := setupMFATestSuite(t)
+
+			assert.NoError(t, ts.mfa.Verify(ts.user, tt.code, entities.ClientInfo{}))
+
+			// Each recovery code works only once; the others stay usable
+			err := ts.mfa.Verify(ts.user, tt.code, entities.ClientInfo{})
+			require.Error(t, err)
+			assert.Equal(t, errInvalidMFACode, err.Error())
+			assert.NoError(t, ts.mfa.Verify(ts.user, ts.recovery[1], entities.ClientInfo{}))
+		})
+	}
+
+	t.Run("should reject an unknown code", func(t *testing.T) {
+		ts := setupMFATestSuite(t)
+
+		err := ts.mfa.Verify(ts.user, "zzzzz-99999", entities.ClientInfo{})
+		require.Error(t, err)
+		assert.Equal(t, errInvalidMFACode, err.Error())
+	})
+}
+
+func TestMFAUsecase_Lockout(t *testing.T) {
+	t.Run("should lock code checks after repeated wrong codes", func(t *testing.T) {
+		ts := setupMFATestSuite(t)
+
+		for i := 1; i < mfaLockoutFailures; i++ {
+			err := ts.mfa.Verify(ts.user, "zzzzz-99999", entities.ClientInfo{})
+			require.Error(t, err)
+			assert.Equal(t, errInvalidMFACode, err.Error())
+		}
+
+		err := ts.mfa.Verify(ts.user, "zzzzz-99999", entities.ClientInfo{})
+		require.Error(t, err)
+		assert.Equal(t, errTooManyMFAAttempts, err.Error())
+
+		// A right code is refused too while locked
+		err = ts.mfa.Verify(ts.user, ts.recovery[0], entities.ClientInfo{})
+		require.Error(t, err)
+		assert.Equal(t, errTooManyMFAAttempts, err.Error())
+
+		assert.Equal(t, mfaLockoutFailures, ts.events.count(entities.SecurityEventMFAVerification, entities.OutcomeFailure, "wrong two-factor code"))
+		assert.Equal(t, 1, ts.events.count(entities.SecurityEventMFAVerification, entities.OutcomeFailure, "two-factor locked out"))
+	})
+
+	t.Run("should start counting again after a right code", func(t *testing.T) {
+		ts := setupMFATestSuite(t)
+
+		for i := 1; i < mfaLockoutFailures; i++ {
+			assert.Error(t, ts.mfa.Verify(ts.user, "zzzzz-99999", entities.ClientInfo{}))
+		}
+		assert.NoError(t, ts.mfa.Verify(ts.user, ts.recovery[0], entities.ClientInfo{}))
+
+		for i := 1; i < mfaLockoutFailures; i++ {
+			err := ts.mfa.Verify(ts.user, "zzzzz-99999", entities.ClientInfo{})
+			require.Error(t, err)
+			assert.Equal(t, errInvalidMFACode, err.Error())
+		}
+	})
+}
@@ -0,0 +1,272 @@
+package usecases
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+	"g6_starter_project/Infrastructure/services"
+
+	"github.com/google/uuid"
+)
+
+// OIDCStateTTL is how long a user has to finish signing in at the provider
+const OIDCStateTTL = 10 * time.Minute
+
+var usernameUnsafeChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// OIDCUsecase signs users in through external OpenID Connect providers
+type OIDCUsecase struct {
+	userRepo       entities.UserRepository
+	stateRepo      entities.OIDCStateRepository
+	oidcService    *services.OIDCService
+	tokenUsecase   *TokenUsecase
+	mfaUsecase     *MFAUsecase
+	oneTimeTokens  *OneTimeTokenUsecase
+	passwordHasher services.PasswordHasher
+	securityEvents *SecurityEventUsecase
+}
+
+// NewOIDCUsecase initializes the OIDC usecase
+func NewOIDCUsecase(userRepo entities.UserRepository, stateRepo entities.OIDCStateRepository, oidcService *services.OIDCService, tokenUsecase *TokenUsecase, mfaUsecase *MFAUsecase, oneTimeTokens *OneTimeTokenUsecase, passwordHasher services.PasswordHasher, securityEvents *SecurityEventUsecase) *OIDCUsecase {
+	return &OIDCUsecase{
+		userRepo:       userRepo,
+		stateRepo:      stateRepo,
+		oidcService:    oidcService,
+		tokenUsecase:   tokenUsecase,
+		mfaUsecase:     mfaUsecase,
+		oneTimeTokens:  oneTimeTokens,
+		passwordHasher: passwordHasher,
+		securityEvents: securityEvents,
+	}
+}
+
+// Providers returns the names of the configured identity providers
+func (u *OIDCUsecase) Providers() []string {
+	return u.oidcService.Providers()
+}
+
+// BeginLogin starts a sign-in and returns the provider URL to redirect the user
+// to, and the state the browser must present again on the callback
+func (u *OIDCUsecase) BeginLogin(provider string) (string, string, error) {
+	ctx := context.Background()
+
+	state, err := services.RandomURLToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := services.RandomURLToken()
+	if err != nil {
+		return "", "", err
+	}
+	codeVerifier, err := services.RandomURLToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err := u.oidcService.AuthCodeURL(ctx, provider, state, nonce, codeVerifier)
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	pending := &entities.OIDCState{
+		State:        state,
+		Provider:     provider,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ExpiresAt:    now.Add(OIDCStateTTL),
+		CreatedAt:    now,
+	}
+	if err := u.stateRepo.Create(ctx, pending); err != nil {
+		return "", "", fmt.Errorf("failed to store login state: %v", err)
+	}
+
+	return authURL, state, nil
+}
+
+// CompleteLogin handles the provider callback. It signs in the linked user, links
+// an existing user with the same verified email, or creates a new verified user.
+// Like Login, it returns an MFA challenge instead of tokens when a second factor is needed.
+// browserState is the state kept by the browser that began the sign-in; it must
+// match, so a callback URL planted in another browser cannot sign it in.
+func (u *OIDCUsecase) CompleteLogin(provider, state, browserState, code string, client entities.ClientInfo) (*entities.User, *entities.Token, *MFAChallenge, error) {
+	ctx := context.Background()
+
+	if browserState == "" || subtle.ConstantTimeCompare([]byte(state), []byte(browserState)) != 1 {
+		return nil, nil, nil, errors.New("invalid or expired login state")
+	}
+
+	pending, err := u.stateRepo.Consume(ctx, state, time.Now())
+	if err != nil || pending.Provider != provider {
+		return nil, nil, nil, errors.New("invalid or expired login state")
+	}
+
+	claims, err := u.oidcService.Exchange(ctx, provider, code, pending.CodeVerifier, pending.Nonce)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	user, err := u.findOrCreateUser(provider, claims)
+	if err != nil {
+		u.securityEvents.Record(entities.SecurityEvent{
+			Type:    entities.SecurityEventLogin,
+			Outcome: entities.OutcomeFailure,
+			Email:   strings.ToLower(claims.Email),
+			Details: provider + ": " + err.Error(),
+		}, client)
+		return nil, nil, nil, err
+	}
+
+	challenge, err := u.mfaUsecase.Challenge(user)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if challenge != nil {
+		return nil, nil, challenge, nil
+	}
+
+	token, err := u.tokenUsecase.GenerateTokens(user.ID.Hex(), user.Role, client)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	u.securityEvents.Record(entities.SecurityEvent{
+		Type:     entities.SecurityEventLogin,
+		Outcome:  entities.OutcomeSuccess,
+		ActorID:  user.ID.Hex(),
+		TargetID: user.ID.Hex(),
+		Details:  provider,
+	}, client)
+
+	user.Password = ""
+	return user, token, nil, nil
+}
+
+func (u *OIDCUsecase) findOrCreateUser(provider string, claims *services.OIDCClaims) (*entities.User, error) {
+	if user, err := u.userRepo.GetUserByIdentity(provider, claims.Subject); err == nil {
+		return user, nil
+	}
+
+	// Only a verified email proves the account belongs to the same person
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, errors.New("identity provider did not return a verified email")
+	}
+	email := strings.ToLower(claims.Email)
+
+	identity := entities.ExternalIdentity{
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    email,
+		LinkedAt: time.Now(),
+	}
+
+	user, err := u.userRepo.GetUserByEmail(email)
+	if err == nil {
+		if !user.IsVerified {
+			// Whoever registered this unverified account never proved they own the
+			// email, so their password must not survive the link
+			if err := u.claimUnverifiedUser(user); err != nil {
+				return nil, err
+			}
+		}
+		if err := u.userRepo.AddIdentity(user.ID.Hex(), identity); err != nil {
+			return nil, fmt.Errorf("failed to link identity: %v", err)
+		}
+		return user, nil
+	}
+
+	return u.createUser(email, claims.Name, identity)
+}
+
+// claimUnverifiedUser marks the account verified and replaces its password with an unusable one
+func (u *OIDCUsecase) claimUnverifiedUser(user *entities.User) error {
+	password, err := u.randomPasswordHash()
+	if err != nil {
+		return err
+	}
+
+	if err := u.userRepo.UpdatePassword(user.ID.Hex(), password, user.PasswordHistory); err != nil {
+		return fmt.Errorf("failed to update user: %v", err)
+	}
+	if err := u.userRepo.UpdateVerificationStatus(user.ID.Hex(), true); err != nil {
+		return fmt.Errorf("failed to update user: %v", err)
+	}
+	user.Password = password
+	user.IsVerified = true
+	// Drop the pending verification and reset links as well
+	if err := u.oneTimeTokens.Revoke(user.ID.Hex(), entities.TokenPurposeVerifyEmail, entities.TokenPurposeResetPassword); err != nil {
+		return fmt.Errorf("failed to update user: %v", err)
+	}
+	return nil
+}
+
+// createUser creates a verified user for a first-time sign-in. The email was
+// verified by the provider, so no verification email is sent.
+func (u *OIDCUsecase) createUser(email, name string, identity entities.ExternalIdentity) (*entities.User, error) {
+	password, err := u.randomPasswordHash()
+	if err != nil {
+		return nil, err
+	}
+
+	username, err := u.availableUsername(email)
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		name = username
+	}
+
+	now := time.Now()
+	user := &entities.User{
+		FullName:   name,
+		Username:   username,
+		Email:      email,
+		Password:   password,
+		Role:       entities.DefaultRole,
+		IsVerified: true,
+		Identities: []entities.ExternalIdentity{identity},
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	createdUser, err := u.userRepo.CreateUser(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %v", err)
+	}
+	return createdUser, nil
+}
+
+// availableUsername derives an unused username from the local part of an email
+func (u *OIDCUsecase) availableUsername(email string) (string, error) {
+	base := usernameUnsafeChars.ReplaceAllString(strings.Split(email, "@")[0], "")
+	if len(base) < 3 {
+		base = "user"
+	}
+
+	username := base
+	for i := 0; i < 5; i++ {
+		if _, err := u.userRepo.GetUserByUsername(username); err != nil {
+			return username, nil
+		}
+
+		username = base + "_" + uuid.NewString()[:6]
+	}
+	return "", errors.New("failed to find an available username")
+}
+
+// randomPasswordHash returns a hash of a random password nobody knows. Users
+// created through a provider set a real password with the reset flow if they want one.
+func (u *OIDCUsecase) randomPasswordHash() (string, error) {
+	password, err := services.RandomURLToken()
+	if err != nil {
+		return "", err
+	}
+
+	return u.passwordHasher.HashPassword(password)
+}
@@ -0,0 +1,346 @@
+package usecases
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+	"g6_starter_project/Infrastructure/services"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	testOIDCProvider = "mock"
+	testOIDCClientID = "blog-client"
+)
+
+// mockIdentity is the account a user signs in with at the mock IdP
+type mockIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+// mockAuthorization is what the mock IdP remembers about an issued code
+type mockAuthorization struct {
+	identity      mockIdentity
+	nonce         string
+	codeChallenge string
+}
+
+// mockIdP is an OpenID Connect provider serving discovery, JWKS and a token
+// endpoint that checks PKCE. tamper, if set, edits the ID token claims.
+type mockIdP struct {
+	server *httptest.Server
+	key    ed25519.PrivateKey
+
+	mutex  sync.Mutex
+	codes  map[string]mockAuthorization
+	tamper func(claims jwt.MapClaims)
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	idp := &mockIdP{key: key, codes: map[string]mockAuthorization{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(services.JSONWebKeySet{Keys: []services.JSONWebKey{{
+			Kty: "OKP",
+			Kid: "idp-key",
+			Use: "sig",
+			Alg: "EdDSA",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+		}}})
+	})
+	mux.HandleFunc("/token", idp.token)
+
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+// authorize plays the user signing in at the IdP and returns the code the IdP
+// would redirect back with
+func (idp *mockIdP) authorize(t *testing.T, authURL string, identity mockIdentity) string {
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	query := parsed.Query()
+	require.Equal(t, testOIDCClientID, query.Get("client_id"))
+	require.Equal(t, "S256", query.Get("code_challenge_method"))
+
+	code, err := services.RandomURLToken()
+	require.NoError(t, err)
+
+	idp.mutex.Lock()
+	defer idp.mutex.Unlock()
+	idp.codes[code] = mockAuthorization{
+		identity:      identity,
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+	}
+	return code
+}
+
+func (idp *mockIdP) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	idp.mutex.Lock()
+	authorization, ok := idp.codes[r.PostForm.Get("code")]
+	delete(idp.codes, r.PostForm.Get("code"))
+	tamper := idp.tamper
+	idp.mutex.Unlock()
+
+	if !ok || services.PKCEChallenge(r.PostForm.Get("code_verifier")) != authorization.codeChallenge {
+		http.Error(w, "invalid_grant", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            idp.server.URL,
+		"aud":            testOIDCClientID,
+		"sub":            authorization.identity.Subject,
+		"email":          authorization.identity.Email,
+		"email_verified": authorization.identity.EmailVerified,
+		"nonce":          authorization.nonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+	}
+	if tamper != nil {
+		tamper(claims)
+	}
+
+	idToken := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	idToken.Header["kid"] = "idp-key"
+	signed, err := idToken.SignedString(idp.key)
+	if err != nil {
+		http.Error(w, "server_error", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"id_token": signed})
+}
+
+type oidcTestSuite struct {
+	oidc          *OIDCUsecase
+	idp           *mockIdP
+	users         *fakeUserRepository
+	oneTimeTokens *fakeOneTimeTokenRepository
+}
+
+func setupOIDCTestSuite(t *testing.T, users ...*entities.User) *oidcTestSuite {
+	idp := newMockIdP(t)
+	oidcService, err := services.NewOIDCService([]services.OIDCProviderConfig{{
+		Name:        testOIDCProvider,
+		IssuerURL:   idp.server.URL,
+		ClientID:    testOIDCClientID,
+		RedirectURL: "http://localhost/auth/oidc/mock/callback",
+	}})
+	require.NoError(t, err)
+
+	passwordHasher, err := services.NewPasswordHasher(services.PasswordHasherConfig{
+		Algorithm:  services.PasswordHashBcrypt,
+		BcryptCost: bcrypt.MinCost,
+		Argon2id:   services.DefaultArgon2idParams,
+	})
+	require.NoError(t, err)
+
+	userRepo := newFakeUserRepository(users...)
+	oneTimeTokenRepo := newFakeOneTimeTokenRepository()
+	tokenHasher := services.NewTokenHasher("test-hash-key")
+	jwtService := newTestJWTService(t)
+	tokenUsecase := NewTokenUsecase(newFakeTokenRepository(), nil, userRepo, jwtService, services.NewInMemoryRevocationStore(), tokenHasher)
+	mfaUsecase := NewMFAUsecase(userRepo, &fakeMFAPolicyRepository{}, nil, tokenUsecase, jwtService, nil, tokenHasher, services.NewInMemoryLoginAttemptStore(), nil)
+	securityEvents := NewSecurityEventUsecase(&fakeSecurityEventRepository{})
+
+	return &oidcTestSuite{
+		oidc:          NewOIDCUsecase(userRepo, newFakeOIDCStateRepository(), oidcService, tokenUsecase, mfaUsecase, NewOneTimeTokenUsecase(oneTimeTokenRepo, tokenHasher), passwordHasher, securityEvents),
+		idp:           idp,
+		users:         userRepo,
+		oneTimeTokens: oneTimeTokenRepo,
+	}
+}
+
+// signIn runs a whole sign-in as the identity
+func (ts *oidcTestSuite) signIn(t *testing.T, identity mockIdentity) (*entities.User, *entities.Token, error) {
+	authURL, state, err := ts.oidc.BeginLogin(testOIDCProvider)
+	require.NoError(t, err)
+	code := ts.idp.authorize(t, authURL, identity)
+
+	user, token, _, err := ts.oidc.CompleteLogin(testOIDCProvider, state, state, code, entities.ClientInfo{})
+	return user, token, err
+}
+
+func TestOIDCUsecase_BeginLogin(t *testing.T) {
+	t.Run("should send the user to the discovered authorization endpoint with PKCE", func(t *testing.T) {
+		ts := setupOIDCTestSuite(t)
+
+		authURL, state, err := ts.oidc.BeginLogin(testOIDCProvider)
+		require.NoError(t, err)
+
+		assert.True(t, strings.HasPrefix(authURL, ts.idp.server.URL+"/authorize?"))
+		query, err := url.Parse(authURL)
+		require.NoError(t, err)
+		assert.Equal(t, state, query.Query().Get("state"))
+		assert.NotEmpty(t, query.Query().Get("nonce"))
+		assert.NotEmpty(t, query.Query().Get("code_challenge"))
+	})
+
+	t.Run("should refuse an unknown provider", func(t *testing.T) {
+		ts := setupOIDCTestSuite(t)
+
+		_, _, err := ts.oidc.BeginLogin("unknown")
+		assert.Error(t, err)
+	})
+}
+
+func TestOIDCUsecase_CompleteLogin(t *testing.T) {
+	identity := mockIdentity{Subject: "idp-user-1", Email: "jane@example.com", EmailVerified: true}
+
+	t.Run("should create a verified user on first sign-in", func(t *testing.T) {
+		ts := setupOIDCTestSuite(t)
+
+		user, token, err := ts.signIn(t, identity)
+		require.NoError(t, err)
+		require.NotNil(t, token)
+		assert.True(t, user.IsVerified)
+		assert.Equal(t, "jane@example.com", user.Email)
+
+		// The second sign-in finds the user by the linked identity
+		again, _, err := ts.signIn(t, identity)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, again.ID)
+	})
+
+	t.Run("should refuse a callback whose state is not the browser's", func(t *testing.T) {
+		ts := setupOIDCTestSuite(t)
+		authURL, state, err := ts.oidc.BeginLogin(testOIDCProvider)
+		require.NoError(t, err)
+		code := ts.idp.authorize(t, authURL, identity)
+
+		_, _, _, err = ts.oidc.CompleteLogin(testOIDCProvider, state, "another-state", code, entities.ClientInfo{})
+		require.Error(t, err)
+		assert.Equal(t, "invalid or expired login state", err.Error())
+	})
+
+	t.Run("should refuse a code issued for another login", func(t *testing.T) {
+		ts := setupOIDCTestSuite(t)
+		victimURL, _, err := ts.oidc.BeginLogin(testOIDCProvider)
+		require.NoError(t, err)
+		_, attackerState, err := ts.oidc.BeginLogin(testOIDCProvider)
+		require.NoError(t, err)
+		code := ts.idp.authorize(t, victimURL, identity)
+
+		// The code verifier stored with this state does not match the code's challenge
+		_, _, _, err = ts.oidc.CompleteLogin(testOIDCProvider, attackerState, attackerState, code, entities.ClientInfo{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "token request failed")
+	})
+
+	tests := []struct {
+		name   string
+		tamper func(claims jwt.MapClaims)
+	}{
+		{
+			name:   "should refuse an ID token from another issuer",
+			tamper: func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example.com" },
+		},
+		{
+			name:   "should refuse an ID token for another client",
+			tamper: func(claims jwt.MapClaims) { claims["aud"] = "another-client" },
+		},
+		{
+			name:   "should refuse an ID token with another nonce",
+			tamper: func(claims jwt.MapClaims) { claims["nonce"] = "replayed-nonce" },
+		},
+		{
+			name:   "should refuse an expired ID token",
+			tamper: func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Minute).Unix() },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := setupOIDCTestSuite(t)
+			ts.idp.tamper = tt.tamper
+
+			_, _, err := ts.signIn(t, identity)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "invalid ID token")
+			assert.Empty(t, ts.users.users)
+		})
+	}
+
+	t.Run("should link an existing account only when the email is verified", func(t *testing.T) {
+		existing := &entities.User{
+			ID:         primitive.NewObjectID(),
+			Email:      "jane@example.com",
+			Username:   "jane",
+			Role:       entities.RoleUser,
+			IsVerified: true,
+		}
+		ts := setupOIDCTestSuite(t, existing)
+
+		unverified := identity
+		unverified.EmailVerified = false
+		_, _, err := ts.signIn(t, unverified)
+		require.Error(t, err)
+		assert.Empty(t, ts.users.users[existing.ID.Hex()].Identities)
+		assert.Len(t, ts.users.users, 1)
+
+		user, _, err := ts.signIn(t, identity)
+		require.NoError(t, err)
+		assert.Equal(t, existing.ID, user.ID)
+		require.Len(t, ts.users.users[existing.ID.Hex()].Identities, 1)
+		assert.Equal(t, identity.Subject, ts.users.users[existing.ID.Hex()].Identities[0].Subject)
+	})
+
+	t.Run("should take over an unverified account and drop its password and links", func(t *testing.T) {
+		existing := &entities.User{
+			ID:         primitive.NewObjectID(),
+			Email:      "jane@example.com",
+			Username:   "jane",
+			Password:   "password-set-by-someone-else",
+			Role:       entities.RoleUser,
+			IsVerified: false,
+		}
+		ts := setupOIDCTestSuite(t, existing)
+
+		user, _, err := ts.signIn(t, identity)
+		require.NoError(t, err)
+		assert.Equal(t, existing.ID, user.ID)
+
+		stored := ts.users.users[existing.ID.Hex()]
+		assert.True(t, stored.IsVerified)
+		assert.NotEqual(t, "password-set-by-someone-else", stored.Password)
+		assert.ElementsMatch(t, []string{entities.TokenPurposeVerifyEmail, entities.TokenPurposeResetPassword}, ts.oneTimeTokens.revokedPurposes(existing.ID.Hex()))
+	})
+}
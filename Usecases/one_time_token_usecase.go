@@ -0,0 +1,99 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+	"g6_starter_project/Infrastructure/services"
+)
+
+// OneTimeTokenUsecase issues and redeems the single-use tokens sent in emailed
+// links (email verification, password reset, email change and magic links)
+type OneTimeTokenUsecase struct {
+	repo        entities.OneTimeTokenRepository
+	tokenHasher *services.TokenHasher
+}
+
+// NewOneTimeTokenUsecase initializes the one-time token usecase
+func NewOneTimeTokenUsecase(repo entities.OneTimeTokenRepository, tokenHasher *services.TokenHasher) *OneTimeTokenUsecase {
+	return &OneTimeTokenUsecase{
+		repo:        repo,
+		tokenHasher: tokenHasher,
+	}
+}
+
+// Issue creates a token for the purpose and returns its value, which is never
+// stored. Earlier tokens of the user for the same purpose stop working. When
+// binding is set, the token can only be redeemed by presenting it as well.
+func (u *OneTimeTokenUsecase) Issue(purpose, userID, email, binding string, ttl time.Duration) (string, error) {
+	ctx := context.Background()
+
+	value, err := services.RandomURLToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token: %v", err)
+	}
+
+	if err := u.repo.DeleteByUser(ctx, userID, purpose); err != nil {
+		return "", fmt.Errorf("failed to replace token: %v", err)
+	}
+
+	now := time.Now()
+	token := &entities.OneTimeToken{
+		TokenHash:   u.tokenHasher.Hash(value),
+		Purpose:     purpose,
+		UserID:      userID,
+		Email:       email,
+		BindingHash: u.bindingHash(binding),
+		ExpiresAt:   now.Add(ttl),
+		CreatedAt:   now,
+	}
+	if err := u.repo.Create(ctx, token); err != nil {
+		return "", fmt.Errorf("failed to store token: %v", err)
+	}
+
+	return value, nil
+}
+
+// Lookup returns the token for the purpose without redeeming it, so a request
+// can be checked in full before the token is spent. It fails for the same
+// tokens as Consume.
+func (u *OneTimeTokenUsecase) Lookup(purpose, value, binding string) (*entities.OneTimeToken, error) {
+	if value == "" {
+		return nil, errors.New("invalid or expired token")
+	}
+
+	token, err := u.repo.Find(context.Background(), purpose, u.tokenHasher.Hash(value), u.bindingHash(binding), time.Now())
+	if err != nil {
+		return nil, errors.New("invalid or expired token")
+	}
+	return token, nil
+}
+
+// Consume redeems a token for the purpose. It fails for unknown, expired, used
+// or differently bound tokens.
+func (u *OneTimeTokenUsecase) Consume(purpose, value, binding string) (*entities.OneTimeToken, error) {
+	if value == "" {
+		return nil, errors.New("invalid or expired token")
+	}
+
+	token, err := u.repo.Consume(context.Background(), purpose, u.tokenHasher.Hash(value), u.bindingHash(binding), time.Now())
+	if err != nil {
+		return nil, errors.New("invalid or expired token")
+	}
+	return token, nil
+}
+
+// Revoke deletes the user's outstanding tokens for the given purposes, or all of them when none is given
+func (u *OneTimeTokenUsecase) Revoke(userID string, purposes ...string) error {
+	return u.repo.DeleteByUser(context.Background(), userID, purposes...)
+}
+
+func (u *OneTimeTokenUsecase) bindingHash(binding string) string {
+	if binding == "" {
+		return ""
+	}
+	return u.tokenHasher.Hash(binding)
+}
@@ -3,6 +3,7 @@ package usecases
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"g6_starter_project/Domain/entities"
@@ -10,90 +11,132 @@ import (
 	"g6_starter_project/Infrastructure/utils"
 )
 
+// resetTokenTTL is how long a password reset link stays valid
+const resetTokenTTL = 15 * time.Minute
+
 type PasswordResetUsecase struct {
-	userRepo     entities.UserRepository
-	jwtService   *services.JWTService
-	emailService *services.EmailService
-	rateLimiter  *services.RateLimiter
+	userRepo       entities.UserRepository
+	emailService   *services.EmailService
+	rateLimiter    *services.RateLimiter
+	tokenUsecase   *TokenUsecase
+	oneTimeTokens  *OneTimeTokenUsecase
+	passwordHasher services.PasswordHasher
+	passwordPolicy *services.PasswordPolicy
+	securityEvents *SecurityEventUsecase
 }
 
-func NewPasswordResetUsecase(userRepo entities.UserRepository, jwtService *services.JWTService, emailService *services.EmailService, rateLimiter *services.RateLimiter) *PasswordResetUsecase {
+func NewPasswordResetUsecase(userRepo entities.UserRepository, emailService *services.EmailService, rateLimiter *services.RateLimiter, tokenUsecase *TokenUsecase, oneTimeTokens *OneTimeTokenUsecase, passwordHasher services.PasswordHasher, passwordPolicy *services.PasswordPolicy, securityEvents *SecurityEventUsecase) *PasswordResetUsecase {
 	return &PasswordResetUsecase{
-		userRepo:     userRepo,
-		jwtService:   jwtService,
-		emailService: emailService,
-		rateLimiter:  rateLimiter,
+		userRepo:       userRepo,
+		emailService:   emailService,
+		rateLimiter:    rateLimiter,
+		tokenUsecase:   tokenUsecase,
+		oneTimeTokens:  oneTimeTokens,
+		passwordHasher: passwordHasher,
+		passwordPolicy: passwordPolicy,
+		securityEvents: securityEvents,
 	}
 }
 
-func (p *PasswordResetUsecase) RequestPasswordReset(email string) error {
+func (p *PasswordResetUsecase) RequestPasswordReset(email string, client entities.ClientInfo) error {
 	if !utils.IsValidEmail(email) {
 		return errors.New("invalid email format")
 	}
 
 	// rate limit 3 request per email6
 	if !p.rateLimiter.IsAllowed("forgot_password:"+email, 3, time.Hour) {
+		p.securityEvents.Record(entities.SecurityEvent{
+			Type:    entities.SecurityEventPasswordResetRequest,
+			Outcome: entities.OutcomeFailure,
+			Email:   strings.ToLower(email),
+			Details: "rate limited",
+		}, client)
 		return errors.New("too many password reset requests. please wait before trying again")
 	}
 
 	user, err := p.userRepo.GetUserByEmail(email)
 	if err != nil {
+		p.securityEvents.Record(entities.SecurityEvent{
+			Type:    entities.SecurityEventPasswordResetRequest,
+			Outcome: entities.OutcomeFailure,
+			Email:   strings.ToLower(email),
+			Details: "unknown account",
+		}, client)
 		// Don't reveal if email exists or not for security
 		return nil
 	}
 
-	resetToken, err := p.jwtService.GenerateResetToken(user.ID.Hex())
+	resetToken, err := p.oneTimeTokens.Issue(entities.TokenPurposeResetPassword, user.ID.Hex(), user.Email, "", resetTokenTTL)
 	if err != nil {
 		return fmt.Errorf("failed to generate reset token: %v", err)
 	}
 
-	expiresAt := time.Now().Add(15 * time.Minute)
-
-	if err := p.userRepo.UpdateResetToken(user.ID.Hex(), &resetToken, &expiresAt); err != nil {
-		return fmt.Errorf("failed to store reset token: %v", err)
-	}
-
 	if err := p.emailService.SendPasswordResetEmail(user.Email, user.FullName, resetToken); err != nil {
 		return fmt.Errorf("failed to send reset email: %v", err)
 	}
 
+	p.securityEvents.Record(entities.SecurityEvent{
+		Type:     entities.SecurityEventPasswordResetRequest,
+		Outcome:  entities.OutcomeSuccess,
+		TargetID: user.ID.Hex(),
+	}, client)
+
 	return nil
 }
 
 // Reset user password 
-func (p *PasswordResetUsecase) ResetPassword(token, newPassword string) error {
-	if !utils.IsValidPassword(newPassword) {
-		return errors.New("invalid password format - password must be at least 8 characters and contain uppercase, lowercase, number and special character")
+func (p *PasswordResetUsecase) ResetPassword(token, newPassword string, client entities.ClientInfo) error {
+	// Check what can be checked before the token is spent
+	if err := p.passwordPolicy.Check(newPassword, services.PasswordContext{}); err != nil {
+		return err
 	}
 
-	if _, err := p.jwtService.ValidateResetToken(token); err != nil {
-		return fmt.Errorf("invalid or expired reset token: %v", err)
+	// Look the token up without spending it, so a rejected password leaves the link usable
+	reset, err := p.oneTimeTokens.Lookup(entities.TokenPurposeResetPassword, token, "")
+	if err != nil {
+		p.securityEvents.Record(entities.SecurityEvent{
+			Type:    entities.SecurityEventPasswordReset,
+			Outcome: entities.OutcomeFailure,
+			Details: "invalid reset token",
+		}, client)
+		return errors.New("invalid or expired reset token")
 	}
 
-	user, err := p.userRepo.GetUserByResetToken(token)
+	user, err := p.userRepo.GetUserByID(reset.UserID)
 	if err != nil {
-		return fmt.Errorf("invalid reset token: %v", err)
+		return errors.New("invalid or expired reset token")
 	}
 
-	if user.ResetTokenExpiresAt != nil && time.Now().After(*user.ResetTokenExpiresAt) {
-		return errors.New("reset token has expired")
+	if err := p.passwordPolicy.Check(newPassword, passwordContext(user)); err != nil {
+		return err
 	}
 
-	bcryptService := services.NewBcryptService(10)
-	hashedPassword, err := bcryptService.HashPassword(newPassword)
-	if err != nil {
-		return fmt.Errorf("failed to hash password: %v", err)
+	if err := setPassword(p.passwordHasher, user, newPassword); err != nil {
+		return err
 	}
 
-	user.Password = hashedPassword
-	user.ResetToken = nil
-	user.ResetTokenExpiresAt = nil
-	user.UpdatedAt = time.Now()
+	// The password will be stored; redeem the token so it cannot be used again
+	redeemed, err := p.oneTimeTokens.Consume(entities.TokenPurposeResetPassword, token, "")
+	if err != nil || redeemed.UserID != reset.UserID {
+		return errors.New("invalid or expired reset token")
+	}
 
-	if _, err := p.userRepo.UpdateUser(user); err != nil {
+	if err := p.userRepo.UpdatePassword(user.ID.Hex(), user.Password, user.PasswordHistory); err != nil {
 		return fmt.Errorf("failed to update password: %v", err)
 	}
 
+	p.securityEvents.Record(entities.SecurityEvent{
+		Type:     entities.SecurityEventPasswordReset,
+		Outcome:  entities.OutcomeSuccess,
+		ActorID:  user.ID.Hex(),
+		TargetID: user.ID.Hex(),
+	}, client)
+
+	// Sign out every device that may have been using the old password
+	if err := p.tokenUsecase.RevokeAllSessions(user.ID.Hex()); err != nil {
+		fmt.Printf("Warning: Failed to revoke sessions after password reset: %v\n", err)
+	}
+
 	if err := p.emailService.SendPasswordChangeNotification(user.Email, user.FullName); err != nil {
 		fmt.Printf("Warning: Failed to send password change notification: %v\n", err)
 	}
@@ -0,0 +1,171 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+	"g6_starter_project/Infrastructure/services"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxPersonalAccessTokens         = 20
+	defaultPersonalAccessTokenDays  = 90
+	maxPersonalAccessTokenDays      = 365
+	personalAccessTokenDisplayChars = 12
+	// lastUsedResolution limits how often a busy token's last-used time is written
+	lastUsedResolution = time.Minute
+)
+
+// PersonalAccessTokenUsecase manages long-lived, scoped tokens for scripts and CI
+type PersonalAccessTokenUsecase struct {
+	repo        entities.PersonalAccessTokenRepository
+	userRepo    entities.UserRepository
+	tokenHasher *services.TokenHasher
+}
+
+// NewPersonalAccessTokenUsecase initializes the personal access token usecase
+func NewPersonalAccessTokenUsecase(repo entities.PersonalAccessTokenRepository, userRepo entities.UserRepository, tokenHasher *services.TokenHasher) *PersonalAccessTokenUsecase {
+	return &PersonalAccessTokenUsecase{
+		repo:        repo,
+		userRepo:    userRepo,
+		tokenHasher: tokenHasher,
+	}
+}
+
+// Create issues a new token. The returned token value is never stored and cannot be shown again.
+func (u *PersonalAccessTokenUsecase) Create(userID, name string, scopes []string, expiresInDays int) (*entities.PersonalAccessToken, string, error) {
+	ctx := context.Background()
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", errors.New("token name is required")
+	}
+
+	scopes, err := validateScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if expiresInDays == 0 {
+		expiresInDays = defaultPersonalAccessTokenDays
+	}
+	if expiresInDays < 1 || expiresInDays > maxPersonalAccessTokenDays {
+		return nil, "", fmt.Errorf("expires_in_days must be between 1 and %d", maxPersonalAccessTokenDays)
+	}
+
+	count, err := u.repo.CountByUserID(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if count >= maxPersonalAccessTokens {
+		return nil, "", fmt.Errorf("token limit reached: a user can have at most %d tokens", maxPersonalAccessTokens)
+	}
+
+	random, err := services.RandomURLToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate token: %v", err)
+	}
+	value := services.PersonalAccessTokenPrefix + random
+
+	now := time.Now()
+	token := &entities.PersonalAccessToken{
+		ID:        uuid.NewString(),
+		UserID:    userID,
+		Name:      name,
+		TokenHash: u.tokenHasher.Hash(value),
+		Prefix:    value[:personalAccessTokenDisplayChars],
+		Scopes:    scopes,
+		ExpiresAt: now.Add(time.Duration(expiresInDays) * 24 * time.Hour),
+		CreatedAt: now,
+	}
+	if err := u.repo.Create(ctx, token); err != nil {
+		return nil, "", fmt.Errorf("failed to store token: %v", err)
+	}
+
+	return token, value, nil
+}
+
+// List returns the user's tokens
+func (u *PersonalAccessTokenUsecase) List(userID string) ([]entities.PersonalAccessToken, error) {
+	return u.repo.FindByUserID(context.Background(), userID)
+}
+
+// Revoke deletes one of the user's tokens
+func (u *PersonalAccessTokenUsecase) Revoke(userID, tokenID string) error {
+	deleted, err := u.repo.DeleteByIDForUser(context.Background(), tokenID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke token: %v", err)
+	}
+	if !deleted {
+		return errors.New("token not found")
+	}
+	return nil
+}
+
+// RevokeAll deletes every token of a user
+func (u *PersonalAccessTokenUsecase) RevokeAll(userID string) error {
+	if err := u.repo.DeleteByUserID(context.Background(), userID); err != nil {
+		return fmt.Errorf("failed to revoke tokens: %v", err)
+	}
+	return nil
+}
+
+// ValidatePersonalAccessToken implements services.PersonalAccessTokenValidator.
+// The role is read from the user on every request so role changes apply at once.
+func (u *PersonalAccessTokenUsecase) ValidatePersonalAccessToken(ctx context.Context, value string) (string, string, []string, error) {
+	token, err := u.repo.FindByHash(ctx, u.tokenHasher.Hash(value))
+	if err != nil {
+		return "", "", nil, errors.New("invalid token")
+	}
+
+	now := time.Now()
+	if now.After(token.ExpiresAt) {
+		return "", "", nil, errors.New("token has expired")
+	}
+
+	user, err := u.userRepo.GetUserByID(token.UserID)
+	if err != nil {
+		return "", "", nil, errors.New("invalid token")
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= lastUsedResolution {
+		if err := u.repo.TouchLastUsed(ctx, token.ID, now); err != nil {
+			fmt.Printf("Warning: Failed to update last use of token %s: %v\n", token.ID, err)
+		}
+	}
+
+	return token.UserID, user.Role, token.Scopes, nil
+}
+
+// validateScopes checks every scope is known and removes duplicates
+func validateScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, errors.New("at least one scope is required")
+	}
+
+	seen := make(map[string]bool, len(scopes))
+	valid := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		known := false
+		for _, s := range entities.PersonalAccessTokenScopes {
+			if scope == s {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("invalid scope: %s", scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			valid = append(valid, scope)
+		}
+	}
+	return valid, nil
+}
@@ -1,21 +1,37 @@
 package usecases
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	"g6_starter_project/Domain/entities"
+	"g6_starter_project/Infrastructure/services"
 )
 
+// passwordHistorySize is how many recent passwords, including the current one,
+// cannot be chosen again
+const passwordHistorySize = 5
+
 // UserProfileUsecase handles user profile operations
 type UserProfileUsecase struct {
-	userRepo entities.UserRepository
+	userRepo       entities.UserRepository
+	tokenUsecase   *TokenUsecase
+	emailService   *services.EmailService
+	passwordHasher services.PasswordHasher
+	passwordPolicy *services.PasswordPolicy
+	securityEvents *SecurityEventUsecase
 }
 
 // NewUserProfileUsecase creates a new user profile usecase
-func NewUserProfileUsecase(userRepo entities.UserRepository) *UserProfileUsecase {
+func NewUserProfileUsecase(userRepo entities.UserRepository, tokenUsecase *TokenUsecase, emailService *services.EmailService, passwordHasher services.PasswordHasher, passwordPolicy *services.PasswordPolicy, securityEvents *SecurityEventUsecase) *UserProfileUsecase {
 	return &UserProfileUsecase{
-		userRepo: userRepo,
+		userRepo:       userRepo,
+		tokenUsecase:   tokenUsecase,
+		emailService:   emailService,
+		passwordHasher: passwordHasher,
+		passwordPolicy: passwordPolicy,
+		securityEvents: securityEvents,
 	}
 }
 
@@ -58,21 +74,103 @@ func (u *UserProfileUsecase) UpdateUserProfile(userID string, updateData *entiti
 	}
 
 	// Note: We intentionally do NOT update:
-	// - Password (handled by password reset)
-	// - Email (handled by separate email update system)
+	// - Password (handled by ChangePassword and password reset)
+	// - Email (handled by EmailChangeUsecase, which confirms the new address)
 	// - Role (handled by admin promotion/demotion)
 
 	// Update timestamp
 	existingUser.UpdatedAt = time.Now()
 
-	// Update user in database
-	updatedUser, err := u.userRepo.UpdateUser(existingUser)
-	if err != nil {
+	// Store only the profile fields, so a password, MFA or email change made
+	// since the user was read is not overwritten
+	if err := u.userRepo.UpdateProfile(existingUser); err != nil {
 		return nil, fmt.Errorf("failed to update user: %v", err)
 	}
 
 	// Clear password from response for security
-	updatedUser.Password = ""
-	return updatedUser, nil
+	existingUser.Password = ""
+	return existingUser, nil
 }
 
+// ChangePassword replaces the password of a signed-in user and signs every
+// other session out. The current session stays signed in.
+func (u *UserProfileUsecase) ChangePassword(userID, sessionID, currentPassword, newPassword string, client entities.ClientInfo) error {
+	user, err := u.userRepo.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("user not found: %v", err)
+	}
+
+	if err := u.passwordHasher.ComparePassword(user.Password, currentPassword); err != nil {
+		u.recordPasswordChange(userID, entities.OutcomeFailure, "wrong current password", client)
+		return errors.New("current password is incorrect")
+	}
+
+	if err := u.passwordPolicy.Check(newPassword, passwordContext(user)); err != nil {
+		return err
+	}
+
+	if err := setPassword(u.passwordHasher, user, newPassword); err != nil {
+		return err
+	}
+
+	if err := u.userRepo.UpdatePassword(userID, user.Password, user.PasswordHistory); err != nil {
+		return fmt.Errorf("failed to update password: %v", err)
+	}
+	u.recordPasswordChange(userID, entities.OutcomeSuccess, "", client)
+
+	if err := u.tokenUsecase.RevokeOtherSessions(userID, sessionID); err != nil {
+		fmt.Printf("Warning: Failed to revoke sessions after password change: %v\n", err)
+	}
+
+	if err := u.emailService.SendPasswordChangeNotification(user.Email, user.FullName); err != nil {
+		fmt.Printf("Warning: Failed to send password change notification: %v\n", err)
+	}
+
+	return nil
+}
+
+func (u *UserProfileUsecase) recordPasswordChange(userID, outcome, details string, client entities.ClientInfo) {
+	u.securityEvents.Record(entities.SecurityEvent{
+		Type:     entities.SecurityEventPasswordChange,
+		Outcome:  outcome,
+		ActorID:  userID,
+		TargetID: userID,
+		Details:  details,
+	}, client)
+}
+
+// passwordContext is what the password policy needs to know about a user
+func passwordContext(user *entities.User) services.PasswordContext {
+	return services.PasswordContext{
+		Email:    user.Email,
+		Username: user.Username,
+		FullName: user.FullName,
+	}
+}
+
+// setPassword hashes a new password into the user, refusing any of the last
+// passwordHistorySize passwords, and moves the old hash into the history
+func setPassword(passwordHasher services.PasswordHasher, user *entities.User, newPassword string) error {
+	recent := append([]string{user.Password}, user.PasswordHistory...)
+	if len(recent) > passwordHistorySize {
+		recent = recent[:passwordHistorySize]
+	}
+	for _, hash := range recent {
+		if hash != "" && passwordHasher.ComparePassword(hash, newPassword) == nil {
+			return fmt.Errorf("new password must differ from your last %d passwords", passwordHistorySize)
+		}
+	}
+
+	hashedPassword, err := passwordHasher.HashPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %v", err)
+	}
+
+	history := recent
+	if len(history) > passwordHistorySize-1 {
+		history = history[:passwordHistorySize-1]
+	}
+	user.PasswordHistory = history
+	user.Password = hashedPassword
+	return nil
+}
@@ -0,0 +1,261 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+)
+
+// roleCacheTTL bounds how long another instance's role changes take to apply here
+const roleCacheTTL = 30 * time.Second
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,31}$`)
+
+// defaultRoles are created on startup if they do not exist yet
+var defaultRoles = []entities.Role{
+	{
+		Name:        entities.RoleAdmin,
+		Description: "Full access",
+		Permissions: entities.AllPermissions,
+	},
+	{
+		Name:        entities.RoleUser,
+		Description: "Writes and manages their own posts and comments",
+		Permissions: []string{},
+	},
+	{
+		Name:        entities.RoleModerator,
+		Description: "Deletes any post or comment",
+		Permissions: []string{entities.PermissionPostsDeleteAny, entities.PermissionCommentsDeleteAny},
+	},
+	{
+		Name:        entities.RoleEditor,
+		Description: "Edits any post",
+		Permissions: []string{entities.PermissionPostsUpdateAny},
+	},
+}
+
+// RoleUsecase manages roles and answers permission checks. It implements
+// entities.PermissionChecker with a short-lived cache so checks stay off the database.
+type RoleUsecase struct {
+	roleRepo entities.RoleRepository
+	userRepo entities.UserRepository
+
+	mutex    sync.RWMutex
+	cache    map[string]*entities.Role
+	cachedAt time.Time
+}
+
+// NewRoleUsecase initializes the role usecase
+func NewRoleUsecase(roleRepo entities.RoleRepository, userRepo entities.UserRepository) *RoleUsecase {
+	return &RoleUsecase{
+		roleRepo: roleRepo,
+		userRepo: userRepo,
+	}
+}
+
+// EnsureDefaultRoles creates the built-in roles. The admin role always keeps
+// every permission so the API cannot be locked out of role management.
+func (u *RoleUsecase) EnsureDefaultRoles(ctx context.Context) error {
+	now := time.Now()
+	for _, role := range defaultRoles {
+		role.BuiltIn = true
+		role.CreatedAt = now
+		role.UpdatedAt = now
+
+		var err error
+		if role.Name == entities.RoleAdmin {
+			err = u.roleRepo.Upsert(ctx, &role)
+		} else {
+			err = u.roleRepo.CreateIfMissing(ctx, &role)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create role %s: %v", role.Name, err)
+		}
+	}
+
+	u.invalidate()
+	return nil
+}
+
+// HasPermission reports whether a role grants a permission. Unknown roles grant nothing.
+func (u *RoleUsecase) HasPermission(ctx context.Context, roleName, permission string) (bool, error) {
+	roles, err := u.roles(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	role, ok := roles[roleName]
+	if !ok {
+		return false, nil
+	}
+	return role.HasPermission(permission), nil
+}
+
+// GrantsAll reports whether a role grants every permission of another role.
+// An unknown role grants nothing.
+func (u *RoleUsecase) GrantsAll(ctx context.Context, roleName, otherRoleName string) (bool, error) {
+	roles, err := u.roles(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	other, ok := roles[otherRoleName]
+	if !ok {
+		return true, nil
+	}
+	role, ok := roles[roleName]
+	if !ok {
+		return len(other.Permissions) == 0, nil
+	}
+	for _, permission := range other.Permissions {
+		if !role.HasPermission(permission) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// RoleExists reports whether a role is defined
+func (u *RoleUsecase) RoleExists(ctx context.Context, roleName string) (bool, error) {
+	roles, err := u.roles(ctx)
+	if err != nil {
+		return false, err
+	}
+	_, ok := roles[roleName]
+	return ok, nil
+}
+
+// GetRoles returns every role
+func (u *RoleUsecase) GetRoles() ([]entities.Role, error) {
+	return u.roleRepo.GetAll(context.Background())
+}
+
+// SaveRole creates a role or replaces its description and permissions
+func (u *RoleUsecase) SaveRole(name, description string, permissions []string) (*entities.Role, error) {
+	ctx := context.Background()
+
+	if !roleNamePattern.MatchString(name) {
+		return nil, errors.New("invalid role name")
+	}
+	if name == entities.RoleAdmin {
+		return nil, errors.New("the admin role cannot be changed")
+	}
+
+	permissions, err := validatePermissions(permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	role := &entities.Role{
+		Name:        name,
+		Description: description,
+		Permissions: permissions,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	existing, err := u.roleRepo.GetByName(ctx, name)
+	if err == nil {
+		role.BuiltIn = existing.BuiltIn
+		role.CreatedAt = existing.CreatedAt
+	}
+
+	if err := u.roleRepo.Upsert(ctx, role); err != nil {
+		return nil, fmt.Errorf("failed to save role: %v", err)
+	}
+
+	u.invalidate()
+	return role, nil
+}
+
+// DeleteRole deletes a custom role that no user has
+func (u *RoleUsecase) DeleteRole(name string) error {
+	ctx := context.Background()
+
+	role, err := u.roleRepo.GetByName(ctx, name)
+	if err != nil {
+		return err
+	}
+	if role.BuiltIn {
+		return errors.New("built-in roles cannot be deleted")
+	}
+
+	count, err := u.userRepo.CountUsersByRole(name)
+	if err != nil {
+		return fmt.Errorf("failed to delete role: %v", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("role is assigned to %d users", count)
+	}
+
+	if err := u.roleRepo.Delete(ctx, name); err != nil {
+		return err
+	}
+
+	u.invalidate()
+	return nil
+}
+
+// roles returns all roles by name, reloading them when the cache is stale
+func (u *RoleUsecase) roles(ctx context.Context) (map[string]*entities.Role, error) {
+	u.mutex.RLock()
+	if u.cache != nil && time.Since(u.cachedAt) < roleCacheTTL {
+		cache := u.cache
+		u.mutex.RUnlock()
+		return cache, nil
+	}
+	u.mutex.RUnlock()
+
+	all, err := u.roleRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load roles: %v", err)
+	}
+
+	cache := make(map[string]*entities.Role, len(all))
+	for i := range all {
+		cache[all[i].Name] = &all[i]
+	}
+
+	u.mutex.Lock()
+	u.cache = cache
+	u.cachedAt = time.Now()
+	u.mutex.Unlock()
+
+	return cache, nil
+}
+
+func (u *RoleUsecase) invalidate() {
+	u.mutex.Lock()
+	u.cache = nil
+	u.mutex.Unlock()
+}
+
+// validatePermissions checks every permission is known and removes duplicates
+func validatePermissions(permissions []string) ([]string, error) {
+	seen := make(map[string]bool, len(permissions))
+	valid := make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		known := false
+		for _, p := range entities.AllPermissions {
+			if permission == p {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("invalid permission: %s", permission)
+		}
+		if !seen[permission] {
+			seen[permission] = true
+			valid = append(valid, permission)
+		}
+	}
+	return valid, nil
+}
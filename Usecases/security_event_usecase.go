@@ -0,0 +1,79 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+
+	"github.com/google/uuid"
+)
+
+const maxSecurityEventPageSize = 100
+
+// SecurityEventUsecase writes and reads the audit log of authentication activity
+type SecurityEventUsecase struct {
+	repo entities.SecurityEventRepository
+}
+
+// NewSecurityEventUsecase initializes the security event usecase
+func NewSecurityEventUsecase(repo entities.SecurityEventRepository) *SecurityEventUsecase {
+	return &SecurityEventUsecase{repo: repo}
+}
+
+// Record appends an event, taking the IP address and user agent from the client.
+// A failure to write is only logged so it never blocks the action being audited.
+func (u *SecurityEventUsecase) Record(event entities.SecurityEvent, client entities.ClientInfo) {
+	event.ID = uuid.NewString()
+	event.IPAddress = client.IPAddress
+	event.UserAgent = client.UserAgent
+	event.CreatedAt = time.Now()
+
+	if err := u.repo.Create(context.Background(), &event); err != nil {
+		fmt.Printf("Warning: Failed to record %s security event: %v\n", event.Type, err)
+	}
+}
+
+// ListForUser returns the events about a user, newest first
+func (u *SecurityEventUsecase) ListForUser(userID string, page, limit int64) ([]entities.SecurityEvent, int64, error) {
+	return u.Query(entities.SecurityEventFilter{TargetID: userID, Page: page, Limit: limit})
+}
+
+// AllForUser returns every event about a user, newest first
+func (u *SecurityEventUsecase) AllForUser(userID string) ([]entities.SecurityEvent, error) {
+	events := []entities.SecurityEvent{}
+	for page := int64(1); ; page++ {
+		batch, total, err := u.ListForUser(userID, page, maxSecurityEventPageSize)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, batch...)
+		if len(batch) == 0 || int64(len(events)) >= total {
+			return events, nil
+		}
+	}
+}
+
+// Query returns the events matching a filter, newest first
+func (u *SecurityEventUsecase) Query(filter entities.SecurityEventFilter) ([]entities.SecurityEvent, int64, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 20
+	}
+	if filter.Limit > maxSecurityEventPageSize {
+		filter.Limit = maxSecurityEventPageSize
+	}
+	if filter.Outcome != "" && filter.Outcome != entities.OutcomeSuccess && filter.Outcome != entities.OutcomeFailure {
+		return nil, 0, errors.New("invalid outcome")
+	}
+
+	events, total, err := u.repo.Find(context.Background(), filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load security events: %v", err)
+	}
+	return events, total, nil
+}
@@ -2,48 +2,72 @@ package usecases
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"g6_starter_project/Domain/entities"
-	"g6_starter_project/Infrastructure/mongodb/repositories"
 	"g6_starter_project/Infrastructure/services"
 
 	"github.com/google/uuid" // For generating unique token ID
 )
 
+// impersonationTokenTTL keeps support sessions short; an admin asks for a new token to continue
+const impersonationTokenTTL = 10 * time.Minute
+
 // TokenUsecase handles token logic between services and database
 type TokenUsecase struct {
-	repo       *repositories.TokenRepository
-	jwtService *services.JWTService
+	repo              entities.TokenRepository
+	impersonationRepo entities.ImpersonationTokenRepository
+	userRepo          entities.UserRepository
+	jwtService        *services.JWTService
+	revocationStore   services.TokenRevocationStore
+	tokenHasher       *services.TokenHasher
 }
 
 // NewTokenUsecase creates a new usecase instance
-func NewTokenUsecase(repo *repositories.TokenRepository, jwtService *services.JWTService) *TokenUsecase {
+func NewTokenUsecase(repo entities.TokenRepository, impersonationRepo entities.ImpersonationTokenRepository, userRepo entities.UserRepository, jwtService *services.JWTService, revocationStore services.TokenRevocationStore, tokenHasher *services.TokenHasher) *TokenUsecase {
 	return &TokenUsecase{
-		repo:       repo,
-		jwtService: jwtService,
+		repo:              repo,
+		impersonationRepo: impersonationRepo,
+		userRepo:          userRepo,
+		jwtService:        jwtService,
+		revocationStore:   revocationStore,
+		tokenHasher:       tokenHasher,
 	}
 }
 
-// GenerateTokens creates new access & refresh tokens for a user
-func (u *TokenUsecase) GenerateTokens(userID, userRole string) (*entities.Token, error) {
+// GenerateTokens creates new access & refresh tokens for a user in a new session
+func (u *TokenUsecase) GenerateTokens(userID, userRole string, client entities.ClientInfo) (*entities.Token, error) {
 	now := time.Now()
+	sessionID := uuid.NewString()
+
+	// Suspended users cannot open a session, whichever way they sign in
+	if user, err := u.userRepo.GetUserByID(userID); err == nil && user.Suspension.Active(now) {
+		return nil, suspendedError(user.Suspension)
+	}
 
 	// Create access and refresh tokens
-	accessToken, refreshToken, err := u.jwtService.GenerateTokens(userID, userRole)
+	accessToken, refreshToken, err := u.jwtService.GenerateTokens(userID, userRole, sessionID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate tokens: %v", err)
 	}
 
-	// Prepare token entity to store in DB
+	// Prepare token entity to store in DB (only the refresh token hash is persisted)
 	token := &entities.Token{
-		ID:           uuid.NewString(),
-		UserID:       userID,
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresAt:    now.Add(7 * 24 * time.Hour), // Token expiry (7 days)
-		CreatedAt:    now,
+		ID:               sessionID,
+		UserID:           userID,
+		RefreshToken:     refreshToken,
+		RefreshTokenHash: u.tokenHasher.Hash(refreshToken),
+		DeviceLabel:      client.DeviceLabel,
+		UserAgent:        client.UserAgent,
+		IPAddress:        client.IPAddress,
+		ExpiresAt:        now.Add(7 * 24 * time.Hour), // Token expiry (7 days)
+		CreatedAt:        now,
+		LastUsedAt:       now,
+	}
+	if err := u.setAccessToken(token, accessToken); err != nil {
+		return nil, err
 	}
 
 	err = u.repo.Create(context.Background(), token)
@@ -54,26 +78,238 @@ func (u *TokenUsecase) GenerateTokens(userID, userRole string) (*entities.Token,
 	return token, nil
 }
 
-// RefreshToken validates existing refresh token and issues new tokens
-func (u *TokenUsecase) RefreshToken(userID string) (*entities.Token, error) {
-	// Get token from DB
-	token, err := u.repo.FindByUserID(context.Background(), userID)
+// GenerateImpersonationToken issues a short-lived access token for a user that
+// actorID acts on behalf of. It opens no session and cannot be refreshed, but
+// is recorded so that signing out either of them revokes it.
+func (u *TokenUsecase) GenerateImpersonationToken(userID, userRole, actorID string) (string, time.Time, error) {
+	accessToken, err := u.jwtService.GenerateImpersonationToken(userID, userRole, actorID, impersonationTokenTTL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find token: %v", err)
+		return "", time.Time{}, fmt.Errorf("failed to generate token: %v", err)
 	}
 
+	jti, expiresAt, err := u.jwtService.TokenID(accessToken)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to read access token: %v", err)
+	}
+
+	// A token that is not recorded could not be revoked, so it is not handed out
+	token := &entities.ImpersonationToken{
+		JTI:       jti,
+		ActorID:   actorID,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+	if err := u.impersonationRepo.Create(context.Background(), token); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to store token: %v", err)
+	}
+	return accessToken, expiresAt, nil
+}
+
+// RefreshToken validates a refresh token, rotates it and issues a new access token.
+// Presenting a refresh token that was already rotated revokes the whole family.
+func (u *TokenUsecase) RefreshToken(refreshToken string) (*entities.Token, error) {
+	ctx := context.Background()
+
 	// Validate the refresh token (is it expired, tampered, etc.)
-	_, err = u.jwtService.ValidateToken(token.RefreshToken)
+	claims, err := u.jwtService.ValidateRefreshToken(refreshToken)
 	if err != nil {
 		return nil, fmt.Errorf("invalid refresh token: %v", err)
 	}
+	sessionID := claims["sid"].(string)
+	userID, _ := claims["sub"].(string)
+
+	// Get token family from DB
+	token, err := u.repo.FindByID(ctx, sessionID)
+	if err != nil {
+		return nil, errors.New("invalid refresh token: session has been revoked")
+	}
+
+	if token.UserID != userID {
+		return nil, errors.New("invalid refresh token")
+	}
+
+	// A validly signed token that is no longer the current one has been rotated before
+	if !u.tokenHasher.Matches(refreshToken, token.RefreshTokenHash) {
+		u.revokeFamily(ctx, token)
+		return nil, errors.New("refresh token reuse detected: session has been revoked")
+	}
+
+	// Re-read the user so the new tokens carry the current role
+	user, err := u.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user: %v", err)
+	}
+	if user.Suspension.Active(time.Now()) {
+		u.revokeFamily(ctx, token)
+		return nil, suspendedError(user.Suspension)
+	}
+
+	accessToken, newRefreshToken, err := u.jwtService.GenerateTokens(userID, user.Role, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate tokens: %v", err)
+	}
+
+	previous := *token
+	if err := u.setAccessToken(token, accessToken); err != nil {
+		return nil, err
+	}
+	token.RefreshToken = newRefreshToken
+	token.RefreshTokenHash = u.tokenHasher.Hash(newRefreshToken)
+	token.ExpiresAt = time.Now().Add(7 * 24 * time.Hour)
+	token.LastUsedAt = time.Now()
+
+	rotated, err := u.repo.Rotate(ctx, sessionID, previous.RefreshTokenHash, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store token: %v", err)
+	}
+	if !rotated {
+		// Another request rotated this token first
+		if current, err := u.repo.FindByID(ctx, sessionID); err == nil {
+			u.revokeFamily(ctx, current)
+		}
+		return nil, errors.New("refresh token reuse detected: session has been revoked")
+	}
+
+	// The replaced access token should not outlive the rotation
+	u.revokeAccessToken(ctx, &previous)
+
+	return token, nil
+}
+
+// setAccessToken stores an access token on the family along with its "jti" and expiry
+func (u *TokenUsecase) setAccessToken(token *entities.Token, accessToken string) error {
+	jti, expiresAt, err := u.jwtService.TokenID(accessToken)
+	if err != nil {
+		return fmt.Errorf("failed to read access token: %v", err)
+	}
+
+	token.AccessToken = accessToken
+	token.AccessJTI = jti
+	token.AccessExpiresAt = expiresAt
+	return nil
+}
+
+// revokeAccessToken puts the family's current access token on the revocation list
+func (u *TokenUsecase) revokeAccessToken(ctx context.Context, token *entities.Token) {
+	if err := u.revocationStore.Revoke(ctx, token.AccessJTI, token.AccessExpiresAt); err != nil {
+		fmt.Printf("Warning: Failed to revoke access token of session %s: %v\n", token.ID, err)
+	}
+}
+
+// revokeFamily revokes the current access token and deletes every token issued for a session
+func (u *TokenUsecase) revokeFamily(ctx context.Context, token *entities.Token) {
+	u.revokeAccessToken(ctx, token)
+	if err := u.repo.DeleteByID(ctx, token.ID); err != nil {
+		fmt.Printf("Warning: Failed to revoke token family %s: %v\n", token.ID, err)
+	}
+}
+
+
+// ListSessions returns the user's active sessions, flagging the one making the request
+func (u *TokenUsecase) ListSessions(userID, currentSessionID string) ([]entities.Session, error) {
+	tokens, err := u.repo.FindByUserID(context.Background(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	sessions := []entities.Session{}
+	for i := range tokens {
+		if tokens[i].ExpiresAt.Before(now) {
+			continue
+		}
+		session := tokens[i].Session()
+		session.Current = session.ID == currentSessionID
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs a single device out, including its outstanding access token
+func (u *TokenUsecase) RevokeSession(userID, sessionID string) error {
+	ctx := context.Background()
+
+	token, err := u.repo.FindByID(ctx, sessionID)
+	if err != nil || token.UserID != userID {
+		return errors.New("session not found")
+	}
+	u.revokeAccessToken(ctx, token)
 
-	// Generate new access/refresh tokens and store again
-	return u.GenerateTokens(userID, "user")
+	deleted, err := u.repo.DeleteByIDForUser(ctx, sessionID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %v", err)
+	}
+	if !deleted {
+		return errors.New("session not found")
+	}
+	return nil
 }
 
+// Logout user ( remove the current session's tokens )
+func (u *TokenUsecase) Logout(userID, sessionID string) error {
+	if sessionID == "" {
+		return errors.New("session not found")
+	}
+	return u.RevokeSession(userID, sessionID)
+}
 
-// Logout user ( remove token )
-func (u *TokenUsecase) Logout(userID string) error {
-	return u.repo.DeleteByUserID(context.TODO(), userID)
-}
\ No newline at end of file
+// RevokeAllSessions signs a user out everywhere and revokes every outstanding
+// access token, including impersonation tokens the user holds as an admin or
+// that act as the user
+func (u *TokenUsecase) RevokeAllSessions(userID string) error {
+	ctx := context.Background()
+
+	tokens, err := u.repo.FindByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for i := range tokens {
+		u.revokeAccessToken(ctx, &tokens[i])
+	}
+
+	if err := u.repo.DeleteByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %v", err)
+	}
+
+	return u.revokeImpersonations(ctx, userID)
+}
+
+// revokeImpersonations puts the impersonation tokens issued to or acting as the
+// user on the revocation list
+func (u *TokenUsecase) revokeImpersonations(ctx context.Context, userID string) error {
+	tokens, err := u.impersonationRepo.FindByUser(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to find impersonation tokens: %v", err)
+	}
+	for _, token := range tokens {
+		if err := u.revocationStore.Revoke(ctx, token.JTI, token.ExpiresAt); err != nil {
+			return fmt.Errorf("failed to revoke impersonation token: %v", err)
+		}
+	}
+
+	if err := u.impersonationRepo.DeleteByUser(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke impersonation tokens: %v", err)
+	}
+	return nil
+}
+
+// RevokeOtherSessions signs a user out of every session except the given one
+func (u *TokenUsecase) RevokeOtherSessions(userID, keepSessionID string) error {
+	ctx := context.Background()
+
+	tokens, err := u.repo.FindByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for i := range tokens {
+		if tokens[i].ID == keepSessionID {
+			continue
+		}
+		u.revokeAccessToken(ctx, &tokens[i])
+		if _, err := u.repo.DeleteByIDForUser(ctx, tokens[i].ID, userID); err != nil {
+			return fmt.Errorf("failed to revoke sessions: %v", err)
+		}
+	}
+	return nil
+}
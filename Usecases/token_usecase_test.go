@@ -0,0 +1,119 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+	"g6_starter_project/Infrastructure/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// newTestJWTService signs with a key generated for the test
+func newTestJWTService(t *testing.T) *services.JWTService {
+	keys, err := services.NewKeyManager(&fakeSigningKeyRepository{}, services.AlgorithmEdDSA, 24*time.Hour, services.RefreshTokenTTL, "test-encryption-key")
+	require.NoError(t, err)
+	require.NoError(t, keys.Load(context.Background()))
+	return services.NewJWTService(keys)
+}
+
+type tokenTestSuite struct {
+	tokens     *TokenUsecase
+	repo       *fakeTokenRepository
+	users      *fakeUserRepository
+	revocation *services.InMemoryRevocationStore
+	jwtService *services.JWTService
+	user       *entities.User
+}
+
+func setupTokenTestSuite(t *testing.T) *tokenTestSuite {
+	user := &entities.User{ID: primitive.NewObjectID(), Role: entities.RoleAdmin}
+	users := newFakeUserRepository(user)
+	repo := newFakeTokenRepository()
+	revocation := services.NewInMemoryRevocationStore()
+	jwtService := newTestJWTService(t)
+
+	return &tokenTestSuite{
+		tokens:     NewTokenUsecase(repo, nil, users, jwtService, revocation, services.NewTokenHasher("test-hash-key")),
+		repo:       repo,
+		users:      users,
+		revocation: revocation,
+		jwtService: jwtService,
+		user:       user,
+	}
+}
+
+// isRevoked reports whether the access token's "jti" is on the revocation list
+func (ts *tokenTestSuite) isRevoked(t *testing.T, jti string) bool {
+	revoked, err := ts.revocation.IsRevoked(context.Background(), jti)
+	require.NoError(t, err)
+	return revoked
+}
+
+func TestTokenUsecase_RefreshToken(t *testing.T) {
+	t.Run("should rotate the refresh token and revoke the replaced access token", func(t *testing.T) {
+		ts := setupTokenTestSuite(t)
+		login, err := ts.tokens.GenerateTokens(ts.user.ID.Hex(), ts.user.Role, entities.ClientInfo{})
+		require.NoError(t, err)
+
+		refreshed, err := ts.tokens.RefreshToken(login.RefreshToken)
+		require.NoError(t, err)
+
+		assert.Equal(t, login.ID, refreshed.ID)
+		assert.NotEqual(t, login.RefreshToken, refreshed.RefreshToken)
+		assert.True(t, ts.isRevoked(t, login.AccessJTI))
+		assert.False(t, ts.isRevoked(t, refreshed.AccessJTI))
+	})
+
+	t.Run("should revoke the whole family when a rotated token is presented again", func(t *testing.T) {
+		ts := setupTokenTestSuite(t)
+		login, err := ts.tokens.GenerateTokens(ts.user.ID.Hex(), ts.user.Role, entities.ClientInfo{})
+		require.NoError(t, err)
+		refreshed, err := ts.tokens.RefreshToken(login.RefreshToken)
+		require.NoError(t, err)
+
+		_, err = ts.tokens.RefreshToken(login.RefreshToken)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "reuse detected")
+
+		assert.False(t, ts.repo.has(login.ID))
+		assert.True(t, ts.isRevoked(t, login.AccessJTI))
+		assert.True(t, ts.isRevoked(t, refreshed.AccessJTI))
+
+		// The current refresh token belongs to the revoked family as well
+		_, err = ts.tokens.RefreshToken(refreshed.RefreshToken)
+		assert.Error(t, err)
+	})
+
+	t.Run("should carry the user's current role rather than the one in the token", func(t *testing.T) {
+		ts := setupTokenTestSuite(t)
+		login, err := ts.tokens.GenerateTokens(ts.user.ID.Hex(), entities.RoleAdmin, entities.ClientInfo{})
+		require.NoError(t, err)
+
+		require.NoError(t, ts.users.SetRole(ts.user.ID.Hex(), entities.RoleUser))
+
+		refreshed, err := ts.tokens.RefreshToken(login.RefreshToken)
+		require.NoError(t, err)
+
+		claims, err := ts.jwtService.ValidateToken(refreshed.AccessToken)
+		require.NoError(t, err)
+		assert.Equal(t, entities.RoleUser, claims["role"])
+	})
+
+	t.Run("should refuse a token from another session", func(t *testing.T) {
+		ts := setupTokenTestSuite(t)
+		accessToken, _, err := ts.jwtService.GenerateTokens(ts.user.ID.Hex(), ts.user.Role, "unknown-session")
+		require.NoError(t, err)
+		_, refreshToken, err := ts.jwtService.GenerateTokens(ts.user.ID.Hex(), ts.user.Role, "unknown-session")
+		require.NoError(t, err)
+
+		_, err = ts.tokens.RefreshToken(accessToken)
+		assert.Error(t, err)
+		_, err = ts.tokens.RefreshToken(refreshToken)
+		assert.Error(t, err)
+	})
+}
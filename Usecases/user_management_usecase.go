@@ -1,74 +1,512 @@
 package usecases
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"g6_starter_project/Domain/entities"
+	"g6_starter_project/Infrastructure/services"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// suspensionCacheTTL bounds how long another instance's suspensions take to
+// apply here. Sessions are revoked at once; the delay only affects personal
+// access tokens and lifted suspensions.
+const suspensionCacheTTL = 30 * time.Second
+
+const (
+	maxUserPageSize = 100
+	// maxUserExportSize bounds a CSV export, which is built in memory
+	maxUserExportSize = 10000
 )
 
-// UserManagementUsecase handles user role changes and admin-only user operations
+// errAccountSuspended starts the error a suspended user gets when signing in
+const errAccountSuspended = "account is suspended"
+
+// UserManagementUsecase handles user role changes and admin-only user operations.
+// It implements services.SuspensionChecker with a short-lived cache of the
+// suspended users so checks stay off the database.
 type UserManagementUsecase struct {
-	userRepo entities.UserRepository
+	userRepo       entities.UserRepository
+	tokenUsecase   *TokenUsecase
+	roleUsecase    *RoleUsecase
+	emailService   *services.EmailService
+	securityEvents *SecurityEventUsecase
+
+	mutex     sync.RWMutex
+	suspended map[string]*entities.Suspension
+	cachedAt  time.Time
 }
 
 // NewUserManagementUsecase initializes the user management usecase
-func NewUserManagementUsecase(userRepo entities.UserRepository) *UserManagementUsecase {
+func NewUserManagementUsecase(userRepo entities.UserRepository, tokenUsecase *TokenUsecase, roleUsecase *RoleUsecase, emailService *services.EmailService, securityEvents *SecurityEventUsecase) *UserManagementUsecase {
 	return &UserManagementUsecase{
-		userRepo: userRepo,
+		userRepo:       userRepo,
+		tokenUsecase:   tokenUsecase,
+		roleUsecase:    roleUsecase,
+		emailService:   emailService,
+		securityEvents: securityEvents,
 	}
 }
 
-// PromoteUser upgrades a user to admin role
-func (u *UserManagementUsecase) PromoteUser(userID string) (*entities.User, error) {
+// PromoteUser upgrades a user to admin role. Only admins can promote.
+func (u *UserManagementUsecase) PromoteUser(adminID, userID string, client entities.ClientInfo) (*entities.User, error) {
 	user, err := u.userRepo.GetUserByID(userID)
 	if err != nil {
 		return nil, fmt.Errorf("user not found: %v", err)
 	}
 
-	if user.Role == "admin" {
+	if user.Role == entities.RoleAdmin {
 		return nil, errors.New("user is already an admin")
 	}
+	if err := u.checkRoleChange(adminID, user, entities.RoleAdmin); err != nil {
+		return nil, err
+	}
 
-	user.Role = "admin"
+	if err := u.userRepo.SetRole(userID, entities.RoleAdmin); err != nil {
+		return nil, fmt.Errorf("failed to promote user: %v", err)
+	}
+	user.Role = entities.RoleAdmin
 	user.UpdatedAt = time.Now()
+	u.recordRoleChange(adminID, userID, entities.RoleAdmin, client)
 
-	updatedUser, err := u.userRepo.UpdateUser(user)
+	user.Password = ""
+	return user, nil
+}
+
+// DemoteUser downgrades an admin to regular user. The last admin cannot be demoted.
+func (u *UserManagementUsecase) DemoteUser(adminID, userID string, client entities.ClientInfo) (*entities.User, error) {
+	user, err := u.userRepo.GetUserByID(userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to promote user: %v", err)
+		return nil, fmt.Errorf("user not found: %v", err)
+	}
+
+	if user.Role == entities.RoleUser {
+		return nil, errors.New("user is already a regular user")
+	}
+	if err := u.checkRoleChange(adminID, user, entities.RoleUser); err != nil {
+		return nil, err
+	}
+
+	if err := u.userRepo.SetRole(userID, entities.RoleUser); err != nil {
+		return nil, fmt.Errorf("failed to demote user: %v", err)
 	}
+	user.Role = entities.RoleUser
+	user.UpdatedAt = time.Now()
+	u.recordRoleChange(adminID, userID, entities.RoleUser, client)
 
-	updatedUser.Password = ""
-	return updatedUser, nil
+	// Outstanding tokens still carry the admin role
+	if err := u.tokenUsecase.RevokeAllSessions(userID); err != nil {
+		return nil, fmt.Errorf("user demoted but failed to revoke sessions: %v", err)
+	}
+
+	user.Password = ""
+	return user, nil
 }
 
-// DemoteUser downgrades an admin to regular user
-func (u *UserManagementUsecase) DemoteUser(userID string) (*entities.User, error) {
+// AssignRole gives a user any defined role, e.g. "moderator" or "editor"
+func (u *UserManagementUsecase) AssignRole(adminID, userID, role string, client entities.ClientInfo) (*entities.User, error) {
+	exists, err := u.roleUsecase.RoleExists(context.Background(), role)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New("role not found")
+	}
+
 	user, err := u.userRepo.GetUserByID(userID)
 	if err != nil {
 		return nil, fmt.Errorf("user not found: %v", err)
 	}
 
-	if user.Role == "user" {
-		return nil, errors.New("user is already a regular user")
+	if user.Role == role {
+		return nil, fmt.Errorf("user already has the %s role", role)
+	}
+	if err := u.checkRoleChange(adminID, user, role); err != nil {
+		return nil, err
 	}
 
-	user.Role = "user"
+	if err := u.userRepo.SetRole(userID, role); err != nil {
+		return nil, fmt.Errorf("failed to assign role: %v", err)
+	}
+	user.Role = role
 	user.UpdatedAt = time.Now()
+	u.recordRoleChange(adminID, userID, role, client)
 
-	updatedUser, err := u.userRepo.UpdateUser(user)
+	// Outstanding tokens still carry the old role
+	if err := u.tokenUsecase.RevokeAllSessions(userID); err != nil {
+		return nil, fmt.Errorf("role assigned but failed to revoke sessions: %v", err)
+	}
+
+	user.Password = ""
+	return user, nil
+}
+
+// checkRoleChange refuses to let adminID change their own role, give or take
+// away a role with permissions their own role lacks, or demote the last admin,
+// which would lock everyone out of role management. An empty adminID is an
+// operator running blogctl, who may give any role.
+func (u *UserManagementUsecase) checkRoleChange(adminID string, user *entities.User, role string) error {
+	if adminID == user.ID.Hex() {
+		return errors.New("cannot change your own role")
+	}
+
+	if adminID != "" {
+		admin, err := u.userRepo.GetUserByID(adminID)
+		if err != nil {
+			return errors.New("admin not found")
+		}
+		for _, changed := range []string{role, user.Role} {
+			if changed == entities.RoleAdmin && admin.Role != entities.RoleAdmin {
+				return errors.New("forbidden: only admins can give or take away the admin role")
+			}
+			covered, err := u.roleUsecase.GrantsAll(context.Background(), admin.Role, changed)
+			if err != nil {
+				return err
+			}
+			if !covered {
+				return fmt.Errorf("forbidden: the %s role has permissions your role lacks", changed)
+			}
+		}
+	}
+
+	if user.Role == entities.RoleAdmin && role != entities.RoleAdmin {
+		admins, err := u.userRepo.CountUsersByRole(entities.RoleAdmin)
+		if err != nil {
+			return fmt.Errorf("failed to count admins: %v", err)
+		}
+		if admins <= 1 {
+			return errors.New("cannot demote the last admin")
+		}
+	}
+	return nil
+}
+
+func (u *UserManagementUsecase) recordRoleChange(adminID, userID, role string, client entities.ClientInfo) {
+	u.securityEvents.Record(entities.SecurityEvent{
+		Type:     entities.SecurityEventRoleChange,
+		Outcome:  entities.OutcomeSuccess,
+		ActorID:  adminID,
+		TargetID: userID,
+		Details:  "role set to " + role,
+	}, client)
+}
+
+// Impersonation is a token an admin uses to act as another user
+type Impersonation struct {
+	User        *entities.User `json:"user"`
+	AccessToken string         `json:"accessToken"`
+	ExpiresAt   time.Time      `json:"expires_at"`
+}
+
+// Impersonate issues a short-lived token that lets an admin act as a user, for
+// reproducing problems without the user's password. Users who may impersonate
+// others themselves, including every admin, cannot be impersonated.
+func (u *UserManagementUsecase) Impersonate(adminID, userID string, client entities.ClientInfo) (*Impersonation, error) {
+	if adminID == userID {
+		return nil, errors.New("cannot impersonate yourself")
+	}
+
+	user, err := u.userRepo.GetUserByID(userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to demote user: %v", err)
+		return nil, errors.New("user not found")
+	}
+
+	privileged, err := u.roleUsecase.HasPermission(context.Background(), user.Role, entities.PermissionUsersImpersonate)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == entities.RoleAdmin || privileged {
+		return nil, errors.New("administrators cannot be impersonated")
+	}
+
+	accessToken, expiresAt, err := u.tokenUsecase.GenerateImpersonationToken(userID, user.Role, adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	u.securityEvents.Record(entities.SecurityEvent{
+		Type:     entities.SecurityEventImpersonation,
+		Outcome:  entities.OutcomeSuccess,
+		ActorID:  adminID,
+		TargetID: userID,
+		Details:  "token expires at " + expiresAt.UTC().Format(time.RFC3339),
+	}, client)
+
+	user.Password = ""
+	return &Impersonation{
+		User:        user,
+		AccessToken: accessToken,
+		ExpiresAt:   expiresAt,
+	}, nil
+}
+
+// Suspend keeps a user from signing in and using the API until the given time,
+// or until an admin lifts the suspension when until is nil. Every session is
+// revoked and the user is told by email. Suspending a suspended user replaces
+// the suspension. Users who may suspend others, including every admin, cannot
+// be suspended.
+func (u *UserManagementUsecase) Suspend(adminID, userID, reason string, until *time.Time, hidePosts bool, client entities.ClientInfo) (*entities.User, error) {
+	if adminID == userID {
+		return nil, errors.New("cannot suspend yourself")
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("reason is required")
+	}
+
+	now := time.Now()
+	if until != nil && !until.After(now) {
+		return nil, errors.New("suspension end must be in the future")
+	}
+
+	user, err := u.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
 	}
 
-	updatedUser.Password = ""
-	return updatedUser, nil
+	privileged, err := u.roleUsecase.HasPermission(context.Background(), user.Role, entities.PermissionUsersSuspend)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == entities.RoleAdmin || privileged {
+		return nil, errors.New("administrators cannot be suspended")
+	}
+
+	suspension := &entities.Suspension{
+		Reason:      reason,
+		Until:       until,
+		HidePosts:   hidePosts,
+		SuspendedBy: adminID,
+		SuspendedAt: now,
+	}
+	if err := u.userRepo.SetSuspension(userID, suspension); err != nil {
+		return nil, fmt.Errorf("failed to suspend user: %v", err)
+	}
+	u.invalidateSuspensions()
+
+	details := "reason: " + reason
+	if until != nil {
+		details += ", until " + until.UTC().Format(time.RFC3339)
+	}
+	u.securityEvents.Record(entities.SecurityEvent{
+		Type:     entities.SecurityEventSuspension,
+		Outcome:  entities.OutcomeSuccess,
+		ActorID:  adminID,
+		TargetID: userID,
+		Details:  details,
+	}, client)
+
+	if err := u.tokenUsecase.RevokeAllSessions(userID); err != nil {
+		return nil, fmt.Errorf("user suspended but failed to revoke sessions: %v", err)
+	}
+
+	if err := u.emailService.SendAccountSuspendedEmail(user.Email, user.FullName, reason, until); err != nil {
+		fmt.Printf("Warning: Failed to send suspension notice: %v\n", err)
+	}
+
+	user.Suspension = suspension
+	user.Password = ""
+	return user, nil
+}
+
+// Unsuspend lifts a user's suspension and tells the user by email
+func (u *UserManagementUsecase) Unsuspend(adminID, userID string, client entities.ClientInfo) (*entities.User, error) {
+	user, err := u.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if !user.Suspension.Active(time.Now()) {
+		return nil, errors.New("user is not suspended")
+	}
+
+	if err := u.userRepo.SetSuspension(userID, nil); err != nil {
+		return nil, fmt.Errorf("failed to lift suspension: %v", err)
+	}
+	u.invalidateSuspensions()
+
+	u.securityEvents.Record(entities.SecurityEvent{
+		Type:     entities.SecurityEventUnsuspension,
+		Outcome:  entities.OutcomeSuccess,
+		ActorID:  adminID,
+		TargetID: userID,
+	}, client)
+
+	if err := u.emailService.SendAccountRestoredEmail(user.Email, user.FullName); err != nil {
+		fmt.Printf("Warning: Failed to send suspension lifted notice: %v\n", err)
+	}
+
+	user.Suspension = nil
+	user.Password = ""
+	return user, nil
+}
+
+// IsSuspended reports whether a user is suspended right now
+func (u *UserManagementUsecase) IsSuspended(ctx context.Context, userID string) (bool, error) {
+	suspended, err := u.suspensions(ctx)
+	if err != nil {
+		return false, err
+	}
+	return suspended[userID].Active(time.Now()), nil
+}
+
+// HiddenAuthorIDs returns the suspended users whose posts are left out of post listings
+func (u *UserManagementUsecase) HiddenAuthorIDs(ctx context.Context) ([]primitive.ObjectID, error) {
+	suspended, err := u.suspensions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var ids []primitive.ObjectID
+	for userID, suspension := range suspended {
+		if !suspension.HidePosts || !suspension.Active(now) {
+			continue
+		}
+		if id, err := primitive.ObjectIDFromHex(userID); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
 }
 
-// GetAllUsers returns all registered users (admin-only)
-func (u *UserManagementUsecase) GetAllUsers() ([]*entities.User, error) {
-	return nil, errors.New("get all users not implemented yet")
+// suspensions returns the suspensions in force by user ID, reloading them when the cache is stale
+func (u *UserManagementUsecase) suspensions(ctx context.Context) (map[string]*entities.Suspension, error) {
+	u.mutex.RLock()
+	if u.suspended != nil && time.Since(u.cachedAt) < suspensionCacheTTL {
+		suspended := u.suspended
+		u.mutex.RUnlock()
+		return suspended, nil
+	}
+	u.mutex.RUnlock()
+
+	users, err := u.userRepo.GetSuspendedUsers(time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load suspended users: %v", err)
+	}
+
+	suspended := make(map[string]*entities.Suspension, len(users))
+	for i := range users {
+		suspended[users[i].ID.Hex()] = users[i].Suspension
+	}
+
+	u.mutex.Lock()
+	u.suspended = suspended
+	u.cachedAt = time.Now()
+	u.mutex.Unlock()
+
+	return suspended, nil
+}
+
+func (u *UserManagementUsecase) invalidateSuspensions() {
+	u.mutex.Lock()
+	u.suspended = nil
+	u.mutex.Unlock()
+}
+
+// suspendedError tells a suspended user why and until when they cannot sign in
+func suspendedError(suspension *entities.Suspension) error {
+	message := errAccountSuspended
+	if suspension.Until != nil {
+		message += " until " + suspension.Until.UTC().Format("2006-01-02 15:04 MST")
+	}
+	if suspension.Reason != "" {
+		message += ": " + suspension.Reason
+	}
+	return errors.New(message)
+}
+
+// GetAllUsers returns a page of the users matching the filter, along with the
+// number of matches (admin-only)
+func (u *UserManagementUsecase) GetAllUsers(filter entities.UserFilter) ([]entities.User, int64, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 20
+	}
+	if filter.Limit > maxUserPageSize {
+		filter.Limit = maxUserPageSize
+	}
+	if err := validateUserFilter(&filter); err != nil {
+		return nil, 0, err
+	}
+
+	users, total, err := u.userRepo.FindUsers(filter, time.Now())
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load users: %v", err)
+	}
+
+	for i := range users {
+		users[i].Password = ""
+	}
+	return users, total, nil
+}
+
+// ExportUsers returns every user matching the filter, for a CSV export. Large
+// directories must be narrowed down with filters first.
+func (u *UserManagementUsecase) ExportUsers(filter entities.UserFilter) ([]entities.User, error) {
+	if err := validateUserFilter(&filter); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	filter.Limit = maxUserPageSize
+	var users []entities.User
+	for filter.Page = 1; ; filter.Page++ {
+		batch, total, err := u.userRepo.FindUsers(filter, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load users: %v", err)
+		}
+		if total > maxUserExportSize {
+			return nil, fmt.Errorf("too many users to export (%d). narrow the filter to at most %d", total, maxUserExportSize)
+		}
+
+		users = append(users, batch...)
+		if len(batch) == 0 || int64(len(users)) >= total {
+			break
+		}
+	}
+
+	for i := range users {
+		users[i].Password = ""
+	}
+	return users, nil
+}
+
+// validateUserFilter checks the sort options and fills in their defaults: newest
+// first, or alphabetical when sorting by a name or email
+func validateUserFilter(filter *entities.UserFilter) error {
+	switch filter.SortBy {
+	case "":
+		filter.SortBy = entities.UserSortCreatedAt
+	case entities.UserSortCreatedAt, entities.UserSortFullName, entities.UserSortUsername, entities.UserSortEmail:
+	default:
+		return errors.New("invalid sort field")
+	}
+
+	switch filter.Order {
+	case "":
+		filter.Order = "asc"
+		if filter.SortBy == entities.UserSortCreatedAt {
+			filter.Order = "desc"
+		}
+	case "asc", "desc":
+	default:
+		return errors.New("invalid sort order")
+	}
+
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && filter.CreatedFrom.After(*filter.CreatedTo) {
+		return errors.New("created_from must be before created_to")
+	}
+	return nil
 }
 
 // GetUserByID returns a user by ID
@@ -0,0 +1,147 @@
+package usecases
+
+import (
+	"testing"
+
+	"g6_starter_project/Domain/entities"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newTestUser(role string) *entities.User {
+	return &entities.User{ID: primitive.NewObjectID(), Username: role + "-" + primitive.NewObjectID().Hex(), Role: role}
+}
+
+type userManagementTestSuite struct {
+	users   *fakeUserRepository
+	usecase *UserManagementUsecase
+}
+
+func setupUserManagementTestSuite(t *testing.T, users ...*entities.User) *userManagementTestSuite {
+	repo := newFakeUserRepository(users...)
+	roles := newTestPermissions(t)
+	events := NewSecurityEventUsecase(&fakeSecurityEventRepository{})
+	return &userManagementTestSuite{
+		users:   repo,
+		usecase: NewUserManagementUsecase(repo, nil, roles, nil, events),
+	}
+}
+
+func TestUserManagementUsecase_RoleChanges(t *testing.T) {
+	admin := newTestUser(entities.RoleAdmin)
+	manager := newTestUser(testRoleManager)
+	user := newTestUser(entities.RoleUser)
+	ts := setupUserManagementTestSuite(t, admin, manager, user)
+
+	tests := []struct {
+		name      string
+		change    func() (*entities.User, error)
+		wantError string
+	}{
+		{
+			"should refuse to promote yourself",
+			func() (*entities.User, error) {
+				return ts.usecase.PromoteUser(manager.ID.Hex(), manager.ID.Hex(), entities.ClientInfo{})
+			},
+			"cannot change your own role",
+		},
+		{
+			"should refuse to give yourself a role",
+			func() (*entities.User, error) {
+				return ts.usecase.AssignRole(manager.ID.Hex(), manager.ID.Hex(), entities.RoleModerator, entities.ClientInfo{})
+			},
+			"cannot change your own role",
+		},
+		{
+			"should refuse to demote yourself",
+			func() (*entities.User, error) {
+				return ts.usecase.DemoteUser(admin.ID.Hex(), admin.ID.Hex(), entities.ClientInfo{})
+			},
+			"cannot change your own role",
+		},
+		{
+			"should let only admins promote",
+			func() (*entities.User, error) {
+				return ts.usecase.PromoteUser(manager.ID.Hex(), user.ID.Hex(), entities.ClientInfo{})
+			},
+			"forbidden: only admins can give or take away the admin role",
+		},
+		{
+			"should let only admins give the admin role",
+			func() (*entities.User, error) {
+				return ts.usecase.AssignRole(manager.ID.Hex(), user.ID.Hex(), entities.RoleAdmin, entities.ClientInfo{})
+			},
+			"forbidden: only admins can give or take away the admin role",
+		},
+		{
+			"should let only admins demote an admin",
+			func() (*entities.User, error) {
+				return ts.usecase.DemoteUser(manager.ID.Hex(), admin.ID.Hex(), entities.ClientInfo{})
+			},
+			"forbidden: only admins can give or take away the admin role",
+		},
+		{
+			"should refuse a role with permissions the caller lacks",
+			func() (*entities.User, error) {
+				return ts.usecase.AssignRole(manager.ID.Hex(), user.ID.Hex(), entities.RoleModerator, entities.ClientInfo{})
+			},
+			"forbidden: the moderator role has permissions your role lacks",
+		},
+		{
+			"should refuse to demote the last admin",
+			func() (*entities.User, error) {
+				return ts.usecase.DemoteUser("", admin.ID.Hex(), entities.ClientInfo{})
+			},
+			"cannot demote the last admin",
+		},
+		{
+			"should refuse to give the last admin another role",
+			func() (*entities.User, error) {
+				return ts.usecase.AssignRole("", admin.ID.Hex(), entities.RoleEditor, entities.ClientInfo{})
+			},
+			"cannot demote the last admin",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.change()
+			assert.EqualError(t, err, tt.wantError)
+		})
+	}
+
+	t.Run("should leave every role unchanged", func(t *testing.T) {
+		for _, u := range []*entities.User{admin, manager, user} {
+			stored, err := ts.users.GetUserByID(u.ID.Hex())
+			require.NoError(t, err)
+			assert.Equal(t, u.Role, stored.Role)
+		}
+	})
+}
+
+func TestUserManagementUsecase_PromoteUser(t *testing.T) {
+	t.Run("should let an admin promote another user", func(t *testing.T) {
+		admin := newTestUser(entities.RoleAdmin)
+		user := newTestUser(entities.RoleUser)
+		ts := setupUserManagementTestSuite(t, admin, user)
+
+		promoted, err := ts.usecase.PromoteUser(admin.ID.Hex(), user.ID.Hex(), entities.ClientInfo{})
+		require.NoError(t, err)
+		assert.Equal(t, entities.RoleAdmin, promoted.Role)
+
+		count, err := ts.users.CountUsersByRole(entities.RoleAdmin)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), count)
+	})
+
+	t.Run("should let blogctl promote without an admin", func(t *testing.T) {
+		user := newTestUser(entities.RoleUser)
+		ts := setupUserManagementTestSuite(t, user)
+
+		promoted, err := ts.usecase.PromoteUser("", user.ID.Hex(), entities.ClientInfo{})
+		require.NoError(t, err)
+		assert.Equal(t, entities.RoleAdmin, promoted.Role)
+	})
+}
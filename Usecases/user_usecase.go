@@ -1,8 +1,12 @@
 package usecases
 
 import (
+	"context"
 	"errors"
-	// "time"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	"g6_starter_project/Domain/entities"
 	"g6_starter_project/Infrastructure/services"
@@ -15,52 +19,234 @@ type userInterface interface {
 	Login(user *entities.User) (*entities.User, error)
 }
 
+// Login throttling. Failures are counted per account and per IP address; an
+// account is slowed down first and then locked, an IP address is locked outright.
+const (
+	loginFailureWindow      = 15 * time.Minute
+	loginDelayAfterFailures = 3
+	maxLoginDelay           = time.Minute
+	accountLockoutFailures  = 10
+	accountLockoutDuration  = 15 * time.Minute
+	ipLockoutFailures       = 50
+	ipLockoutDuration       = 15 * time.Minute
+)
+
+const (
+	// errInvalidCredentials is the only error a failed login returns, so it does
+	// not reveal whether an account exists
+	errInvalidCredentials   = "invalid email or password"
+	errTooManyLoginAttempts = "too many failed login attempts. please try again later"
+)
+
 type UserUsecase struct {
-	userRepo     entities.UserRepository
-	tokenUsecase *TokenUsecase
+	userRepo       entities.UserRepository
+	tokenUsecase   *TokenUsecase
+	mfaUsecase     *MFAUsecase
+	attemptStore   services.LoginAttemptStore
+	emailService   *services.EmailService
+	passwordHasher services.PasswordHasher
+	securityEvents *SecurityEventUsecase
+
+	dummyPasswordHash     string
+	dummyPasswordHashOnce sync.Once
 }
 
-func NewUserUsecase(userRepo entities.UserRepository, tokenUsecase *TokenUsecase) *UserUsecase {
+func NewUserUsecase(userRepo entities.UserRepository, tokenUsecase *TokenUsecase, mfaUsecase *MFAUsecase, attemptStore services.LoginAttemptStore, emailService *services.EmailService, passwordHasher services.PasswordHasher, securityEvents *SecurityEventUsecase) *UserUsecase {
 	return &UserUsecase{
-		userRepo:     userRepo,
-		tokenUsecase: tokenUsecase,
+		userRepo:       userRepo,
+		tokenUsecase:   tokenUsecase,
+		mfaUsecase:     mfaUsecase,
+		attemptStore:   attemptStore,
+		emailService:   emailService,
+		passwordHasher: passwordHasher,
+		securityEvents: securityEvents,
 	}
 }
 
-// Login checks credentials and returns user + tokens if valid
-func (u *UserUsecase) Login(user *entities.User) (*entities.User, *entities.Token, error) {
+// Login checks credentials and returns user + tokens for a new session if valid.
+// When a second factor is needed it returns only an MFA challenge instead.
+func (u *UserUsecase) Login(user *entities.User, client entities.ClientInfo) (*entities.User, *entities.Token, *MFAChallenge, error) {
 	if !utils.IsValidEmail(user.Email) {
-		return nil, nil, errors.New("invalid email format")
+		return nil, nil, nil, errors.New("invalid email format")
+	}
+
+	ctx := context.Background()
+	accountKey := "account:" + strings.ToLower(user.Email)
+
+	// Locks are checked by email, whether or not the account exists
+	if u.isLocked(ctx, accountKey) || (client.IPAddress != "" && u.isLocked(ctx, "ip:"+client.IPAddress)) {
+		u.recordLogin(entities.OutcomeFailure, nil, user.Email, "locked out", client)
+		return nil, nil, nil, errors.New(errTooManyLoginAttempts)
 	}
 
 	existingUser, err := u.userRepo.GetUserByEmail(user.Email)
 	if err != nil {
-		return nil, nil, err
+		// Spend the same time as a real password check
+		u.passwordHasher.ComparePassword(u.dummyHash(), user.Password)
+		u.recordFailure(ctx, accountKey, client.IPAddress, nil)
+		u.recordLogin(entities.OutcomeFailure, nil, user.Email, "unknown account", client)
+		return nil, nil, nil, errors.New(errInvalidCredentials)
+	}
+
+	// Compare entered password with stored hash
+	err = u.passwordHasher.ComparePassword(existingUser.Password, user.Password)
+	if err != nil {
+		u.recordFailure(ctx, accountKey, client.IPAddress, existingUser)
+		u.recordLogin(entities.OutcomeFailure, existingUser, "", "wrong password", client)
+		return nil, nil, nil, errors.New(errInvalidCredentials)
 	}
 
-	// Check if user is verified
+	// The plain password is only available now, so upgrade outdated hashes here
+	if u.passwordHasher.NeedsRehash(existingUser.Password) {
+		u.rehashPassword(existingUser, user.Password)
+	}
+
+	if err := u.attemptStore.Reset(ctx, accountKey); err != nil {
+		fmt.Printf("Warning: Failed to reset failed login attempts: %v\n", err)
+	}
+
+	// Only reported once the password is known to be right
 	if !existingUser.IsVerified {
-		return nil, nil, errors.New("account not verified. Please check your email and verify your account")
+		u.recordLogin(entities.OutcomeFailure, existingUser, "", "account not verified", client)
+		return nil, nil, nil, errors.New("account not verified. Please check your email and verify your account")
 	}
 
-	// Compare entered password with stored hash
-	bcryptService := services.NewBcryptService(10)
-	err = bcryptService.ComparePassword(existingUser.Password, user.Password)
+	if existingUser.Suspension.Active(time.Now()) {
+		u.recordLogin(entities.OutcomeFailure, existingUser, "", "account suspended", client)
+		return nil, nil, nil, suspendedError(existingUser.Suspension)
+	}
+
+	// Hold the tokens back until the second factor is verified
+	challenge, err := u.mfaUsecase.Challenge(existingUser)
 	if err != nil {
-		return nil, nil, errors.New("invalid password")
+		return nil, nil, nil, err
+	}
+	if challenge != nil {
+		return nil, nil, challenge, nil
 	}
 
 	// Generate JWT access & refresh tokens
-	token, err := u.tokenUsecase.GenerateTokens(existingUser.ID.Hex(), existingUser.Role)
+	token, err := u.tokenUsecase.GenerateTokens(existingUser.ID.Hex(), existingUser.Role, client)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
+	u.recordLogin(entities.OutcomeSuccess, existingUser, "", "password", client)
 
 	existingUser.Password = ""
-	return existingUser, token, nil
+	return existingUser, token, nil, nil
+}
+
+// recordLogin adds a password login attempt to the audit log. The email is only
+// recorded when no account matched it.
+func (u *UserUsecase) recordLogin(outcome string, user *entities.User, email, details string, client entities.ClientInfo) {
+	event := entities.SecurityEvent{
+		Type:    entities.SecurityEventLogin,
+		Outcome: outcome,
+		Email:   strings.ToLower(email),
+		Details: details,
+	}
+	if user != nil {
+		event.ActorID = user.ID.Hex()
+		event.TargetID = user.ID.Hex()
+		event.Email = ""
+	}
+	u.securityEvents.Record(event, client)
 }
 
-// logout user
-func (u *UserUsecase) Logout(userID string) error {
-	return u.tokenUsecase.Logout(userID)
+// isLocked reports whether logins for a key are blocked. Store errors let the
+// login through so an outage does not lock everyone out.
+func (u *UserUsecase) isLocked(ctx context.Context, key string) bool {
+	lockedUntil, err := u.attemptStore.LockedUntil(ctx, key)
+	if err != nil {
+		fmt.Printf("Warning: Failed to check login lock: %v\n", err)
+		return false
+	}
+	return !lockedUntil.IsZero()
+}
+
+// recordFailure counts a failed login against the account and the IP address.
+// From the third account failure each attempt must wait twice as long as the
+// last; at the tenth the account is locked and its owner (if any) is emailed.
+func (u *UserUsecase) recordFailure(ctx context.Context, accountKey, ipAddress string, owner *entities.User) {
+	now := time.Now()
+
+	failures, err := u.attemptStore.RecordFailure(ctx, accountKey, loginFailureWindow)
+	if err != nil {
+		fmt.Printf("Warning: Failed to record failed login: %v\n", err)
+	} else if failures >= accountLockoutFailures {
+		lockedUntil := now.Add(accountLockoutDuration)
+		if err := u.attemptStore.Lock(ctx, accountKey, lockedUntil); err != nil {
+			fmt.Printf("Warning: Failed to lock account: %v\n", err)
+		}
+		// Only the failure that starts the lockout sends an email
+		if failures == accountLockoutFailures && owner != nil {
+			if err := u.emailService.SendAccountLockedEmail(owner.Email, owner.FullName, lockedUntil); err != nil {
+				fmt.Printf("Warning: Failed to send account locked email: %v\n", err)
+			}
+		}
+	} else if failures >= loginDelayAfterFailures {
+		delay := time.Second << uint(failures-loginDelayAfterFailures)
+		if delay > maxLoginDelay {
+			delay = maxLoginDelay
+		}
+		if err := u.attemptStore.Lock(ctx, accountKey, now.Add(delay)); err != nil {
+			fmt.Printf("Warning: Failed to delay logins: %v\n", err)
+		}
+	}
+
+	if ipAddress == "" {
+		return
+	}
+	ipKey := "ip:" + ipAddress
+	failures, err = u.attemptStore.RecordFailure(ctx, ipKey, loginFailureWindow)
+	if err != nil {
+		fmt.Printf("Warning: Failed to record failed login: %v\n", err)
+	} else if failures >= ipLockoutFailures {
+		if err := u.attemptStore.Lock(ctx, ipKey, now.Add(ipLockoutDuration)); err != nil {
+			fmt.Printf("Warning: Failed to lock IP address: %v\n", err)
+		}
+	}
+}
+
+// rehashPassword stores a hash of the password made with the current algorithm
+// and parameters. Failures only mean the upgrade is retried at the next login.
+func (u *UserUsecase) rehashPassword(user *entities.User, password string) {
+	hashedPassword, err := u.passwordHasher.HashPassword(password)
+	if err != nil {
+		fmt.Printf("Warning: Failed to rehash password: %v\n", err)
+		return
+	}
+	if err := u.userRepo.UpdatePasswordHash(user.ID.Hex(), user.Password, hashedPassword); err != nil {
+		fmt.Printf("Warning: Failed to store rehashed password: %v\n", err)
+		return
+	}
+	user.Password = hashedPassword
+}
+
+// dummyHash returns a hash to compare against when the account does not exist
+func (u *UserUsecase) dummyHash() string {
+	u.dummyPasswordHashOnce.Do(func() {
+		u.dummyPasswordHash, _ = u.passwordHasher.HashPassword("not-a-real-password")
+	})
+	return u.dummyPasswordHash
+}
+
+// RefreshToken rotates a refresh token and returns the new token pair
+func (u *UserUsecase) RefreshToken(refreshToken string) (*entities.Token, error) {
+	return u.tokenUsecase.RefreshToken(refreshToken)
+}
+
+// logout user from the current session
+func (u *UserUsecase) Logout(userID, sessionID string, client entities.ClientInfo) error {
+	if err := u.tokenUsecase.Logout(userID, sessionID); err != nil {
+		return err
+	}
+
+	u.securityEvents.Record(entities.SecurityEvent{
+		Type:     entities.SecurityEventLogout,
+		Outcome:  entities.OutcomeSuccess,
+		ActorID:  userID,
+		TargetID: userID,
+	}, client)
+	return nil
 }
\ No newline at end of file
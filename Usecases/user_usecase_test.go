@@ -0,0 +1,159 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"g6_starter_project/Domain/entities"
+	"g6_starter_project/Infrastructure/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type loginTestSuite struct {
+	users        *UserUsecase
+	attemptStore *services.InMemoryLoginAttemptStore
+	events       *fakeSecurityEventRepository
+}
+
+func setupLoginTestSuite(t *testing.T) *loginTestSuite {
+	passwordHasher, err := services.NewPasswordHasher(services.PasswordHasherConfig{
+		Algorithm:  services.PasswordHashBcrypt,
+		BcryptCost: bcrypt.MinCost,
+		Argon2id:   services.DefaultArgon2idParams,
+	})
+	require.NoError(t, err)
+
+	hash, err := passwordHasher.HashPassword("Correct-Horse-1")
+	require.NoError(t, err)
+	user := &entities.User{
+		ID:         primitive.NewObjectID(),
+		Email:      "jane@example.com",
+		Password:   hash,
+		Role:       entities.RoleUser,
+		IsVerified: false,
+	}
+
+	attemptStore := services.NewInMemoryLoginAttemptStore()
+	events := &fakeSecurityEventRepository{}
+	users := NewUserUsecase(newFakeUserRepository(user), nil, nil, attemptStore, nil, passwordHasher, NewSecurityEventUsecase(events))
+
+	return &loginTestSuite{
+		users:        users,
+		attemptStore: attemptStore,
+		events:       events,
+	}
+}
+
+// lockedFor returns how much longer logins for the key are blocked
+func (ts *loginTestSuite) lockedFor(t *testing.T, key string) time.Duration {
+	lockedUntil, err := ts.attemptStore.LockedUntil(context.Background(), key)
+	require.NoError(t, err)
+	if lockedUntil.IsZero() {
+		return 0
+	}
+	return time.Until(lockedUntil)
+}
+
+func TestUserUsecase_RecordFailureBackoff(t *testing.T) {
+	// The delay each failure of an account imposes on the next attempt
+	tests := []struct {
+		failures int
+		delay    time.Duration
+	}{
+		{1, 0},
+		{2, 0},
+		{3, time.Second},
+		{4, 2 * time.Second},
+		{5, 4 * time.Second},
+		{6, 8 * time.Second},
+		{7, 16 * time.Second},
+		{8, 32 * time.Second},
+		{9, maxLoginDelay},
+		{10, accountLockoutDuration},
+	}
+
+	ts := setupLoginTestSuite(t)
+	ctx := context.Background()
+	for _, tt := range tests {
+		ts.users.recordFailure(ctx, "account:jane@example.com", "", nil)
+
+		lockedFor := ts.lockedFor(t, "account:jane@example.com")
+		assert.InDelta(t, tt.delay.Seconds(), lockedFor.Seconds(), 1, "after %d failures", tt.failures)
+	}
+}
+
+func TestUserUsecase_RecordFailureIPLockout(t *testing.T) {
+	ts := setupLoginTestSuite(t)
+	ctx := context.Background()
+
+	t.Run("should lock an IP address failing across many accounts", func(t *testing.T) {
+		for i := 1; i < ipLockoutFailures; i++ {
+			ts.users.recordFailure(ctx, "account:"+primitive.NewObjectID().Hex(), "203.0.113.7", nil)
+		}
+		assert.Zero(t, ts.lockedFor(t, "ip:203.0.113.7"))
+
+		ts.users.recordFailure(ctx, "account:"+primitive.NewObjectID().Hex(), "203.0.113.7", nil)
+		assert.InDelta(t, ipLockoutDuration.Seconds(), ts.lockedFor(t, "ip:203.0.113.7").Seconds(), 1)
+	})
+}
+
+func TestUserUsecase_LoginLockout(t *testing.T) {
+	client := entities.ClientInfo{IPAddress: "198.51.100.1"}
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		lock     string
+		want     string
+	}{
+		{"should refuse a locked account before checking the password", "jane@example.com", "Correct-Horse-1", "account:jane@example.com", errTooManyLoginAttempts},
+		{"should match the account lock case-insensitively", "JANE@example.com", "Correct-Horse-1", "account:jane@example.com", errTooManyLoginAttempts},
+		{"should refuse a locked IP address", "jane@example.com", "Correct-Horse-1", "ip:198.51.100.1", errTooManyLoginAttempts},
+		{"should lock unknown accounts too", "nobody@example.com", "Correct-Horse-1", "account:nobody@example.com", errTooManyLoginAttempts},
+		{"should refuse a wrong password", "jane@example.com", "Wrong-Horse-1", "", errInvalidCredentials},
+		{"should refuse an unknown account the same way", "nobody@example.com", "Correct-Horse-1", "", errInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := setupLoginTestSuite(t)
+			if tt.lock != "" {
+				require.NoError(t, ts.attemptStore.Lock(context.Background(), tt.lock, time.Now().Add(time.Minute)))
+			}
+
+			_, _, _, err := ts.users.Login(&entities.User{Email: tt.email, Password: tt.password}, client)
+			require.Error(t, err)
+			assert.Equal(t, tt.want, err.Error())
+		})
+	}
+
+	t.Run("should forget failures after the right password", func(t *testing.T) {
+		ts := setupLoginTestSuite(t)
+		ctx := context.Background()
+		for i := 0; i < loginDelayAfterFailures-1; i++ {
+			ts.users.recordFailure(ctx, "account:jane@example.com", "", nil)
+		}
+
+		// The test user is unverified, so the login stops after the password check
+		_, _, _, err := ts.users.Login(&entities.User{Email: "jane@example.com", Password: "Correct-Horse-1"}, client)
+		require.Error(t, err)
+		assert.NotEqual(t, errInvalidCredentials, err.Error())
+
+		ts.users.recordFailure(ctx, "account:jane@example.com", "", nil)
+		assert.Zero(t, ts.lockedFor(t, "account:jane@example.com"))
+	})
+
+	t.Run("should record locked out attempts", func(t *testing.T) {
+		ts := setupLoginTestSuite(t)
+		require.NoError(t, ts.attemptStore.Lock(context.Background(), "account:jane@example.com", time.Now().Add(time.Minute)))
+
+		ts.users.Login(&entities.User{Email: "jane@example.com", Password: "Correct-Horse-1"}, client)
+		assert.Equal(t, 1, ts.events.count(entities.SecurityEventLogin, entities.OutcomeFailure, "locked out"))
+	})
+}
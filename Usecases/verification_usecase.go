@@ -8,15 +8,26 @@ import (
 	"g6_starter_project/Infrastructure/services"
 )
 
+// verificationTokenTTL is how long an email verification link stays valid
+const verificationTokenTTL = 24 * time.Hour
+
 type VerificationUsecase struct {
-	userRepo    entities.UserRepository
-	emailService *services.EmailService
+	userRepo       entities.UserRepository
+	emailService   *services.EmailService
+	oneTimeTokens  *OneTimeTokenUsecase
+	passwordHasher services.PasswordHasher
+	passwordPolicy *services.PasswordPolicy
+	securityEvents *SecurityEventUsecase
 }
 
-func NewVerificationUsecase(userRepo entities.UserRepository, emailService *services.EmailService) *VerificationUsecase {
+func NewVerificationUsecase(userRepo entities.UserRepository, emailService *services.EmailService, oneTimeTokens *OneTimeTokenUsecase, passwordHasher services.PasswordHasher, passwordPolicy *services.PasswordPolicy, securityEvents *SecurityEventUsecase) *VerificationUsecase {
 	return &VerificationUsecase{
-		userRepo:     userRepo,
-		emailService: emailService,
+		userRepo:       userRepo,
+		emailService:   emailService,
+		oneTimeTokens:  oneTimeTokens,
+		passwordHasher: passwordHasher,
+		passwordPolicy: passwordPolicy,
+		securityEvents: securityEvents,
 	}
 }
 
@@ -24,30 +35,27 @@ func NewVerificationUsecase(userRepo entities.UserRepository, emailService *serv
 func (v *VerificationUsecase) RegisterWithVerification(user *entities.User) (*entities.User, error) {
 	// Set user as unverified initially
 	user.IsVerified = false
+	user.Role = entities.DefaultRole
+
+	if err := v.passwordPolicy.Check(user.Password, passwordContext(user)); err != nil {
+		return nil, err
+	}
 	
 	// Hash the password before storing
-	bcryptService := services.NewBcryptService(10)
-	hashedPassword, err := bcryptService.HashPassword(user.Password)
+	hashedPassword, err := v.passwordHasher.HashPassword(user.Password)
 	if err != nil {
 		return nil, err
 	}
 	user.Password = hashedPassword
 	
-	// Generate verification token
-	verificationToken, err := v.emailService.GenerateVerificationToken()
+	// Create user in database
+	createdUser, err := v.userRepo.CreateUser(user)
 	if err != nil {
 		return nil, err
 	}
 	
-	// Store verification token in user (we'll use ResetToken field for this)
-	user.ResetToken = &verificationToken
-	
-	// Set token expiration (24 hours)
-	expiresAt := time.Now().Add(24 * time.Hour)
-	user.ResetTokenExpiresAt = &expiresAt
-	
-	// Create user in database
-	createdUser, err := v.userRepo.CreateUser(user)
+	// Generate verification token (expires in 24 hours)
+	verificationToken, err := v.oneTimeTokens.Issue(entities.TokenPurposeVerifyEmail, createdUser.ID.Hex(), createdUser.Email, "", verificationTokenTTL)
 	if err != nil {
 		return nil, err
 	}
@@ -70,23 +78,26 @@ func (v *VerificationUsecase) RegisterWithVerification(user *entities.User) (*en
 	
 	// Don't expose sensitive data
 	createdUser.Password = ""
-	createdUser.ResetToken = nil
-	createdUser.ResetTokenExpiresAt = nil
 	
 	return createdUser, nil
 }
 
 // VerifyEmail verifies a user's email using the verification token
-func (v *VerificationUsecase) VerifyEmail(token string) error {
-	// Find user by verification token
-	user, err := v.userRepo.GetUserByResetToken(token)
+func (v *VerificationUsecase) VerifyEmail(token string, client entities.ClientInfo) error {
+	// Redeem the token; it cannot be used again
+	verification, err := v.oneTimeTokens.Consume(entities.TokenPurposeVerifyEmail, token, "")
 	if err != nil {
-		return errors.New("invalid verification token")
+		v.securityEvents.Record(entities.SecurityEvent{
+			Type:    entities.SecurityEventEmailVerification,
+			Outcome: entities.OutcomeFailure,
+			Details: "invalid verification token",
+		}, client)
+		return errors.New("invalid or expired verification token")
 	}
 	
-	// Check if token is expired
-	if user.ResetTokenExpiresAt != nil && time.Now().After(*user.ResetTokenExpiresAt) {
-		return errors.New("verification token has expired")
+	user, err := v.userRepo.GetUserByID(verification.UserID)
+	if err != nil {
+		return errors.New("invalid or expired verification token")
 	}
 	
 	// Update user verification status
@@ -94,12 +105,13 @@ func (v *VerificationUsecase) VerifyEmail(token string) error {
 	if err != nil {
 		return err
 	}
-	
-	// Clear the verification token
-	err = v.userRepo.UpdateResetToken(user.ID.Hex(), nil, nil)
-	if err != nil {
-		return err
-	}
+
+	v.securityEvents.Record(entities.SecurityEvent{
+		Type:     entities.SecurityEventEmailVerification,
+		Outcome:  entities.OutcomeSuccess,
+		ActorID:  user.ID.Hex(),
+		TargetID: user.ID.Hex(),
+	}, client)
 	
 	// Send welcome email
 	username := user.Username
@@ -132,17 +144,8 @@ func (v *VerificationUsecase) ResendVerificationEmail(email string) error {
 		return errors.New("user is already verified")
 	}
 	
-	// Generate new verification token
-	verificationToken, err := v.emailService.GenerateVerificationToken()
-	if err != nil {
-		return err
-	}
-	
-	// Set token expiration (24 hours)
-	expiresAt := time.Now().Add(24 * time.Hour)
-	
-	// Update user with new verification token
-	err = v.userRepo.UpdateResetToken(user.ID.Hex(), &verificationToken, &expiresAt)
+	// Generate new verification token; the previous link stops working
+	verificationToken, err := v.oneTimeTokens.Issue(entities.TokenPurposeVerifyEmail, user.ID.Hex(), user.Email, "", verificationTokenTTL)
 	if err != nil {
 		return err
 	}
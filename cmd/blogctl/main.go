@@ -0,0 +1,327 @@
+// Command blogctl runs administrative tasks against the configured database,
+// such as creating the first admin. It reads the same environment and .env
+// file as the API server.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"g6_starter_project/Domain/entities"
+	"g6_starter_project/Infrastructure/config"
+	"g6_starter_project/Infrastructure/mongodb/migrations"
+	"g6_starter_project/Infrastructure/mongodb/repositories"
+	"g6_starter_project/Infrastructure/services"
+	usecases "g6_starter_project/Usecases"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/term"
+)
+
+const usage = `Usage: blogctl <command> [flags]
+
+Commands:
+  create-admin     -name <full name> -username <username> -email <email>
+  promote          -user <id|email|username> [-role <role>] [-force]
+  demote           -user <id|email|username> [-force]
+  verify           -user <id|email|username>
+  revoke-sessions  -user <id|email|username> [-force]
+  reset-password   -user <id|email|username> [-force]
+  migrate
+
+Passwords are read from BLOGCTL_PASSWORD, or from standard input if it is not set.
+
+Commands that sign a user out need REDIS_ADDR set to the server's Redis, so
+the server sees the revoked access tokens. -force runs them without it.
+`
+
+// client identifies blogctl in the audit log
+var client = entities.ClientInfo{UserAgent: "blogctl"}
+
+// app holds what the commands need, wired the same way as the API server
+type app struct {
+	database      *mongo.Database
+	tokenHasher   *services.TokenHasher
+	tokens        *usecases.TokenUsecase
+	management    *usecases.UserManagementUsecase
+	accountAdmins *usecases.AccountAdminUsecase
+
+	// sharedRevocations is set when revoked tokens go to Redis, where the
+	// server reads them, rather than to memory that is lost when blogctl exits
+	sharedRevocations bool
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	commands := map[string]func(*app, []string) error{
+		"create-admin":    createAdmin,
+		"promote":         promote,
+		"demote":          demote,
+		"verify":          verify,
+		"revoke-sessions": revokeSessions,
+		"reset-password":  resetPassword,
+		"migrate":         migrate,
+	}
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	config.LoadEnvVariables()
+
+	mongoURI, databaseName, _ := config.GetAppConfig()
+	mongoClient := config.ConnectToMongoDB(mongoURI)
+	defer mongoClient.Disconnect(context.TODO())
+
+	a := newApp(mongoClient.Database(databaseName))
+	if err := command(a, os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		mongoClient.Disconnect(context.TODO())
+		os.Exit(1)
+	}
+}
+
+func newApp(database *mongo.Database) *app {
+	tokenHasher := services.NewTokenHasher(config.GetTokenHashKey())
+
+	// Repositories
+	userRepository := repositories.NewUserRepository(database.Collection("users"))
+	tokenRepository := repositories.NewTokenRepository(database.Collection("token"))
+	impersonationTokenRepository := repositories.NewImpersonationTokenRepository(database.Collection("impersonation_tokens"))
+	roleRepository := repositories.NewRoleRepository(database.Collection("roles"))
+	securityEventRepository := repositories.NewSecurityEventRepository(database.Collection("security_events"))
+
+	// Services
+	emailService := services.NewEmailService()
+	revocationStore := config.NewRevocationStore()
+	passwordHasher := config.SetupPasswordHasher()
+	passwordPolicy := config.SetupPasswordPolicy()
+
+	// UseCases. blogctl never issues tokens, so the token usecase needs no JWT service.
+	roleUseCase := usecases.NewRoleUsecase(roleRepository, userRepository)
+	if err := roleUseCase.EnsureDefaultRoles(context.TODO()); err != nil {
+		log.Fatal("Failed to create default roles:", err)
+	}
+	securityEventUseCase := usecases.NewSecurityEventUsecase(securityEventRepository)
+	tokenUseCase := usecases.NewTokenUsecase(tokenRepository, impersonationTokenRepository, userRepository, nil, revocationStore, tokenHasher)
+	userManagementUseCase := usecases.NewUserManagementUsecase(userRepository, tokenUseCase, roleUseCase, emailService, securityEventUseCase)
+	accountAdminUseCase := usecases.NewAccountAdminUsecase(userRepository, tokenUseCase, emailService, passwordHasher, passwordPolicy, securityEventUseCase)
+
+	return &app{
+		database:      database,
+		tokenHasher:   tokenHasher,
+		tokens:        tokenUseCase,
+		management:    userManagementUseCase,
+		accountAdmins: accountAdminUseCase,
+
+		sharedRevocations: os.Getenv("REDIS_ADDR") != "",
+	}
+}
+
+// checkRevocations refuses to sign a user out when the revoked access tokens
+// would only be kept in blogctl's memory, unless force is set. Without Redis
+// the refresh tokens are still revoked, but access tokens already issued keep
+// working until they expire.
+func (a *app) checkRevocations(force bool) error {
+	if a.sharedRevocations {
+		return nil
+	}
+	if !force {
+		return errors.New("REDIS_ADDR is not set, so the server would not see the revoked access tokens and they would keep working until they expire. Set REDIS_ADDR to the server's Redis, or pass -force to go ahead anyway")
+	}
+	fmt.Fprintln(os.Stderr, "Warning: REDIS_ADDR is not set, access tokens already issued keep working until they expire")
+	return nil
+}
+
+func createAdmin(a *app, args []string) error {
+	flags := flag.NewFlagSet("create-admin", flag.ExitOnError)
+	fullName := flags.String("name", "", "full name of the admin")
+	username := flags.String("username", "", "username of the admin")
+	email := flags.String("email", "", "email address of the admin")
+	flags.Parse(args)
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+
+	user, err := a.accountAdmins.CreateAdmin(*fullName, *username, *email, password, client)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created admin %s <%s> with ID %s\n", user.Username, user.Email, user.ID.Hex())
+	return nil
+}
+
+func promote(a *app, args []string) error {
+	flags := flag.NewFlagSet("promote", flag.ExitOnError)
+	ref := flags.String("user", "", "ID, email or username of the user")
+	role := flags.String("role", entities.RoleAdmin, "role to give the user")
+	force := flags.Bool("force", false, "sign the user out even without a shared revocation store")
+	flags.Parse(args)
+
+	// Only a role other than admin signs the user out
+	if *role != entities.RoleAdmin {
+		if err := a.checkRevocations(*force); err != nil {
+			return err
+		}
+	}
+
+	user, err := a.accountAdmins.FindUser(*ref)
+	if err != nil {
+		return err
+	}
+
+	if *role == entities.RoleAdmin {
+		user, err = a.management.PromoteUser("", user.ID.Hex(), client)
+	} else {
+		user, err = a.management.AssignRole("", user.ID.Hex(), *role, client)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s now has the %s role\n", user.Username, user.Role)
+	return nil
+}
+
+func demote(a *app, args []string) error {
+	flags := flag.NewFlagSet("demote", flag.ExitOnError)
+	ref := flags.String("user", "", "ID, email or username of the user")
+	force := flags.Bool("force", false, "sign the user out even without a shared revocation store")
+	flags.Parse(args)
+
+	if err := a.checkRevocations(*force); err != nil {
+		return err
+	}
+
+	user, err := a.accountAdmins.FindUser(*ref)
+	if err != nil {
+		return err
+	}
+
+	user, err = a.management.DemoteUser("", user.ID.Hex(), client)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s now has the %s role and was signed out everywhere\n", user.Username, user.Role)
+	return nil
+}
+
+func verify(a *app, args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	ref := flags.String("user", "", "ID, email or username of the user")
+	flags.Parse(args)
+
+	user, err := a.accountAdmins.FindUser(*ref)
+	if err != nil {
+		return err
+	}
+
+	user, err = a.accountAdmins.VerifyUser(user.ID.Hex(), client)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Verified %s <%s>\n", user.Username, user.Email)
+	return nil
+}
+
+func revokeSessions(a *app, args []string) error {
+	flags := flag.NewFlagSet("revoke-sessions", flag.ExitOnError)
+	ref := flags.String("user", "", "ID, email or username of the user")
+	force := flags.Bool("force", false, "sign the user out even without a shared revocation store")
+	flags.Parse(args)
+
+	if err := a.checkRevocations(*force); err != nil {
+		return err
+	}
+
+	user, err := a.accountAdmins.FindUser(*ref)
+	if err != nil {
+		return err
+	}
+
+	if err := a.tokens.RevokeAllSessions(user.ID.Hex()); err != nil {
+		return err
+	}
+	fmt.Printf("Signed %s out everywhere\n", user.Username)
+	return nil
+}
+
+func resetPassword(a *app, args []string) error {
+	flags := flag.NewFlagSet("reset-password", flag.ExitOnError)
+	ref := flags.String("user", "", "ID, email or username of the user")
+	force := flags.Bool("force", false, "sign the user out even without a shared revocation store")
+	flags.Parse(args)
+
+	if err := a.checkRevocations(*force); err != nil {
+		return err
+	}
+
+	user, err := a.accountAdmins.FindUser(*ref)
+	if err != nil {
+		return err
+	}
+
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+
+	if err := a.accountAdmins.ResetPassword(user.ID.Hex(), password, client); err != nil {
+		return err
+	}
+	fmt.Printf("Reset the password of %s and signed them out everywhere\n", user.Username)
+	return nil
+}
+
+func migrate(a *app, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	flags.Parse(args)
+
+	if err := migrations.Migrate(context.TODO(), a.database, a.tokenHasher); err != nil {
+		return err
+	}
+	fmt.Println("Database is up to date")
+	return nil
+}
+
+// readPassword takes the password from BLOGCTL_PASSWORD so it stays out of the
+// shell history, or reads it from standard input. A terminal does not echo it;
+// from a pipe a single line is read.
+func readPassword() (string, error) {
+	if password := os.Getenv("BLOGCTL_PASSWORD"); password != "" {
+		return password, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		fmt.Fprint(os.Stderr, "Password: ")
+		password, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil || len(password) == 0 {
+			return "", errors.New("no password given")
+		}
+		return string(password), nil
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", errors.New("no password given")
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("no password given")
+	}
+	return password, nil
+}
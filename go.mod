@@ -11,7 +11,7 @@ require (
 	github.com/stretchr/testify v1.10.0
 	go.mongodb.org/mongo-driver v1.17.4
 	golang.org/x/crypto v0.26.0
-
+	golang.org/x/term v0.23.0
 )
 
 require (